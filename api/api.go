@@ -218,13 +218,35 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/contracts", api.hostContractsHandler)
+		router.GET("/host/denylist", api.hostDenylistHandlerGET)
+		router.POST("/host/denylist/add", RequirePassword(api.hostDenylistAddHandler, requiredPassword))
+		router.POST("/host/denylist/remove", RequirePassword(api.hostDenylistRemoveHandler, requiredPassword))
+		router.GET("/host/maintenance", api.hostMaintenanceHandlerGET)
+		router.POST("/host/maintenance", RequirePassword(api.hostMaintenanceHandlerPOST, requiredPassword))
+		router.POST("/host/maintenance/evacuate", RequirePassword(api.hostMaintenanceEvacuateHandler, requiredPassword))
+		router.GET("/host/metrics/history", api.hostMetricsHistoryHandler)
+		router.GET("/host/proofcheck", api.hostProofCheckHandlerGET)
+		router.POST("/host/proofcheck/run", RequirePassword(api.hostProofCheckRunHandler, requiredPassword))
+		router.GET("/host/pricing", api.hostPricingHandlerGET)
+		router.POST("/host/pricing", RequirePassword(api.hostPricingHandlerPOST, requiredPassword))
+		router.GET("/host/pricing/preview", api.hostPricingPreviewHandler)
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
+		router.POST("/host/storage/folders/move", RequirePassword(api.storageFoldersMoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
+		router.POST("/host/storage/folders/tier", RequirePassword(api.storageFoldersTierHandler, requiredPassword))
+		router.GET("/host/storage/sectors", api.storageSectorsHandlerGET)
+		router.GET("/host/storage/sectors/:merkleroot", api.storageSectorHandlerGET)
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.GET("/host/storage/check", api.storageCheckHandlerGET)
+		router.GET("/host/storage/scrub", api.storageScrubHandlerGET)
+		router.POST("/host/storage/scrub/start", RequirePassword(api.storageScrubStartHandler, requiredPassword))
+		router.POST("/host/storage/scrub/stop", RequirePassword(api.storageScrubStopHandler, requiredPassword))
+		router.GET("/host/storage/wal", api.storageWALHandlerGET)
 	}
 
 	// Miner API Calls
@@ -244,6 +266,8 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/prices", api.renterPricesHandler)
+		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 
 		// TODO: re-enable these routes once the new .sia format has been
 		// standardized and implemented.
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strings"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
@@ -21,6 +25,10 @@ var (
 	// storage folder which does not appear to exist within the storage
 	// manager.
 	errStorageFolderNotFound = errors.New("storage folder with the provided path could not be found")
+
+	// defaultSectorsLimit is the number of sectors returned by
+	// /host/storage/sectors if no limit is provided.
+	defaultSectorsLimit = uint64(100)
 )
 
 type (
@@ -35,6 +43,18 @@ type (
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
 	}
 
+	// HostContractsGET contains the storage obligations of the host, returned
+	// by a GET request to /host/contracts.
+	HostContractsGET struct {
+		Contracts []modules.StorageObligation `json:"contracts"`
+	}
+
+	// HostDenylistGET contains the host's denylist, returned by a GET request
+	// to /host/denylist.
+	HostDenylistGET struct {
+		modules.HostDenylist
+	}
+
 	// HostEstimateScoreGET contains the information that is returned from a
 	// /host/estimatescore call.
 	HostEstimateScoreGET struct {
@@ -42,11 +62,79 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostMaintenanceGET contains the maintenance status of the host,
+	// returned by a GET request to /host/maintenance.
+	HostMaintenanceGET struct {
+		modules.HostMaintenanceStatus
+	}
+
+	// HostMetricsHistoryGET contains the snapshots of the host's metrics,
+	// returned by a GET request to /host/metrics/history.
+	HostMetricsHistoryGET struct {
+		Snapshots []modules.HostMetricsSnapshot `json:"snapshots"`
+	}
+
+	// HostPricingGET contains the configuration of the host's automatic
+	// pricing engine, returned by a GET request to /host/pricing.
+	HostPricingGET struct {
+		Settings modules.HostPricingSettings `json:"settings"`
+	}
+
+	// HostPricingPreviewGET contains the prices that the host's automatic
+	// pricing engine would set if it ran now, returned by a GET request to
+	// /host/pricing/preview.
+	HostPricingPreviewGET struct {
+		modules.HostPricePreview
+	}
+
+	// HostProofCheckGET contains the result of the most recent storage proof
+	// readiness self-check, returned by a GET request to /host/proofcheck.
+	HostProofCheckGET struct {
+		modules.HostProofReadiness
+	}
+
+	// StorageCheckGET contains the result of a consistency check of the
+	// storage manager, returned by a GET request to /host/storage/check.
+	StorageCheckGET struct {
+		modules.StorageConsistencyReport
+	}
+
+	// StorageScrubGET contains the progress of the background sector
+	// scrubber, returned by a GET request to /host/storage/scrub.
+	StorageScrubGET struct {
+		modules.StorageScrubStatus
+	}
+
+	// StorageWALGET contains the size and the last checkpoint of the storage
+	// manager's write-ahead log, returned by a GET request to
+	// /host/storage/wal.
+	StorageWALGET struct {
+		modules.WALStatus
+	}
+
+	// StorageSectorGET contains the location of a sector and the storage
+	// obligations that reference it, returned by a GET request to
+	// /host/storage/sectors/:merkleroot.
+	StorageSectorGET struct {
+		modules.HostSectorLookup
+	}
+
+	// StorageSectorsGET contains the sector statistics of each storage folder,
+	// returned by a GET request to /host/storage/sectors. If a storage folder
+	// is requested, Sectors contains a page of its sectors and Total the
+	// number of sectors in the folder.
+	StorageSectorsGET struct {
+		Folders []modules.StorageFolderUsage `json:"folders"`
+		Sectors []modules.StoredSector       `json:"sectors"`
+		Total   uint64                       `json:"total"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
-		Folders []modules.StorageFolderMetadata `json:"folders"`
+		Folders     []modules.StorageFolderMetadata `json:"folders"`
+		SectorCache modules.StorageCacheMetrics     `json:"sectorcache"`
 	}
 )
 
@@ -119,6 +207,54 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MaxReviseBatchSize = x
 	}
+	if req.FormValue("maxdownloadspeed") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxdownloadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxDownloadSpeed = x
+	}
+	if req.FormValue("maxuploadspeed") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxuploadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxUploadSpeed = x
+	}
+	if req.FormValue("maxconnections") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxconnections"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxConnections = x
+	}
+	if req.FormValue("maxconnectionsperip") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxconnectionsperip"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxConnectionsPerIP = x
+	}
+	if req.FormValue("metricssnapshotinterval") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("metricssnapshotinterval"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MetricsSnapshotInterval = x
+	}
+	if req.FormValue("sectorcachesize") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("sectorcachesize"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.SectorCacheSize = x
+	}
 	if req.FormValue("netaddress") != "" {
 		var x modules.NetAddress
 		_, err := fmt.Sscan(req.FormValue("netaddress"), &x)
@@ -160,6 +296,63 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MaxCollateral = x
 	}
+	if req.FormValue("feebumpcap") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("feebumpcap"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.FeeBumpCap = x
+	}
+
+	if req.FormValue("minduration") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("minduration"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MinDuration = x
+	}
+	if req.FormValue("mincontractpayout") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("mincontractpayout"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MinContractPayout = x
+	}
+	if req.FormValue("maxcollateralratio") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("maxcollateralratio"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxCollateralRatio = x
+	}
+	if req.FormValue("maxcontractsperrenter") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxcontractsperrenter"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxContractsPerRenter = x
+	}
+	if req.FormValue("collateralperiod") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("collateralperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.CollateralPeriod = x
+	}
+	if req.FormValue("periodcollateralbudget") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("periodcollateralbudget"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeriodCollateralBudget = x
+	}
 
 	if req.FormValue("mincontractprice") != "" {
 		var x types.Currency
@@ -264,6 +457,314 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 	WriteSuccess(w)
 }
 
+// hostContractsHandler handles GET requests to /host/contracts, returning the
+// storage obligations of the host. If the status parameter is provided, only
+// obligations with that status are returned.
+func (api *API) hostContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status := req.FormValue("status")
+	switch status {
+	case "", "unresolved", "rejected", "succeeded", "failed":
+	default:
+		WriteError(w, Error{"status must be one of unresolved, rejected, succeeded or failed"}, http.StatusBadRequest)
+		return
+	}
+	contracts := []modules.StorageObligation{}
+	for _, so := range api.host.StorageObligations() {
+		if status == "" || so.Status == status {
+			contracts = append(contracts, so)
+		}
+	}
+	WriteJSON(w, HostContractsGET{
+		Contracts: contracts,
+	})
+}
+
+// parseDenylist returns the denylist entries given in the subnets and
+// renterkeys parameters of a request. Both parameters are comma separated
+// lists.
+func parseDenylist(req *http.Request) (dl modules.HostDenylist, err error) {
+	if subnets := req.FormValue("subnets"); subnets != "" {
+		dl.Subnets = strings.Split(subnets, ",")
+	}
+	if keys := req.FormValue("renterkeys"); keys != "" {
+		for _, k := range strings.Split(keys, ",") {
+			var spk types.SiaPublicKey
+			spk.LoadString(k)
+			if len(spk.Key) == 0 {
+				return modules.HostDenylist{}, errors.New("could not parse renter key " + k)
+			}
+			dl.RenterKeys = append(dl.RenterKeys, spk)
+		}
+	}
+	if len(dl.Subnets) == 0 && len(dl.RenterKeys) == 0 {
+		return modules.HostDenylist{}, errors.New("at least one of subnets or renterkeys is required")
+	}
+	return dl, nil
+}
+
+// hostDenylistHandlerGET handles GET requests to /host/denylist, returning
+// the IP subnets and renter keys that the host refuses to do business with.
+func (api *API) hostDenylistHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostDenylistGET{api.host.Denylist()})
+}
+
+// hostDenylistAddHandler handles POST requests to /host/denylist/add, adding
+// entries to the host's denylist.
+func (api *API) hostDenylistAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dl, err := parseDenylist(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.AddToDenylist(dl)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostDenylistRemoveHandler handles POST requests to /host/denylist/remove,
+// removing entries from the host's denylist.
+func (api *API) hostDenylistRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dl, err := parseDenylist(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.RemoveFromDenylist(dl)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostMaintenanceHandlerGET handles GET requests to /host/maintenance,
+// returning the maintenance status of the host and its drain estimate.
+func (api *API) hostMaintenanceHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostMaintenanceGET{api.host.MaintenanceStatus()})
+}
+
+// hostMaintenanceHandlerPOST handles POST requests to /host/maintenance,
+// enabling or disabling maintenance mode.
+func (api *API) hostMaintenanceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var enabled bool
+	_, err := fmt.Sscan(req.FormValue("enabled"), &enabled)
+	if err != nil {
+		WriteError(w, Error{"could not parse enabled: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.SetMaintenance(enabled)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostMaintenanceEvacuateHandler handles POST requests to
+// /host/maintenance/evacuate, moving the data off of a storage folder and
+// removing it from the host.
+func (api *API) hostMaintenanceEvacuateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, errNoPath, http.StatusBadRequest)
+		return
+	}
+	err := api.host.EvacuateStorageFolder(folderPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostMetricsHistoryHandler handles GET requests to /host/metrics/history,
+// returning the snapshots of the host's metrics between the from and to
+// heights. If format is "csv", the snapshots are written as CSV with one row
+// per snapshot.
+func (api *API) hostMetricsHistoryHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	from, to := types.BlockHeight(0), types.BlockHeight(math.MaxUint64)
+	if req.FormValue("from") != "" {
+		if _, err := fmt.Sscan(req.FormValue("from"), &from); err != nil {
+			WriteError(w, Error{"could not parse from: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("to") != "" {
+		if _, err := fmt.Sscan(req.FormValue("to"), &to); err != nil {
+			WriteError(w, Error{"could not parse to: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	format := req.FormValue("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, Error{"format must be json or csv"}, http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := api.host.MetricsHistory(from, to)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"metrics.csv\"")
+		writeMetricsCSV(w, snapshots)
+		return
+	}
+	if snapshots == nil {
+		snapshots = []modules.HostMetricsSnapshot{}
+	}
+	WriteJSON(w, HostMetricsHistoryGET{
+		Snapshots: snapshots,
+	})
+}
+
+// writeMetricsCSV writes a set of metrics snapshots as CSV, with a header
+// row naming each column after its JSON field.
+func writeMetricsCSV(w io.Writer, snapshots []modules.HostMetricsSnapshot) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"height", "timestamp",
+		"contractcount", "contractcompensation", "potentialcontractcompensation",
+		"lockedstoragecollateral", "lostrevenue", "loststoragecollateral",
+		"potentialstoragerevenue", "riskedstoragecollateral", "storagerevenue",
+		"transactionfeeexpenses", "downloadbandwidthrevenue",
+		"potentialdownloadbandwidthrevenue", "potentialuploadbandwidthrevenue",
+		"uploadbandwidthrevenue",
+		"downloadcalls", "errorcalls", "formcontractcalls", "renewcalls",
+		"revisecalls", "sectorrootscalls", "settingscalls", "unrecognizedcalls",
+		"rejectedconnections", "rejectedipconnections",
+		"deniedconnections", "deniedrenters", "securesessions", "policyrejections",
+	})
+	for _, s := range snapshots {
+		fm, nm := s.FinancialMetrics, s.NetworkMetrics
+		cw.Write([]string{
+			fmt.Sprint(s.Height), fmt.Sprint(s.Timestamp),
+			fmt.Sprint(fm.ContractCount), fm.ContractCompensation.String(), fm.PotentialContractCompensation.String(),
+			fm.LockedStorageCollateral.String(), fm.LostRevenue.String(), fm.LostStorageCollateral.String(),
+			fm.PotentialStorageRevenue.String(), fm.RiskedStorageCollateral.String(), fm.StorageRevenue.String(),
+			fm.TransactionFeeExpenses.String(), fm.DownloadBandwidthRevenue.String(),
+			fm.PotentialDownloadBandwidthRevenue.String(), fm.PotentialUploadBandwidthRevenue.String(),
+			fm.UploadBandwidthRevenue.String(),
+			fmt.Sprint(nm.DownloadCalls), fmt.Sprint(nm.ErrorCalls), fmt.Sprint(nm.FormContractCalls), fmt.Sprint(nm.RenewCalls),
+			fmt.Sprint(nm.ReviseCalls), fmt.Sprint(nm.SectorRootsCalls), fmt.Sprint(nm.SettingsCalls), fmt.Sprint(nm.UnrecognizedCalls),
+			fmt.Sprint(nm.RejectedConnections), fmt.Sprint(nm.RejectedIPConnections),
+			fmt.Sprint(nm.DeniedConnections), fmt.Sprint(nm.DeniedRenters), fmt.Sprint(nm.SecureSessions), fmt.Sprint(nm.PolicyRejections),
+		})
+	}
+	cw.Flush()
+}
+
+// hostProofCheckHandlerGET handles GET requests to /host/proofcheck,
+// returning the result of the most recent storage proof readiness self-check.
+func (api *API) hostProofCheckHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	pr := api.host.ProofReadiness()
+	if pr.Risks == nil {
+		pr.Risks = []modules.HostProofRisk{}
+	}
+	WriteJSON(w, HostProofCheckGET{pr})
+}
+
+// hostProofCheckRunHandler handles POST requests to /host/proofcheck/run,
+// running the storage proof readiness self-check immediately.
+func (api *API) hostProofCheckRunHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	_, err := api.host.CheckProofReadiness()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostPricingHandlerGET handles GET requests to /host/pricing, returning the
+// configuration of the automatic pricing engine.
+func (api *API) hostPricingHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPricingGET{
+		Settings: api.host.PricingSettings(),
+	})
+}
+
+// hostPricingHandlerPOST handles POST requests to /host/pricing, which
+// configure the automatic pricing engine. Parameters that are not provided
+// keep their current values.
+func (api *API) hostPricingHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ps := api.host.PricingSettings()
+	if req.FormValue("strategy") != "" {
+		ps.Strategy = modules.HostPricingStrategy(req.FormValue("strategy"))
+	}
+	if req.FormValue("updateinterval") != "" {
+		_, err := fmt.Sscan(req.FormValue("updateinterval"), &ps.UpdateInterval)
+		if err != nil {
+			WriteError(w, Error{"could not parse updateinterval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("targetutilization") != "" {
+		_, err := fmt.Sscan(req.FormValue("targetutilization"), &ps.TargetUtilization)
+		if err != nil {
+			WriteError(w, Error{"could not parse targetutilization: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("networkoffset") != "" {
+		_, err := fmt.Sscan(req.FormValue("networkoffset"), &ps.NetworkOffset)
+		if err != nil {
+			WriteError(w, Error{"could not parse networkoffset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	prices := []struct {
+		param string
+		dest  *types.Currency
+	}{
+		{"basecollateral", &ps.BasePrices.Collateral},
+		{"basemindownloadbandwidthprice", &ps.BasePrices.MinDownloadBandwidthPrice},
+		{"baseminstorageprice", &ps.BasePrices.MinStoragePrice},
+		{"baseminuploadbandwidthprice", &ps.BasePrices.MinUploadBandwidthPrice},
+		{"collateralfloor", &ps.CollateralFloor},
+		{"collateralceiling", &ps.CollateralCeiling},
+		{"downloadbandwidthpricefloor", &ps.DownloadBandwidthPriceFloor},
+		{"downloadbandwidthpriceceiling", &ps.DownloadBandwidthPriceCeiling},
+		{"storagepricefloor", &ps.StoragePriceFloor},
+		{"storagepriceceiling", &ps.StoragePriceCeiling},
+		{"uploadbandwidthpricefloor", &ps.UploadBandwidthPriceFloor},
+		{"uploadbandwidthpriceceiling", &ps.UploadBandwidthPriceCeiling},
+	}
+	for _, b := range prices {
+		if req.FormValue(b.param) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(b.param), b.dest)
+		if err != nil {
+			WriteError(w, Error{"could not parse " + b.param + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := api.host.SetPricingSettings(ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostPricingPreviewHandler handles GET requests to /host/pricing/preview,
+// returning the prices that the automatic pricing engine would set if it ran
+// now.
+func (api *API) hostPricingPreviewHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	pp, err := api.host.PricePreview()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostPricingPreviewGET{pp})
+}
+
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -284,10 +785,54 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	WriteJSON(w, StorageGET{
-		Folders: api.host.StorageFolders(),
+		Folders:     api.host.StorageFolders(),
+		SectorCache: api.host.SectorCacheMetrics(),
 	})
 }
 
+// storageCheckHandlerGET checks the storage manager for inconsistencies
+// without changing anything.
+func (api *API) storageCheckHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	report, err := api.host.CheckStorageConsistency()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, StorageCheckGET{report})
+}
+
+// storageScrubHandlerGET returns the progress of the background sector
+// scrubber.
+func (api *API) storageScrubHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, StorageScrubGET{api.host.ScrubStatus()})
+}
+
+// storageScrubStartHandler starts a scrub of every sector on the host.
+func (api *API) storageScrubStartHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.host.StartScrub()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// storageScrubStopHandler stops the scrub that is in progress.
+func (api *API) storageScrubStopHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.host.StopScrub()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// storageWALHandlerGET returns the status of the storage manager's
+// write-ahead log.
+func (api *API) storageWALHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, StorageWALGET{api.host.WALStatus()})
+}
+
 // storageFoldersAddHandler adds a storage folder to the storage manager.
 func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -334,6 +879,34 @@ func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageFoldersMoveHandler moves a storage folder in the storage manager to a
+// new path.
+func (api *API) storageFoldersMoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	newPath := req.FormValue("newpath")
+	if newPath == "" {
+		WriteError(w, Error{"newpath parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.MoveStorageFolder(uint16(folderIndex), newPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -359,6 +932,90 @@ func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageFoldersTierHandler sets the storage tier of a storage folder.
+func (api *API) storageFoldersTierHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	tier := req.FormValue("tier")
+	if tier != modules.StorageTierFast && tier != modules.StorageTierSlow {
+		WriteError(w, Error{"tier parameter must be either 'fast' or 'slow'"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.SetStorageFolderTier(uint16(folderIndex), tier)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// storageSectorsHandlerGET handles GET requests to /host/storage/sectors,
+// returning the sector statistics of each storage folder and, if a storage
+// folder path is provided, a page of the sectors stored in that folder.
+func (api *API) storageSectorsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ssg := StorageSectorsGET{
+		Folders: api.host.StorageFolderUsage(),
+		Sectors: []modules.StoredSector{},
+	}
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteJSON(w, ssg)
+		return
+	}
+	folderIndex, err := folderIndex(folderPath, api.host.StorageFolders())
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var offset uint64
+	limit := defaultSectorsLimit
+	if req.FormValue("offset") != "" {
+		if _, err := fmt.Sscan(req.FormValue("offset"), &offset); err != nil {
+			WriteError(w, Error{"error parsing offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("limit") != "" {
+		if _, err := fmt.Sscan(req.FormValue("limit"), &limit); err != nil {
+			WriteError(w, Error{"error parsing limit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	ssg.Sectors, ssg.Total, err = api.host.StorageFolderSectors(uint16(folderIndex), offset, limit)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ssg)
+}
+
+// storageSectorHandlerGET handles GET requests to
+// /host/storage/sectors/:merkleroot, returning the location of the sector and
+// the storage obligations that reference it.
+func (api *API) storageSectorHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	sectorRoot, err := scanHash(ps.ByName("merkleroot"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	lookup, err := api.host.LookupSector(sectorRoot)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, StorageSectorGET{lookup})
+}
+
 // storageSectorsDeleteHandler handles the call to delete a sector from the
 // storage manager.
 func (api *API) storageSectorsDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
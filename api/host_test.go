@@ -1,12 +1,15 @@
 package api
 
 import (
+	"bytes"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -16,6 +19,7 @@ import (
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
 	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
 )
 
 var (
@@ -1002,3 +1006,666 @@ func TestDeleteNonexistentSector(t *testing.T) {
 		t.Fatalf("expected error to be %v; got %v", crypto.ErrHashWrongLen, err)
 	}
 }
+
+// TestHostPricing checks that the automatic pricing engine can be configured
+// and previewed through the API.
+func TestHostPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should default to manual pricing, which previews the current
+	// prices.
+	var hpg HostPricingGET
+	if err := st.getAPI("/host/pricing", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Settings.Strategy != modules.HostPricingManual {
+		t.Fatal("expected manual pricing, got", hpg.Settings.Strategy)
+	}
+	var pp HostPricingPreviewGET
+	if err := st.getAPI("/host/pricing/preview", &pp); err != nil {
+		t.Fatal(err)
+	}
+	if !pp.Next.MinStoragePrice.Equals(pp.Current.MinStoragePrice) {
+		t.Fatal("manual pricing should not change prices")
+	}
+
+	// An unknown strategy should be rejected.
+	pricingValues := url.Values{}
+	pricingValues.Set("strategy", "bogus")
+	if err := st.stdPostAPI("/host/pricing", pricingValues); err == nil {
+		t.Fatal("expected an error when setting an unknown strategy")
+	}
+
+	// Automatic pricing should not be enabled without price floors.
+	current := pp.Current
+	floor := current.MinStoragePrice.MulFloat(0.95)
+	pricingValues = url.Values{}
+	pricingValues.Set("strategy", string(modules.HostPricingUtilization))
+	pricingValues.Set("targetutilization", "0.5")
+	pricingValues.Set("storagepricefloor", floor.String())
+	if err := st.stdPostAPI("/host/pricing", pricingValues); err == nil {
+		t.Fatal("expected an error when enabling automatic pricing without floors")
+	}
+
+	// The host's storage is empty, so the utilization strategy should lower
+	// its prices, but not below the floor.
+	pricingValues.Set("collateralfloor", "1")
+	pricingValues.Set("downloadbandwidthpricefloor", "1")
+	pricingValues.Set("uploadbandwidthpricefloor", "1")
+	if err := st.stdPostAPI("/host/pricing", pricingValues); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		is := st.host.InternalSettings()
+		if !is.MinStoragePrice.Equals(floor) {
+			return errors.New("storage price was not lowered to the floor")
+		}
+		if is.MinUploadBandwidthPrice.Cmp(current.MinUploadBandwidthPrice) >= 0 {
+			return errors.New("upload price was not lowered")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/host/pricing", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Settings.Strategy != modules.HostPricingUtilization || hpg.Settings.TargetUtilization != 0.5 || !hpg.Settings.StoragePriceFloor.Equals(floor) {
+		t.Fatal("pricing settings were not updated:", hpg.Settings)
+	}
+	if !hpg.Settings.BasePrices.MinStoragePrice.Equals(current.MinStoragePrice) {
+		t.Fatal("base prices were not set to the prices at the time pricing was enabled:", hpg.Settings.BasePrices)
+	}
+}
+
+// TestStorageScrub checks that a scrub can be started and monitored through
+// the API.
+func TestStorageScrub(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ssg StorageScrubGET
+	if err := st.getAPI("/host/storage/scrub", &ssg); err != nil {
+		t.Fatal(err)
+	}
+	if ssg.Running || ssg.PassesCompleted != 0 {
+		t.Fatal("no scrub should have run yet")
+	}
+	if err := st.stdPostAPI("/host/storage/scrub/stop", url.Values{}); err == nil {
+		t.Fatal("expected an error when stopping a scrub that is not running")
+	}
+	if err := st.stdPostAPI("/host/storage/scrub/start", url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if err := st.getAPI("/host/storage/scrub", &ssg); err != nil {
+			return err
+		}
+		if ssg.PassesCompleted != 1 {
+			return errors.New("scrub did not complete")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ssg.CorruptSectors != 0 || len(ssg.CorruptSectorRoots) != 0 {
+		t.Fatal("scrub reported corruption on a healthy host")
+	}
+}
+
+// TestHostContracts checks that the host reports the storage obligations
+// formed with a renter, and that the status filter is applied.
+func TestHostContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err := st.announceHost(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.acceptContracts(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should not have any contracts yet.
+	var hc HostContractsGET
+	if err = st.getAPI("/host/contracts", &hc); err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.Contracts) != 0 {
+		t.Fatalf("expected host to have 0 contracts; got %v", len(hc.Contracts))
+	}
+
+	// Set an allowance for the renter, allowing a contract to be formed.
+	allowanceValues := url.Values{}
+	allowanceValues.Set("funds", testFunds)
+	allowanceValues.Set("period", testPeriod)
+	if err = st.stdPostAPI("/renter", allowanceValues); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, time.Millisecond*250, func() error {
+		var hc HostContractsGET
+		if err := st.getAPI("/host/contracts", &hc); err != nil {
+			return err
+		}
+		if len(hc.Contracts) != 1 {
+			return errors.New("no contracts")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rc RenterContracts
+	if err = st.getAPI("/renter/contracts", &rc); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/contracts?status=unresolved", &hc); err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.Contracts) != 1 || len(rc.Contracts) != 1 {
+		t.Fatal("expected one unresolved contract")
+	}
+	so := hc.Contracts[0]
+	if so.ObligationID != rc.Contracts[0].ID {
+		t.Error("obligation id does not match the renter's contract id")
+	}
+	if so.Status != "unresolved" {
+		t.Error("expected unresolved status, got", so.Status)
+	}
+	if so.ExpirationHeight <= so.NegotiationHeight || so.ProofDeadline <= so.ExpirationHeight {
+		t.Error("contract heights are out of order:", so.NegotiationHeight, so.ExpirationHeight, so.ProofDeadline)
+	}
+	if so.ContractCost.IsZero() || !so.RealizedRevenue.IsZero() {
+		t.Error("unexpected revenue for an unresolved contract:", so.ContractCost, so.RealizedRevenue)
+	}
+	if len(so.TransactionIDs) == 0 {
+		t.Error("contract has no transaction ids")
+	}
+
+	// No contracts have succeeded yet, and unknown statuses are rejected.
+	if err = st.getAPI("/host/contracts?status=succeeded", &hc); err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.Contracts) != 0 {
+		t.Fatal("expected no succeeded contracts, got", len(hc.Contracts))
+	}
+	if err = st.getAPI("/host/contracts?status=bogus", &hc); err == nil {
+		t.Fatal("expected an error for an unknown status")
+	}
+}
+
+// TestHostMetricsHistory checks the JSON and CSV forms of the host's metrics
+// history.
+func TestHostMetricsHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var hmh HostMetricsHistoryGET
+	if err = st.getAPI("/host/metrics/history", &hmh); err != nil {
+		t.Fatal(err)
+	}
+	if len(hmh.Snapshots) == 0 {
+		t.Fatal("host did not record any snapshots")
+	}
+	if err = st.getAPI("/host/metrics/history?from=2&to=2", &hmh); err != nil {
+		t.Fatal(err)
+	}
+	if len(hmh.Snapshots) != 1 || hmh.Snapshots[0].Height != 2 {
+		t.Fatal("expected a single snapshot at height 2, got", hmh.Snapshots)
+	}
+	if err = st.getAPI("/host/metrics/history?from=5&to=2", &hmh); err == nil {
+		t.Fatal("expected an error for a reversed range")
+	}
+	if err = st.getAPI("/host/metrics/history?format=xml", &hmh); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+
+	// The CSV form should have a header row and one row per snapshot.
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/host/metrics/history?to=4&format=csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatal("expected a header and 3 snapshots, got", len(records))
+	}
+	if records[0][0] != "height" || records[1][0] != "0" || records[3][0] != "4" {
+		t.Fatal("unexpected CSV contents:", records)
+	}
+}
+
+// TestWriteMetricsCSV checks that the CSV form of the metrics history has a
+// column for every metric in the JSON form.
+func TestWriteMetricsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetricsCSV(&buf, []modules.HostMetricsSnapshot{{Height: 5}})
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(records[1]) {
+		t.Fatal("unexpected CSV contents:", records)
+	}
+
+	// Every metric in the JSON form should have a matching CSV column.
+	columns := make(map[string]bool)
+	for _, c := range records[0] {
+		columns[c] = true
+	}
+	for _, m := range []interface{}{modules.HostFinancialMetrics{}, modules.HostNetworkMetrics{}} {
+		typ := reflect.TypeOf(m)
+		for i := 0; i < typ.NumField(); i++ {
+			if tag := typ.Field(i).Tag.Get("json"); !columns[tag] {
+				t.Error("CSV export is missing column", tag)
+			}
+		}
+	}
+}
+
+// TestHostDenylist checks that entries can be added to and removed from the
+// host's denylist through the API.
+func TestHostDenylist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	renterKey := "ed25519:8408ad8d5e7f605995bdf9ab13e5c0d84fbe1fc610c141e0578c7d26d5cfee75"
+	values := url.Values{}
+	values.Set("subnets", "203.0.113.0/24,198.51.100.7")
+	values.Set("renterkeys", renterKey)
+	if err = st.stdPostAPI("/host/denylist/add", values); err != nil {
+		t.Fatal(err)
+	}
+	var hdg HostDenylistGET
+	if err = st.getAPI("/host/denylist", &hdg); err != nil {
+		t.Fatal(err)
+	}
+	if len(hdg.Subnets) != 2 || hdg.Subnets[1] != "198.51.100.7/32" {
+		t.Fatal("unexpected denied subnets:", hdg.Subnets)
+	}
+	if len(hdg.RenterKeys) != 1 || hdg.RenterKeys[0].String() != renterKey {
+		t.Fatal("unexpected denied renter keys:", hdg.RenterKeys)
+	}
+
+	// Bad and missing entries should be rejected.
+	if err = st.stdPostAPI("/host/denylist/add", url.Values{"subnets": {"bogus"}}); err == nil {
+		t.Fatal("expected an error for a bad subnet")
+	}
+	if err = st.stdPostAPI("/host/denylist/add", url.Values{"renterkeys": {"bogus"}}); err == nil {
+		t.Fatal("expected an error for a bad renter key")
+	}
+	if err = st.stdPostAPI("/host/denylist/remove", url.Values{}); err == nil {
+		t.Fatal("expected an error when no entries are given")
+	}
+
+	values.Set("subnets", "203.0.113.0/24")
+	if err = st.stdPostAPI("/host/denylist/remove", values); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/denylist", &hdg); err != nil {
+		t.Fatal(err)
+	}
+	if len(hdg.Subnets) != 1 || hdg.Subnets[0] != "198.51.100.7/32" || len(hdg.RenterKeys) != 0 {
+		t.Fatal("entries were not removed:", hdg)
+	}
+}
+
+// TestHostMaintenance checks that maintenance mode can be toggled through the
+// API, and that it is reflected in the host's external settings.
+func TestHostMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err = st.acceptContracts(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	var hmg HostMaintenanceGET
+	if err = st.getAPI("/host/maintenance", &hmg); err != nil {
+		t.Fatal(err)
+	}
+	if hmg.Enabled || hmg.UnresolvedObligations != 0 {
+		t.Fatal("unexpected maintenance status:", hmg)
+	}
+
+	// Evacuating a folder requires maintenance mode.
+	var sg StorageGET
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	evacuateValues := url.Values{}
+	evacuateValues.Set("path", sg.Folders[0].Path)
+	if err = st.stdPostAPI("/host/maintenance/evacuate", evacuateValues); err == nil {
+		t.Fatal("expected an error evacuating outside of maintenance mode")
+	}
+
+	if err = st.stdPostAPI("/host/maintenance", url.Values{"enabled": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/maintenance", &hmg); err != nil {
+		t.Fatal(err)
+	}
+	if !hmg.Enabled {
+		t.Fatal("maintenance mode was not enabled")
+	}
+	var hg HostGET
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if hg.ExternalSettings.AcceptingContracts || !hg.InternalSettings.AcceptingContracts {
+		t.Fatal("maintenance mode should only change the advertised settings")
+	}
+	if err = st.stdPostAPI("/host/maintenance/evacuate", url.Values{}); err == nil {
+		t.Fatal("expected an error when no path is given")
+	}
+	if err = st.stdPostAPI("/host/maintenance", url.Values{"enabled": {"false"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if !hg.ExternalSettings.AcceptingContracts {
+		t.Fatal("host should accept contracts after leaving maintenance mode")
+	}
+}
+
+// TestHostProofCheck checks that the storage proof readiness self-check can be
+// run and read through the API.
+func TestHostProofCheck(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err = st.stdPostAPI("/host/proofcheck/run", url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	var hpg HostProofCheckGET
+	if err = st.getAPI("/host/proofcheck", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.CheckTime.IsZero() || hpg.ObligationsChecked != 0 || len(hpg.Risks) != 0 {
+		t.Fatalf("unexpected self-check result: %+v", hpg)
+	}
+}
+
+// TestStorageSectors checks that the sectors of a storage folder can be listed
+// and looked up through the API.
+func TestStorageSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	if err = st.host.AddSector(root, data); err != nil {
+		t.Fatal(err)
+	}
+
+	var ssg StorageSectorsGET
+	if err = st.getAPI("/host/storage/sectors", &ssg); err != nil {
+		t.Fatal(err)
+	}
+	if len(ssg.Folders) != 1 || ssg.Folders[0].PhysicalSectors != 1 || len(ssg.Sectors) != 0 {
+		t.Fatalf("unexpected storage folder usage: %+v", ssg)
+	}
+	if err = st.getAPI("/host/storage/sectors?path="+url.QueryEscape(st.dir)+"&limit=10", &ssg); err != nil {
+		t.Fatal(err)
+	}
+	if ssg.Total != 1 || len(ssg.Sectors) != 1 || ssg.Sectors[0].Count != 1 {
+		t.Fatalf("unexpected sector listing: %+v", ssg)
+	}
+	if err = st.getAPI("/host/storage/sectors?path=/nonexistent", &ssg); err == nil {
+		t.Fatal("expected an error for an unknown storage folder")
+	}
+
+	// The sector does not belong to an obligation, but can still be found.
+	var sg StorageSectorGET
+	if err = st.getAPI("/host/storage/sectors/"+root.String(), &sg); err != nil {
+		t.Fatal(err)
+	}
+	if !sg.Stored || sg.Sector.Root != root || len(sg.Obligations) != 0 {
+		t.Fatalf("unexpected sector lookup: %+v", sg)
+	}
+}
+
+// TestStorageFoldersMove checks that a storage folder can be moved through the
+// API.
+func TestStorageFoldersMove(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	newPath := filepath.Join(st.dir, "moved")
+	if err = os.MkdirAll(newPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new path is required.
+	moveValues := url.Values{}
+	moveValues.Set("path", st.dir)
+	if err = st.stdPostAPI("/host/storage/folders/move", moveValues); err == nil {
+		t.Fatal("expected an error when no new path is provided")
+	}
+	moveValues.Set("newpath", newPath)
+	if err = st.stdPostAPI("/host/storage/folders/move", moveValues); err != nil {
+		t.Fatal(err)
+	}
+	var sg StorageGET
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Folders) != 1 || sg.Folders[0].Path != newPath {
+		t.Fatal("storage folder was not moved:", sg.Folders)
+	}
+}
+
+// TestStorageFoldersTier sets the tier of a storage folder through the API.
+func TestStorageFoldersTier(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	var sg StorageGET
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Folders) != 1 || sg.Folders[0].Tier != modules.StorageTierSlow {
+		t.Fatal("storage folder should be on the slow tier by default:", sg.Folders)
+	}
+
+	// Only the fast and slow tiers are valid.
+	tierValues := url.Values{}
+	tierValues.Set("path", st.dir)
+	tierValues.Set("tier", "medium")
+	if err = st.stdPostAPI("/host/storage/folders/tier", tierValues); err == nil {
+		t.Fatal("expected an error when an invalid tier is provided")
+	}
+	tierValues.Set("tier", modules.StorageTierFast)
+	if err = st.stdPostAPI("/host/storage/folders/tier", tierValues); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if sg.Folders[0].Tier != modules.StorageTierFast {
+		t.Fatal("storage folder tier was not set:", sg.Folders[0].Tier)
+	}
+}
+
+// TestHostSectorCache sets the size of the sector cache through the host
+// settings, and checks that it is reported by /host/storage.
+func TestHostSectorCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var sg StorageGET
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	var hg HostGET
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if sg.SectorCache.Capacity != hg.InternalSettings.SectorCacheSize {
+		t.Fatal("sector cache does not have the default size:", sg.SectorCache.Capacity)
+	}
+
+	settingsValues := url.Values{}
+	settingsValues.Set("sectorcachesize", fmt.Sprint(3*modules.SectorSize))
+	if err = st.stdPostAPI("/host", settingsValues); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if sg.SectorCache.Capacity != 3*modules.SectorSize {
+		t.Fatal("sector cache was not resized:", sg.SectorCache.Capacity)
+	}
+
+	// An invalid size should be rejected.
+	settingsValues.Set("sectorcachesize", "not a number")
+	if err = st.stdPostAPI("/host", settingsValues); err == nil {
+		t.Fatal("expected an invalid sector cache size to be rejected")
+	}
+}
+
+// TestHostBandwidthSettingsInvalid checks that invalid bandwidth and
+// connection limits are rejected instead of being silently ignored.
+func TestHostBandwidthSettingsInvalid(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	for _, param := range []string{"maxdownloadspeed", "maxuploadspeed", "maxconnections", "maxconnectionsperip"} {
+		settingsValues := url.Values{}
+		settingsValues.Set(param, "not a number")
+		if err = st.stdPostAPI("/host", settingsValues); err == nil {
+			t.Fatal("expected an invalid", param, "to be rejected")
+		}
+	}
+}
+
+// TestHostPolicySettingsInvalid checks that invalid contract acceptance
+// policy settings are rejected instead of being silently ignored.
+func TestHostPolicySettingsInvalid(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	for _, param := range []string{"minduration", "mincontractpayout", "maxcollateralratio", "maxcontractsperrenter", "collateralperiod", "periodcollateralbudget"} {
+		settingsValues := url.Values{}
+		settingsValues.Set(param, "not a number")
+		if err = st.stdPostAPI("/host", settingsValues); err == nil {
+			t.Fatal("expected an invalid", param, "to be rejected")
+		}
+	}
+}
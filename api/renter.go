@@ -146,6 +146,12 @@ type (
 		modules.RenterPriceEstimation
 	}
 
+	// RenterRecoveryScanGET reports the progress of the recovery scan.
+	RenterRecoveryScanGET struct {
+		ScanInProgress bool              `json:"scaninprogress"`
+		ScannedHeight  types.BlockHeight `json:"scannedheight"`
+	}
+
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -197,6 +203,26 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, req *http.Request, _ htt
 
 // renterHandlerPOST handles the API call to set the Renter's settings.
 func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings := api.renter.Settings()
+
+	// Scan the download overdrive. (optional parameter)
+	if req.FormValue("downloadoverdrive") != "" {
+		_, err := fmt.Sscan(req.FormValue("downloadoverdrive"), &settings.DownloadOverdrive)
+		if err != nil {
+			WriteError(w, Error{"unable to parse downloadoverdrive: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		// The allowance is left untouched if no funds were provided.
+		if req.FormValue("funds") == "" {
+			if err := api.renter.SetSettings(settings); err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+			WriteSuccess(w)
+			return
+		}
+	}
+
 	// Scan the allowance amount.
 	funds, ok := scanAmount(req.FormValue("funds"))
 	if !ok {
@@ -245,14 +271,13 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	}
 
 	// Set the settings in the renter.
-	err = api.renter.SetSettings(modules.RenterSettings{
-		Allowance: modules.Allowance{
-			Funds:       funds,
-			Hosts:       hosts,
-			Period:      period,
-			RenewWindow: renewWindow,
-		},
-	})
+	settings.Allowance = modules.Allowance{
+		Funds:       funds,
+		Hosts:       hosts,
+		Period:      period,
+		RenewWindow: renewWindow,
+	}
+	err = api.renter.SetSettings(settings)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
@@ -361,6 +386,27 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	})
 }
 
+// renterRecoveryScanHandlerGET handles the API call to get the progress of the
+// recovery scan.
+func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	inProgress, height := api.renter.RecoveryScanStatus()
+	WriteJSON(w, RenterRecoveryScanGET{
+		ScanInProgress: inProgress,
+		ScannedHeight:  height,
+	})
+}
+
+// renterRecoveryScanHandlerPOST handles the API call to start a recovery scan,
+// which searches the blockchain for contracts formed with keys derived from
+// the wallet seed.
+func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.InitRecoveryScan(); err != nil {
+		WriteError(w, Error{"unable to start recovery scan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterDeleteHandler handles the API call to delete a file entry from the
 // renter.
 func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
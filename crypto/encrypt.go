@@ -5,6 +5,7 @@ package crypto
 
 import (
 	"crypto/cipher"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"io"
@@ -72,6 +73,35 @@ func (key TwofishKey) DecryptBytes(ct Ciphertext) ([]byte, error) {
 	return aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], nil)
 }
 
+// DecryptBytesInRange decrypts part of a ciphertext created by EncryptBytes.
+// nonce is the nonce that EncryptBytes prepended to the ciphertext, and ct
+// holds the bytes of the ciphertext that follow the nonce, starting at offset
+// off. GCM encrypts the plaintext in counter mode, so any range of it can be
+// decrypted on its own. The authentication tag cannot be checked without the
+// whole ciphertext, so the caller must verify the ciphertext by other means,
+// e.g. with a Merkle proof.
+func (key TwofishKey) DecryptBytesInRange(nonce []byte, ct []byte, off uint64) ([]byte, error) {
+	if len(nonce) != 12 {
+		return nil, ErrInsufficientLen
+	}
+
+	// GCM uses the nonce followed by a 32-bit big-endian block counter as the
+	// counter block. The counter starts at 1, which is reserved for the
+	// authentication tag, so the first block of plaintext uses 2.
+	iv := make([]byte, twofish.BlockSize)
+	copy(iv, nonce)
+	binary.BigEndian.PutUint32(iv[len(nonce):], uint32(2+off/twofish.BlockSize))
+	stream := cipher.NewCTR(key.NewCipher(), iv)
+
+	// Discard the keystream that precedes off within its block.
+	skip := make([]byte, off%twofish.BlockSize)
+	stream.XORKeyStream(skip, skip)
+
+	plaintext := make([]byte, len(ct))
+	stream.XORKeyStream(plaintext, ct)
+	return plaintext, nil
+}
+
 // NewWriter returns a writer that encrypts or decrypts its input stream.
 func (key TwofishKey) NewWriter(w io.Writer) io.Writer {
 	// OK to use a zero IV if the key is unique for each ciphertext.
@@ -62,6 +62,39 @@ func TestTwofishEncryption(t *testing.T) {
 	}
 }
 
+// TestDecryptBytesInRange checks that ranges of a ciphertext can be decrypted
+// without the rest of the ciphertext.
+func TestDecryptBytesInRange(t *testing.T) {
+	key := GenerateTwofishKey()
+	plaintext := fastrand.Bytes(10e3)
+	ciphertext := key.EncryptBytes(plaintext)
+	nonce, ct := ciphertext[:12], ciphertext[12:]
+
+	ranges := []struct{ start, end int }{
+		{0, 100},
+		{5, 77},
+		{1600, 3333},
+		{9990, 10e3},
+		{17, 18},
+		{0, 10e3},
+	}
+	for _, r := range ranges {
+		decrypted, err := key.DecryptBytesInRange(nonce, ct[r.start:r.end], uint64(r.start))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plaintext[r.start:r.end]) {
+			t.Fatalf("range [%v, %v) was not decrypted correctly", r.start, r.end)
+		}
+	}
+
+	// A nonce of the wrong size should be rejected.
+	_, err := key.DecryptBytesInRange(nonce[:10], ct, 0)
+	if err != ErrInsufficientLen {
+		t.Error("Expecting ErrInsufficientLen:", err)
+	}
+}
+
 // TestReaderWriter probes the NewReader and NewWriter methods of the key type.
 func TestReaderWriter(t *testing.T) {
 	// Get a key for encryption.
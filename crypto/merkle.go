@@ -148,3 +148,125 @@ func VerifySegment(base []byte, hashSet []Hash, numSegments, proofIndex uint64,
 	}
 	return merkletree.VerifyProof(NewHash(), root[:], proofSet, proofIndex, numSegments)
 }
+
+// joinSubtrees returns the root of the tree whose left and right children
+// are the subtrees with the provided roots.
+func joinSubtrees(left, right Hash) Hash {
+	ct := NewCachedTree(0)
+	ct.Push(left)
+	ct.Push(right)
+	return ct.Root()
+}
+
+// nextSubtreeSize returns the number of segments in the largest complete
+// subtree that starts at segment 'start' and does not extend past segment
+// 'end'.
+func nextSubtreeSize(start, end uint64) uint64 {
+	size := uint64(1)
+	for start%(size*2) == 0 && start+size*2 <= end {
+		size *= 2
+	}
+	return size
+}
+
+// subtreeHeight returns the height of a complete subtree with 'size' leaves.
+func subtreeHeight(size uint64) int {
+	height := 0
+	for size > 1 {
+		size /= 2
+		height++
+	}
+	return height
+}
+
+// rangeProofStack accumulates the roots of complete subtrees from left to
+// right, joining subtrees of equal height as they are pushed. It is used to
+// rebuild a Merkle root from a range proof.
+type rangeProofStack struct {
+	heights []int
+	roots   []Hash
+}
+
+// push adds the root of a complete subtree with the given height.
+func (s *rangeProofStack) push(height int, root Hash) {
+	for len(s.heights) > 0 && s.heights[len(s.heights)-1] == height {
+		last := len(s.heights) - 1
+		root = joinSubtrees(s.roots[last], root)
+		height++
+		s.heights, s.roots = s.heights[:last], s.roots[:last]
+	}
+	s.heights = append(s.heights, height)
+	s.roots = append(s.roots, root)
+}
+
+// root returns the Merkle root of all of the subtrees that have been pushed.
+func (s *rangeProofStack) root() Hash {
+	if len(s.roots) == 0 {
+		return Hash{}
+	}
+	root := s.roots[len(s.roots)-1]
+	for i := len(s.roots) - 2; i >= 0; i-- {
+		root = joinSubtrees(s.roots[i], root)
+	}
+	return root
+}
+
+// MerkleRangeProof builds a Merkle proof that the segments in the range
+// [start, end) are a part of the Merkle root formed by 'b'. The proof is the
+// set of subtree roots that cover the segments outside of the range, ordered
+// from left to right.
+func MerkleRangeProof(b []byte, start, end uint64) []Hash {
+	numSegments := CalculateLeaves(uint64(len(b)))
+	if start >= end || end > numSegments {
+		return nil
+	}
+	var proof []Hash
+	consume := func(i, j uint64) {
+		for i < j {
+			size := nextSubtreeSize(i, j)
+			lower, upper := i*SegmentSize, (i+size)*SegmentSize
+			if upper > uint64(len(b)) {
+				upper = uint64(len(b))
+			}
+			proof = append(proof, MerkleRoot(b[lower:upper]))
+			i += size
+		}
+	}
+	consume(0, start)
+	consume(end, numSegments)
+	return proof
+}
+
+// VerifyRangeProof will verify that the segments in the range [start, end),
+// given the proof, are a part of a Merkle root containing 'numSegments'
+// segments.
+func VerifyRangeProof(segments []byte, proof []Hash, start, end, numSegments uint64, root Hash) bool {
+	if start >= end || end > numSegments || CalculateLeaves(uint64(len(segments))) != end-start {
+		return false
+	}
+
+	var s rangeProofStack
+	consume := func(i, j uint64) bool {
+		for i < j {
+			if len(proof) == 0 {
+				return false
+			}
+			size := nextSubtreeSize(i, j)
+			s.push(subtreeHeight(size), proof[0])
+			proof = proof[1:]
+			i += size
+		}
+		return true
+	}
+	if !consume(0, start) {
+		return false
+	}
+	buf := bytes.NewBuffer(segments)
+	for buf.Len() > 0 {
+		s.push(0, MerkleRoot(buf.Next(SegmentSize)))
+	}
+	if !consume(end, numSegments) {
+		return false
+	}
+	return len(proof) == 0 && s.root() == root
+}
@@ -153,3 +153,49 @@ func TestOddDataSize(t *testing.T) {
 		}
 	}
 }
+
+// TestMerkleRangeProof builds range proofs over a variety of ranges and checks
+// that they verify correctly.
+func TestMerkleRangeProof(t *testing.T) {
+	for _, numSegments := range []uint64{1, 2, 7, 8, 13, 64} {
+		data := fastrand.Bytes(int(numSegments * SegmentSize))
+		root := MerkleRoot(data)
+		for start := uint64(0); start < numSegments; start++ {
+			for end := start + 1; end <= numSegments; end++ {
+				segments := data[start*SegmentSize : end*SegmentSize]
+				proof := MerkleRangeProof(data, start, end)
+				if !VerifyRangeProof(segments, proof, start, end, numSegments, root) {
+					t.Fatalf("range proof [%v, %v) of %v segments did not pass verification", start, end, numSegments)
+				}
+			}
+		}
+	}
+
+	// Try some incorrect proofs.
+	data := fastrand.Bytes(int(16 * SegmentSize))
+	root := MerkleRoot(data)
+	proof := MerkleRangeProof(data, 3, 9)
+	segments := data[3*SegmentSize : 9*SegmentSize]
+	if VerifyRangeProof(segments, proof, 4, 10, 16, root) {
+		t.Error("verified a proof for the wrong range")
+	}
+	if VerifyRangeProof(segments, proof[1:], 3, 9, 16, root) {
+		t.Error("verified a truncated proof")
+	}
+	badSegments := append([]byte(nil), segments...)
+	badSegments[0]++
+	if VerifyRangeProof(badSegments, proof, 3, 9, 16, root) {
+		t.Error("verified a proof with corrupted segments")
+	}
+}
+
+// TestMerkleRangeProofPartialSegment checks that range proofs work when the
+// last segment of the data is not full.
+func TestMerkleRangeProofPartialSegment(t *testing.T) {
+	data := fastrand.Bytes(int(5*SegmentSize + 10))
+	root := MerkleRoot(data)
+	proof := MerkleRangeProof(data, 4, 6)
+	if !VerifyRangeProof(data[4*SegmentSize:], proof, 4, 6, 6, root) {
+		t.Error("range proof including a partial segment did not pass verification")
+	}
+}
@@ -1,6 +1,9 @@
 package modules
 
 import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -44,9 +47,29 @@ var (
 	// ConnectabilityStatus() if the host is not connectable at its configured
 	// netaddress.
 	HostConnectabilityStatusNotConnectable = HostConnectabilityStatus("not connectable")
+
+	// HostPricingManual indicates that the host's prices are only changed by
+	// the operator. This is the default strategy.
+	HostPricingManual = HostPricingStrategy("manual")
+
+	// HostPricingUtilization indicates that the host raises its prices when
+	// more than the target fraction of its storage is in use, and lowers its
+	// prices when less than the target fraction is in use.
+	HostPricingUtilization = HostPricingStrategy("utilization")
+
+	// HostPricingNetwork indicates that the host sets its prices relative to
+	// the median prices of the other hosts on the network.
+	HostPricingNetwork = HostPricingStrategy("network")
 )
 
 type (
+	// HostDenylist contains the IP subnets and renter public keys that the
+	// host refuses to do business with. Subnets are in CIDR notation.
+	HostDenylist struct {
+		Subnets    []string             `json:"subnets"`
+		RenterKeys []types.SiaPublicKey `json:"renterkeys"`
+	}
+
 	// HostFinancialMetrics provides financial statistics for the host,
 	// including money that is locked in contracts. Though verbose, these
 	// statistics should provide a clear picture of where the host's money is
@@ -86,16 +109,152 @@ type (
 		NetAddress           NetAddress        `json:"netaddress"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// MaxDownloadSpeed and MaxUploadSpeed limit the combined rate, in
+		// bytes per second, at which the host receives and sends data.
+		// MaxConnections and MaxConnectionsPerIP limit the number of
+		// concurrent connections to the host, overall and from a single IP
+		// address. A value of zero means no limit.
+		MaxDownloadSpeed    uint64 `json:"maxdownloadspeed"`
+		MaxUploadSpeed      uint64 `json:"maxuploadspeed"`
+		MaxConnections      uint64 `json:"maxconnections"`
+		MaxConnectionsPerIP uint64 `json:"maxconnectionsperip"`
+
+		// MetricsSnapshotInterval is the number of blocks between the
+		// snapshots of the host's metrics that are kept in the metrics
+		// history. A value of zero uses the default interval.
+		MetricsSnapshotInterval types.BlockHeight `json:"metricssnapshotinterval"`
+
+		// SectorCacheSize is the number of bytes of recently read sectors
+		// that the host keeps in memory to serve repeated downloads. A value
+		// of zero disables the cache.
+		SectorCacheSize uint64 `json:"sectorcachesize"`
+
 		Collateral       types.Currency `json:"collateral"`
 		CollateralBudget types.Currency `json:"collateralbudget"`
 		MaxCollateral    types.Currency `json:"maxcollateral"`
 
+		// FeeBumpCap is the most that the host will pay in miner fees to get
+		// a single revision or storage proof confirmed. A stuck transaction
+		// is rebuilt with higher fees until this cap is reached. A value of
+		// zero disables fee bumping.
+		FeeBumpCap types.Currency `json:"feebumpcap"`
+
+		// The contract acceptance policy. The host rejects contracts that
+		// last fewer than MinDuration blocks, that the renter funds with less
+		// than MinContractPayout, or that ask for more than
+		// MaxCollateralRatio times their potential revenue in collateral. A
+		// renter may hold at most MaxContractsPerRenter unresolved contracts
+		// with the host, and the collateral of the contracts formed in the
+		// last CollateralPeriod blocks may not exceed PeriodCollateralBudget.
+		// A value of zero means no limit.
+		MinDuration            types.BlockHeight `json:"minduration"`
+		MinContractPayout      types.Currency    `json:"mincontractpayout"`
+		MaxCollateralRatio     float64           `json:"maxcollateralratio"`
+		MaxContractsPerRenter  uint64            `json:"maxcontractsperrenter"`
+		CollateralPeriod       types.BlockHeight `json:"collateralperiod"`
+		PeriodCollateralBudget types.Currency    `json:"periodcollateralbudget"`
+
 		MinContractPrice          types.Currency `json:"mincontractprice"`
 		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
 	}
 
+	// HostFolderEvacuation reports the progress of moving the data off of a
+	// storage folder while the host is in maintenance mode. The folder is
+	// removed from the host once its data has been moved.
+	HostFolderEvacuation struct {
+		Path     string `json:"path"`
+		Finished bool   `json:"finished"`
+		Error    string `json:"error"`
+	}
+
+	// HostRenterAddress is an address that a renter connected to the host
+	// from, along with the block heights at which the address was first and
+	// last seen.
+	HostRenterAddress struct {
+		Address   NetAddress        `json:"address"`
+		FirstSeen types.BlockHeight `json:"firstseen"`
+		LastSeen  types.BlockHeight `json:"lastseen"`
+	}
+
+	// HostMaintenanceStatus reports whether the host is in maintenance mode,
+	// and how long it will take for the host's obligations to drain.
+	HostMaintenanceStatus struct {
+		Enabled bool `json:"enabled"`
+
+		// UnresolvedObligations is the number of storage obligations that
+		// have not yet been resolved. DrainHeight is the proof deadline of
+		// the last of them, after which the host holds no obligations.
+		UnresolvedObligations uint64            `json:"unresolvedobligations"`
+		DrainHeight           types.BlockHeight `json:"drainheight"`
+
+		// LockedCollateral and RiskedCollateral are the totals of the
+		// unresolved obligations.
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+
+		Evacuations []HostFolderEvacuation `json:"evacuations"`
+	}
+
+	// HostMetricsSnapshot contains the financial and network metrics of the
+	// host at a given block height.
+	HostMetricsSnapshot struct {
+		Height           types.BlockHeight    `json:"height"`
+		Timestamp        types.Timestamp      `json:"timestamp"`
+		FinancialMetrics HostFinancialMetrics `json:"financialmetrics"`
+		NetworkMetrics   HostNetworkMetrics   `json:"networkmetrics"`
+	}
+
+	// HostProofRisk describes an unresolved storage obligation for which the
+	// host may be unable to submit a valid storage proof.
+	HostProofRisk struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		ProofDeadline    types.BlockHeight    `json:"proofdeadline"`
+
+		// UnreadableSectors counts the sectors that are missing from the
+		// storage manager or could not be read from disk, and CorruptSectors
+		// the sectors whose data no longer matches their root. MerkleRootMismatch is set if the
+		// sector roots do not match the Merkle root of the last revision.
+		UnreadableSectors  uint64 `json:"unreadablesectors"`
+		CorruptSectors     uint64 `json:"corruptsectors"`
+		MerkleRootMismatch bool   `json:"merklerootmismatch"`
+
+		// CannotFundProof is set if the wallet is locked or does not have
+		// enough money to pay the fee of the proof transaction, and
+		// FeeExceedsValue is set if the fee is higher than the value of the
+		// obligation, in which case the host will not submit a proof.
+		CannotFundProof bool           `json:"cannotfundproof"`
+		FeeExceedsValue bool           `json:"feeexceedsvalue"`
+		EstimatedFee    types.Currency `json:"estimatedfee"`
+
+		// CollateralAtStake is the collateral that the host loses if it
+		// fails to submit a storage proof.
+		CollateralAtStake types.Currency `json:"collateralatstake"`
+	}
+
+	// HostProofReadiness is the result of the host's storage proof
+	// readiness self-check.
+	HostProofReadiness struct {
+		CheckHeight        types.BlockHeight `json:"checkheight"`
+		CheckTime          time.Time         `json:"checktime"`
+		ObligationsChecked uint64            `json:"obligationschecked"`
+		SectorsChecked     uint64            `json:"sectorschecked"`
+
+		Risks            []HostProofRisk `json:"risks"`
+		CollateralAtRisk types.Currency  `json:"collateralatrisk"`
+	}
+
+	// HostSectorLookup reports where a sector is stored and which storage
+	// obligations reference it. Stored is false if the obligations reference
+	// a sector that the storage manager no longer holds.
+	HostSectorLookup struct {
+		Sector      StoredSector           `json:"sector"`
+		Stored      bool                   `json:"stored"`
+		Obligations []types.FileContractID `json:"obligations"`
+	}
+
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
@@ -104,14 +263,70 @@ type (
 		FormContractCalls uint64 `json:"formcontractcalls"`
 		RenewCalls        uint64 `json:"renewcalls"`
 		ReviseCalls       uint64 `json:"revisecalls"`
+		SectorRootsCalls  uint64 `json:"sectorrootscalls"`
 		SettingsCalls     uint64 `json:"settingscalls"`
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
+
+		// RejectedConnections and RejectedIPConnections count the
+		// connections that were refused because of the host's overall and
+		// per-IP connection limits.
+		RejectedConnections   uint64 `json:"rejectedconnections"`
+		RejectedIPConnections uint64 `json:"rejectedipconnections"`
+
+		// DeniedConnections counts the connections that were refused because
+		// the IP address is on the host's denylist, and DeniedRenters counts
+		// the contracts that were refused because the renter's key is.
+		DeniedConnections uint64 `json:"deniedconnections"`
+		DeniedRenters     uint64 `json:"deniedrenters"`
+
+		// SecureSessions counts the connections that were upgraded to an
+		// encrypted session.
+		SecureSessions uint64 `json:"securesessions"`
+
+		// PolicyRejections counts the contracts and renewals that were
+		// refused because of the host's contract acceptance policy.
+		PolicyRejections uint64 `json:"policyrejections"`
 	}
 
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
-		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
+		ObligationID      types.FileContractID `json:"obligationid"`
+		NegotiationHeight types.BlockHeight    `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight    `json:"expirationheight"`
+		ProofDeadline     types.BlockHeight    `json:"proofdeadline"`
+		DataSize          uint64               `json:"datasize"`
+		SectorRootsCount  uint64               `json:"sectorrootscount"`
+
+		// The potential revenue is the revenue that the host will earn if the
+		// obligation succeeds. RealizedRevenue is the sum of the potential
+		// revenues once the obligation has succeeded, and zero otherwise.
+		ContractCost             types.Currency `json:"contractcost"`
+		PotentialDownloadRevenue types.Currency `json:"potentialdownloadrevenue"`
+		PotentialStorageRevenue  types.Currency `json:"potentialstoragerevenue"`
+		PotentialUploadRevenue   types.Currency `json:"potentialuploadrevenue"`
+		RealizedRevenue          types.Currency `json:"realizedrevenue"`
+		LockedCollateral         types.Currency `json:"lockedcollateral"`
+		RiskedCollateral         types.Currency `json:"riskedcollateral"`
+		TransactionFeesAdded     types.Currency `json:"transactionfeesadded"`
+
+		// FeeBumps is the number of times the host raised the fees of the
+		// obligation's revision and storage proof transactions.
+		FeeBumps uint64 `json:"feebumps"`
+
+		// Bandwidth accounting. BytesUploaded is the data that the renter
+		// sent to the host, and BytesDownloaded is the data that the host
+		// sent to the renter. RevisionCount is the number of revisions
+		// negotiated with the renter, and RenterAddresses lists the
+		// addresses that the renter connected from.
+		BytesUploaded   uint64              `json:"bytesuploaded"`
+		BytesDownloaded uint64              `json:"bytesdownloaded"`
+		RevisionCount   uint64              `json:"revisioncount"`
+		RenterAddresses []HostRenterAddress `json:"renteraddresses"`
+
+		// TransactionIDs contains the ids of the transactions in the origin
+		// and revision transaction sets of the obligation.
+		TransactionIDs []types.TransactionID `json:"transactionids"`
 
 		OriginConfirmed     bool   `json:"originconfirmed"`
 		RevisionConstructed bool   `json:"revisionconstructed"`
@@ -119,6 +334,67 @@ type (
 		ProofConstructed    bool   `json:"proofconstructed"`
 		ProofConfirmed      bool   `json:"proofconfirmed"`
 		ObligationStatus    uint64 `json:"obligationstatus"`
+
+		// Status is the human readable form of ObligationStatus: one of
+		// "unresolved", "rejected", "succeeded" or "failed".
+		Status string `json:"status"`
+	}
+
+	// HostPricingStrategy names the method that the host uses to adjust its
+	// prices automatically.
+	HostPricingStrategy string
+
+	// HostPricingSettings configures the host's automatic pricing. Every
+	// UpdateInterval blocks, the host computes new prices using the
+	// configured strategy and clamps them between the floors and ceilings. A
+	// ceiling of zero means that the price has no ceiling.
+	HostPricingSettings struct {
+		Strategy       HostPricingStrategy `json:"strategy"`
+		UpdateInterval types.BlockHeight   `json:"updateinterval"`
+
+		// TargetUtilization is the fraction of the host's storage that the
+		// utilization strategy aims to have in use, between 0 and 1.
+		TargetUtilization float64 `json:"targetutilization"`
+
+		// NetworkOffset is the multiple of the network median prices that the
+		// network strategy charges. A value of 0.9 prices the host 10% below
+		// the median.
+		NetworkOffset float64 `json:"networkoffset"`
+
+		// BasePrices are the prices that the utilization strategy adjusts.
+		// Every update starts from the base prices, so that adjustments do
+		// not compound. If the utilization strategy is enabled without base
+		// prices, the host's current prices are used.
+		BasePrices HostPrices `json:"baseprices"`
+
+		// The floors must be nonzero for either automatic strategy.
+		CollateralFloor               types.Currency `json:"collateralfloor"`
+		CollateralCeiling             types.Currency `json:"collateralceiling"`
+		DownloadBandwidthPriceFloor   types.Currency `json:"downloadbandwidthpricefloor"`
+		DownloadBandwidthPriceCeiling types.Currency `json:"downloadbandwidthpriceceiling"`
+		StoragePriceFloor             types.Currency `json:"storagepricefloor"`
+		StoragePriceCeiling           types.Currency `json:"storagepriceceiling"`
+		UploadBandwidthPriceFloor     types.Currency `json:"uploadbandwidthpricefloor"`
+		UploadBandwidthPriceCeiling   types.Currency `json:"uploadbandwidthpriceceiling"`
+	}
+
+	// HostPrices is the set of prices that the automatic pricing engine
+	// manages.
+	HostPrices struct {
+		Collateral                types.Currency `json:"collateral"`
+		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
+		MinStoragePrice           types.Currency `json:"minstorageprice"`
+		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
+	}
+
+	// HostPricePreview reports the prices that the automatic pricing engine
+	// would set if it ran now, along with the current prices and the height
+	// of the next scheduled update.
+	HostPricePreview struct {
+		Strategy         HostPricingStrategy `json:"strategy"`
+		Current          HostPrices          `json:"current"`
+		Next             HostPrices          `json:"next"`
+		NextUpdateHeight types.BlockHeight   `json:"nextupdateheight"`
 	}
 
 	// HostWorkingStatus reports the working state of a host. Can be one of
@@ -139,10 +415,34 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// CheckProofReadiness runs the storage proof readiness self-check,
+		// confirming that the host will be able to submit a storage proof
+		// for each of its unresolved obligations.
+		CheckProofReadiness() (HostProofReadiness, error)
+
+		// CheckStorageConsistency checks the storage manager's metadata,
+		// data files, and sector location map for inconsistencies, and
+		// cross-checks the stored sectors against the host's storage
+		// obligations. Nothing is changed, the location map can only be
+		// repaired offline.
+		CheckStorageConsistency() (StorageConsistencyReport, error)
+
+		// AddToDenylist adds IP subnets and renter public keys to the host's
+		// denylist.
+		AddToDenylist(HostDenylist) error
+
+		// Denylist returns the IP subnets and renter public keys that the
+		// host refuses to do business with.
+		Denylist() HostDenylist
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
 
+		// EvacuateStorageFolder moves the data off of a storage folder and
+		// then removes it. The host must be in maintenance mode.
+		EvacuateStorageFolder(path string) error
+
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
@@ -150,16 +450,52 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// LookupSector returns the location of a sector along with the
+		// storage obligations that reference it.
+		LookupSector(sectorRoot crypto.Hash) (HostSectorLookup, error)
+
+		// MaintenanceStatus returns whether the host is in maintenance mode,
+		// along with an estimate of when its obligations will drain.
+		MaintenanceStatus() HostMaintenanceStatus
+
+		// MetricsHistory returns the snapshots of the host's metrics that
+		// were taken between the two heights, inclusive.
+		MetricsHistory(from, to types.BlockHeight) ([]HostMetricsSnapshot, error)
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// PricePreview returns the prices that the automatic pricing engine
+		// would set if it ran now.
+		PricePreview() (HostPricePreview, error)
+
+		// PricingSettings returns the configuration of the automatic pricing
+		// engine.
+		PricingSettings() HostPricingSettings
+
+		// ProofReadiness returns the result of the most recent storage proof
+		// readiness self-check.
+		ProofReadiness() HostProofReadiness
+
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// RemoveFromDenylist removes IP subnets and renter public keys from
+		// the host's denylist.
+		RemoveFromDenylist(HostDenylist) error
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetMaintenance enables or disables maintenance mode. In maintenance
+		// mode the host refuses new contracts and renewals, but continues to
+		// serve its existing obligations.
+		SetMaintenance(enabled bool) error
+
+		// SetPricingSettings configures the automatic pricing engine.
+		SetPricingSettings(HostPricingSettings) error
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
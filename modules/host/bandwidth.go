@@ -0,0 +1,57 @@
+package host
+
+import (
+	"net"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// bandwidth.go keeps the bandwidth accounting of each storage obligation. The
+// host's network metrics only count RPC calls across all contracts, so each
+// obligation additionally tracks the data that its renter uploaded and
+// downloaded, the number of revisions, and the addresses that the renter
+// connected from. The accounting is saved with the obligation, and helps the
+// host spot abusive renters and see which contracts earn bandwidth revenue.
+
+const (
+	// maxRenterAddresses is the number of distinct renter addresses that are
+	// remembered for each storage obligation. Once the limit is reached, the
+	// address that was seen least recently is forgotten.
+	maxRenterAddresses = 16
+)
+
+// renterAddress returns the address that the renter connected from, without
+// the port, which changes for every connection.
+func renterAddress(conn net.Conn) modules.NetAddress {
+	addr := modules.NetAddress(conn.RemoteAddr().String())
+	if host := addr.Host(); host != "" {
+		return modules.NetAddress(host)
+	}
+	return addr
+}
+
+// recordRenterAddress adds the renter address to the address history of the
+// storage obligation, or updates the height at which it was last seen.
+func (so *storageObligation) recordRenterAddress(addr modules.NetAddress, blockHeight types.BlockHeight) {
+	for i := range so.RenterAddresses {
+		if so.RenterAddresses[i].Address == addr {
+			so.RenterAddresses[i].LastSeen = blockHeight
+			return
+		}
+	}
+	if len(so.RenterAddresses) >= maxRenterAddresses {
+		oldest := 0
+		for i, ra := range so.RenterAddresses {
+			if ra.LastSeen < so.RenterAddresses[oldest].LastSeen {
+				oldest = i
+			}
+		}
+		so.RenterAddresses = append(so.RenterAddresses[:oldest], so.RenterAddresses[oldest+1:]...)
+	}
+	so.RenterAddresses = append(so.RenterAddresses, modules.HostRenterAddress{
+		Address:   addr,
+		FirstSeen: blockHeight,
+		LastSeen:  blockHeight,
+	})
+}
@@ -0,0 +1,40 @@
+package host
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRecordRenterAddress probes the address history of a storage obligation.
+func TestRecordRenterAddress(t *testing.T) {
+	var so storageObligation
+	so.recordRenterAddress("1.2.3.4", 10)
+	so.recordRenterAddress("1.2.3.4", 12)
+	if len(so.RenterAddresses) != 1 {
+		t.Fatal("expected one address, got", len(so.RenterAddresses))
+	}
+	if ra := so.RenterAddresses[0]; ra.FirstSeen != 10 || ra.LastSeen != 12 {
+		t.Fatal("address heights were not updated correctly:", ra)
+	}
+
+	// Fill the history; the address seen least recently should be dropped.
+	for i := 1; i < maxRenterAddresses; i++ {
+		so.recordRenterAddress(modules.NetAddress(fmt.Sprintf("10.0.0.%v", i)), types.BlockHeight(20+i))
+	}
+	so.recordRenterAddress("1.2.3.4", 50)
+	so.recordRenterAddress("5.6.7.8", 60)
+	if len(so.RenterAddresses) != maxRenterAddresses {
+		t.Fatal("history exceeds the limit:", len(so.RenterAddresses))
+	}
+	for _, ra := range so.RenterAddresses {
+		if ra.Address == "10.0.0.1" {
+			t.Fatal("least recently seen address was not dropped")
+		}
+	}
+	if last := so.RenterAddresses[len(so.RenterAddresses)-1]; last.Address != "5.6.7.8" || last.FirstSeen != 60 {
+		t.Fatal("new address was not recorded:", last)
+	}
+}
@@ -0,0 +1,121 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
+	"github.com/NebulousLabs/Sia/persist"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// consistency.go runs the storage manager's consistency checker, supplying
+// the sector roots of the host's storage obligations so that the stored
+// sectors can be cross-checked against them. The check can be run on a
+// running host, or offline against the host's persist directory. Repairs are
+// only made offline, because the storage of a running host keeps changing
+// while the sector location map would be rebuilt.
+
+var (
+	// errNoHostDatabase is returned by CheckStorage if the persist directory
+	// does not contain a host database.
+	errNoHostDatabase = errors.New("no host database found in the persist directory")
+)
+
+// storageReferences returns the number of times that each sector root is
+// referenced by the host's storage obligations. Obligations that have been
+// resolved no longer hold any sectors.
+func storageReferences(tx *bolt.Tx) (map[crypto.Hash]uint64, error) {
+	references := make(map[crypto.Hash]uint64)
+	b := tx.Bucket(bucketStorageObligations)
+	if b == nil {
+		return references, nil
+	}
+	err := b.ForEach(func(_, soBytes []byte) error {
+		var so storageObligation
+		err := json.Unmarshal(soBytes, &so)
+		if err != nil {
+			return build.ExtendErr("unable to unmarshal storage obligation:", err)
+		}
+		for _, root := range so.SectorRoots {
+			references[root]++
+		}
+		return nil
+	})
+	return references, err
+}
+
+// CheckStorageConsistency checks the storage manager for inconsistencies
+// between the storage folder metadata, the data files, and the sector
+// location map, and cross-checks the stored sectors against the host's
+// storage obligations. Nothing is changed; the sector location map can be
+// repaired offline using CheckStorage.
+func (h *Host) CheckStorageConsistency() (modules.StorageConsistencyReport, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.StorageConsistencyReport{}, err
+	}
+	defer h.tg.Done()
+
+	var references map[crypto.Hash]uint64
+	h.mu.RLock()
+	err = h.db.View(func(tx *bolt.Tx) error {
+		references, err = storageReferences(tx)
+		return err
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		return modules.StorageConsistencyReport{}, err
+	}
+	report, err := h.StorageManager.CheckConsistency(references)
+	if err != nil {
+		return modules.StorageConsistencyReport{}, err
+	}
+	if !report.Consistent {
+		h.log.Printf("WARN: storage consistency check found %v slot problems and %v missing sectors\n", len(report.Issues), len(report.MissingSectors))
+	}
+	return report, nil
+}
+
+// CheckStorage runs the storage consistency check against the host in
+// persistDir without starting the host. If repair is set, the sector location
+// map is rebuilt from the metadata on disk. The host must not be running,
+// which is enforced by the lock on the host database.
+func CheckStorage(persistDir string, repair bool) (modules.StorageConsistencyReport, error) {
+	dbPath := filepath.Join(persistDir, dbFilename)
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return modules.StorageConsistencyReport{}, errNoHostDatabase
+	}
+	db, err := persist.OpenDatabase(dbMetadata, dbPath)
+	if err != nil {
+		return modules.StorageConsistencyReport{}, build.ExtendErr("unable to open the host database", err)
+	}
+	defer db.Close()
+
+	var references map[crypto.Hash]uint64
+	err = db.View(func(tx *bolt.Tx) error {
+		references, err = storageReferences(tx)
+		return err
+	})
+	if err != nil {
+		return modules.StorageConsistencyReport{}, err
+	}
+
+	cm, err := contractmanager.New(filepath.Join(persistDir, "contractmanager"))
+	if err != nil {
+		return modules.StorageConsistencyReport{}, build.ExtendErr("unable to open the contract manager", err)
+	}
+	var report modules.StorageConsistencyReport
+	if repair {
+		report, err = cm.RepairConsistency(references)
+	} else {
+		report, err = cm.CheckConsistency(references)
+	}
+	return report, build.ComposeErrors(err, cm.Close())
+}
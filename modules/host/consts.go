@@ -26,11 +26,23 @@ const (
 	// connection.
 	iteratedConnectionTime = 1200 * time.Second
 
+	// pricingMaxAdjustment is the largest fraction by which the utilization
+	// pricing strategy will change the host's prices in a single update. The
+	// adjustment is proportional to how far the host's utilization is from the
+	// target, reaching the maximum when the host is completely full or
+	// completely empty.
+	pricingMaxAdjustment = 0.1
+
 	// resubmissionTimeout defines the number of blocks that a host will wait
 	// before attempting to resubmit a transaction to the blockchain.
 	// Typically, this transaction will contain either a file contract, a file
 	// contract revision, or a storage proof.
 	resubmissionTimeout = 3
+
+	// txnPoolMaxAge is the number of blocks that the transaction pool keeps
+	// an unconfirmed transaction before dropping it. It matches maxTxnAge in
+	// the transaction pool.
+	txnPoolMaxAge = types.BlockHeight(24)
 )
 
 var (
@@ -83,6 +95,11 @@ var (
 	// with a number like 65 MiB.
 	defaultMaxReviseBatchSize = 17 * (1 << 20)
 
+	// defaultSectorCacheSize defines the number of bytes of recently read
+	// sectors that the host keeps in memory. 64 MiB holds 16 sectors, which
+	// is enough to serve the most popular sectors without going to disk.
+	defaultSectorCacheSize = 64 * (1 << 20)
+
 	// defaultMaxCollateral defines the maximum amount of collateral that the
 	// host is comfortable putting into a single file contract. 10e3 is a
 	// relatively small file contract, but millions of siacoins could be locked
@@ -91,6 +108,48 @@ var (
 	// bit.
 	defaultMaxCollateral = types.SiacoinPrecision.Mul64(5e3)
 
+	// defaultFeeBumpCap is the default maximum amount that the host will pay
+	// in fees, including all fee bumps, to get a single revision or storage
+	// proof confirmed.
+	defaultFeeBumpCap = types.SiacoinPrecision.Mul64(5)
+
+	// defaultMetricsSnapshotInterval is the default number of blocks between
+	// snapshots of the host's metrics.
+	defaultMetricsSnapshotInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(10),
+		Standard: types.BlockHeight(144), // 1 day.
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+
+	// proofCheckInterval is the number of blocks between the periodic storage
+	// proof readiness self-checks. The check reads every sector of every
+	// unresolved obligation, so it is run a few times per day rather than
+	// every block.
+	proofCheckInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(10),
+		Standard: types.BlockHeight(36), // 6 hours.
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// proofCheckBytesPerSecond limits the rate at which the storage proof
+	// readiness self-check reads sectors from disk, so that the check does
+	// not compete with renters for disk bandwidth.
+	proofCheckBytesPerSecond = build.Select(build.Var{
+		Dev:      uint64(64 << 20),
+		Standard: uint64(16 << 20),
+		Testing:  uint64(1 << 30),
+	}).(uint64)
+
+	// defaultPricingUpdateInterval is the default number of blocks between
+	// price updates made by the automatic pricing engine. Prices are adjusted
+	// once per day in release builds, which gives renters time to notice the
+	// new prices before they change again.
+	defaultPricingUpdateInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(10),
+		Standard: types.BlockHeight(144), // 1 day.
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
 	// defaultStoragePrice defines the starting price for hosts selling
 	// storage. We try to match a number that is both reasonably profitable and
 	// reasonably competitive.
@@ -204,6 +263,15 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
+	// feeBumpWindow is the number of blocks before the deadline of a revision
+	// or storage proof transaction at which the host starts raising the fees
+	// of the transaction if it has not been confirmed.
+	feeBumpWindow = build.Select(build.Var{
+		Dev:      types.BlockHeight(10),
+		Standard: types.BlockHeight(36), // 6 hours.
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+
 	// revisionSubmissionBuffer describes the number of blocks ahead of time
 	// that the host will submit a file contract revision. The host will not
 	// accept any more revisions once inside the submission buffer.
@@ -235,6 +303,12 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketMetricsHistory maps a blockchain height to a snapshot of the
+	// host's financial and network metrics taken at that height. As with
+	// bucketActionItems, the height is stored as a big endian uint64 so that
+	// the snapshots are sorted by height.
+	bucketMetricsHistory = []byte("BucketMetricsHistory")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
@@ -249,4 +323,10 @@ func init() {
 	if revisionSubmissionBuffer < resubmissionTimeout {
 		build.Critical("revision submission buffer needs to be larger than or equal to the resubmission timeout")
 	}
+
+	// Fee bumps for a revision need to start after the revision is first
+	// submitted, otherwise the first attempt is never given a chance.
+	if feeBumpWindow >= revisionSubmissionBuffer {
+		build.Critical("fee bump window needs to be smaller than the revision submission buffer")
+	}
 }
@@ -0,0 +1,356 @@
+package contractmanager
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// consistency.go checks the contract manager's persistent state for damage
+// caused by unclean shutdown or disk failure. The sector location map is
+// compared against the sector metadata stored in each storage folder, and the
+// metadata is compared against the length of the data file. The location map
+// can be rebuilt from the metadata on disk, which is the authoritative record
+// of which sector is stored in which slot.
+
+const (
+	// Problems that can be found with a sector slot.
+	slotDanglingLocation = "dangling location"
+	slotDoublyAllocated  = "doubly allocated"
+	slotOrphaned         = "orphaned"
+	slotTruncated        = "truncated"
+
+	// maxConsistencyIssues is the maximum number of individual problems that
+	// are listed in a consistency report. All problems are counted.
+	maxConsistencyIssues = 1000
+)
+
+// diskSlot is a used sector slot as recorded in the metadata of a storage
+// folder.
+type diskSlot struct {
+	id sectorID
+	sectorLocation
+}
+
+// fileLength returns the length of the file, up to max bytes. Files are not
+// required to support Stat, so the length is found by probing with ReadAt.
+func fileLength(f file, max int64) int64 {
+	if max == 0 {
+		return 0
+	}
+	b := make([]byte, 1)
+	if n, _ := f.ReadAt(b, max-1); n == 1 {
+		return max
+	}
+	// Binary search for the first offset that cannot be read.
+	low, high := int64(0), max-1
+	for low < high {
+		mid := low + (high-low)/2
+		if n, _ := f.ReadAt(b, mid); n == 1 {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low
+}
+
+// addIssue records a problem with a sector slot in the report.
+func addIssue(report *modules.StorageConsistencyReport, check *modules.StorageFolderCheck, index uint32, problem string, referenced bool) {
+	switch problem {
+	case slotDanglingLocation:
+		check.DanglingLocations++
+	case slotDoublyAllocated:
+		check.DoublyAllocatedSlots++
+	case slotOrphaned:
+		check.OrphanedSlots++
+	case slotTruncated:
+		check.TruncatedSlots++
+	}
+	if len(report.Issues) < maxConsistencyIssues {
+		report.Issues = append(report.Issues, modules.StorageConsistencyIssue{
+			StorageFolder: check.Index,
+			Index:         index,
+			Problem:       problem,
+			Referenced:    referenced,
+		})
+	}
+}
+
+// checkStorageFolder reads the metadata of a storage folder and compares it
+// against the data file and the location map. The slots that hold a sector
+// are returned, grouped by sector id. Slots that cannot hold a sector, either
+// because their metadata is empty or because the data file is too short, are
+// returned separately. Whether the slots that hold a sector are orphaned or
+// doubly allocated is decided by the caller once every storage folder has
+// been read. The WAL lock must be held.
+func (cm *ContractManager) checkStorageFolder(sf *storageFolder, referenced map[sectorID]uint64, report *modules.StorageConsistencyReport, check *modules.StorageFolderCheck) (slots map[sectorID][]diskSlot, bad []uint32) {
+	numSectors := int64(len(sf.usage) * storageFolderGranularity)
+	check.ExpectedMetadataSize = numSectors * sectorMetadataDiskSize
+	check.ExpectedDataSize = numSectors * int64(modules.SectorSize)
+	check.MetadataSize = fileLength(sf.metadataFile, check.ExpectedMetadataSize)
+	check.DataSize = fileLength(sf.sectorFile, check.ExpectedDataSize)
+
+	metadata := make([]byte, check.MetadataSize)
+	n, _ := sf.metadataFile.ReadAt(metadata, 0)
+	metadata = metadata[:n]
+
+	// Slots of sectors that are being added or deleted are in flux, and are
+	// not checked.
+	inFlight := make(map[uint32]struct{})
+	for _, index := range sf.availableSectors {
+		inFlight[index] = struct{}{}
+	}
+
+	slots = make(map[sectorID][]diskSlot)
+	for _, index := range usageSectors(sf.usage) {
+		if _, exists := inFlight[index]; exists {
+			continue
+		}
+		check.UsedSlots++
+		readHead := int64(index) * sectorMetadataDiskSize
+		if readHead+sectorMetadataDiskSize > int64(len(metadata)) {
+			addIssue(report, check, index, slotTruncated, false)
+			bad = append(bad, index)
+			continue
+		}
+		var ds diskSlot
+		copy(ds.id[:], metadata[readHead:readHead+12])
+		ds.count = binary.LittleEndian.Uint16(metadata[readHead+12 : readHead+14])
+		ds.index = index
+		ds.storageFolder = sf.index
+		_, isReferenced := referenced[ds.id]
+
+		if int64(index+1)*int64(modules.SectorSize) > check.DataSize {
+			addIssue(report, check, index, slotTruncated, isReferenced)
+			bad = append(bad, index)
+			continue
+		}
+		if ds.count == 0 {
+			addIssue(report, check, index, slotOrphaned, false)
+			bad = append(bad, index)
+			continue
+		}
+		slots[ds.id] = append(slots[ds.id], ds)
+	}
+
+	// Every location in the map should point to a used slot whose metadata
+	// holds the same sector.
+	for id, sl := range cm.sectorLocations {
+		if sl.storageFolder != sf.index {
+			continue
+		}
+		found := false
+		for _, ds := range slots[id] {
+			if ds.index == sl.index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			_, isReferenced := referenced[id]
+			addIssue(report, check, sl.index, slotDanglingLocation, isReferenced)
+		}
+	}
+	return slots, bad
+}
+
+// CheckConsistency checks the sector metadata of every storage folder against
+// the data file and the sector location map. The references map the sector
+// roots of the host's storage obligations to the number of times that each
+// root is referenced, and are used to cross-check the stored sectors. Nothing
+// is changed.
+func (cm *ContractManager) CheckConsistency(references map[crypto.Hash]uint64) (modules.StorageConsistencyReport, error) {
+	return cm.managedCheckConsistency(references, false)
+}
+
+// RepairConsistency runs the same check as CheckConsistency, then rebuilds the
+// location map from the metadata on disk, and frees the slots that do not hold
+// a usable sector. The rebuilt map does not account for sector updates that
+// are still being applied, so RepairConsistency must only be called while
+// nothing else is using the contract manager, which is why it is only
+// available offline through 'siad host fsck'.
+func (cm *ContractManager) RepairConsistency(references map[crypto.Hash]uint64) (modules.StorageConsistencyReport, error) {
+	return cm.managedCheckConsistency(references, true)
+}
+
+// managedCheckConsistency checks the consistency of the contract manager, and
+// repairs the location map if repair is set.
+func (cm *ContractManager) managedCheckConsistency(references map[crypto.Hash]uint64, repair bool) (modules.StorageConsistencyReport, error) {
+	err := cm.tg.Add()
+	if err != nil {
+		return modules.StorageConsistencyReport{}, err
+	}
+	defer cm.tg.Done()
+
+	referenced := make(map[sectorID]uint64, len(references))
+	roots := make(map[sectorID]crypto.Hash, len(references))
+	for root, refs := range references {
+		id := cm.managedSectorID(root)
+		referenced[id] = refs
+		roots[id] = root
+	}
+
+	cm.wal.mu.Lock()
+	var report modules.StorageConsistencyReport
+	folders := make([]*storageFolder, 0, len(cm.storageFolders))
+	for _, sf := range cm.storageFolders {
+		folders = append(folders, sf)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].index < folders[j].index
+	})
+
+	// Check each storage folder, collecting the slots found on disk. The
+	// locations of sectors in unavailable folders cannot be checked, and are
+	// kept as they are.
+	slots := make(map[sectorID][]diskSlot)
+	bad := make(map[*storageFolder][]uint32)
+	unavailable := make(map[uint16]struct{})
+	for _, sf := range folders {
+		check := modules.StorageFolderCheck{
+			Index: sf.index,
+			Path:  sf.path,
+		}
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			check.Unavailable = true
+			unavailable[sf.index] = struct{}{}
+			report.Folders = append(report.Folders, check)
+			continue
+		}
+		folderSlots, folderBad := cm.checkStorageFolder(sf, referenced, &report, &check)
+		for id, dss := range folderSlots {
+			slots[id] = append(slots[id], dss...)
+		}
+		bad[sf] = folderBad
+		report.Folders = append(report.Folders, check)
+	}
+
+	// A sector that is stored in more than one slot is only kept in one of
+	// them, preferring the slot that the location map points to. If the map
+	// does not point to any of the slots, the kept slot is orphaned.
+	checks := make(map[uint16]*modules.StorageFolderCheck)
+	for i := range report.Folders {
+		checks[report.Folders[i].Index] = &report.Folders[i]
+	}
+	rebuilt := make(map[sectorID]sectorLocation)
+	for id, sl := range cm.sectorLocations {
+		if _, exists := unavailable[sl.storageFolder]; exists {
+			rebuilt[id] = sl
+		}
+	}
+	for id, dss := range slots {
+		_, isReferenced := referenced[id]
+		keep := -1
+		sl, exists := cm.sectorLocations[id]
+		for i, ds := range dss {
+			if exists && sl.storageFolder == ds.storageFolder && sl.index == ds.index {
+				keep = i
+			}
+		}
+		if keep == -1 {
+			keep = 0
+			addIssue(&report, checks[dss[keep].storageFolder], dss[keep].index, slotOrphaned, isReferenced)
+		}
+		rebuilt[id] = dss[keep].sectorLocation
+		for i, ds := range dss {
+			if i == keep {
+				continue
+			}
+			addIssue(&report, checks[ds.storageFolder], ds.index, slotDoublyAllocated, isReferenced)
+			sf := cm.storageFolders[ds.storageFolder]
+			bad[sf] = append(bad[sf], ds.index)
+		}
+	}
+
+	// Compare the location map against the storage obligations.
+	locations := cm.sectorLocations
+	if repair {
+		locations = rebuilt
+	}
+	for id, refs := range referenced {
+		sl, exists := locations[id]
+		if !exists {
+			report.MissingSectors = append(report.MissingSectors, roots[id])
+		} else if uint64(sl.count) != refs {
+			report.CountMismatches++
+		}
+	}
+	for id := range locations {
+		if _, exists := referenced[id]; !exists {
+			report.UnreferencedSectors++
+		}
+	}
+	sort.Slice(report.MissingSectors, func(i, j int) bool {
+		return string(report.MissingSectors[i][:]) < string(report.MissingSectors[j][:])
+	})
+
+	report.Consistent = len(report.Issues) == 0 && len(report.MissingSectors) == 0
+	for _, check := range report.Folders {
+		if check.MetadataSize != check.ExpectedMetadataSize || check.DataSize != check.ExpectedDataSize {
+			report.Consistent = false
+		}
+	}
+	if !repair || len(report.Issues) == 0 {
+		cm.wal.mu.Unlock()
+		return report, nil
+	}
+
+	// Replace the location map with the rebuilt map, and record the freed
+	// slots in the WAL. As with deleting a sector, the usage of the freed
+	// slots is only cleared once the change has been committed.
+	for id := range rebuilt {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			report.SectorsRecovered++
+		}
+	}
+	for id := range cm.sectorLocations {
+		if _, exists := rebuilt[id]; !exists {
+			report.SectorsDropped++
+		}
+	}
+	var updates []sectorUpdate
+	for sf, indexes := range bad {
+		for _, index := range indexes {
+			updates = append(updates, sectorUpdate{
+				Folder: sf.index,
+				Index:  index,
+			})
+		}
+	}
+	if len(updates) > 0 {
+		cm.wal.appendChange(stateChange{
+			SectorUpdates: updates,
+		})
+	}
+	// The sector counts include the freed slots until their usage is
+	// cleared.
+	cm.sectorLocations = rebuilt
+	for _, sf := range folders {
+		if _, exists := unavailable[sf.index]; !exists {
+			sf.sectors = uint64(len(bad[sf]))
+		}
+	}
+	for _, sl := range rebuilt {
+		if _, exists := unavailable[sl.storageFolder]; !exists {
+			cm.storageFolders[sl.storageFolder].sectors++
+		}
+	}
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+
+	cm.wal.mu.Lock()
+	for sf, indexes := range bad {
+		for _, index := range indexes {
+			sf.clearUsage(index)
+		}
+	}
+	cm.wal.mu.Unlock()
+	report.Repaired = true
+	cm.log.Printf("Consistency repair: rebuilt the sector location map, %v sectors recovered, %v sectors dropped, %v slots freed\n", report.SectorsRecovered, report.SectorsDropped, len(updates))
+	return report, nil
+}
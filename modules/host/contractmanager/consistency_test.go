@@ -0,0 +1,158 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestCheckConsistency damages the sector location map and the sector
+// metadata of a storage folder, and checks that the consistency checker finds
+// and repairs the damage.
+func TestCheckConsistency(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder and fill it with a few sectors.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	references := make(map[crypto.Hash]uint64)
+	var roots []crypto.Hash
+	for i := 0; i < 5; i++ {
+		var root crypto.Hash
+		fastrand.Read(root[:])
+		err = cmt.cm.AddSector(root, fastrand.Bytes(int(modules.SectorSize)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		references[root] = 1
+		roots = append(roots, root)
+	}
+
+	// A healthy contract manager should be consistent.
+	report, err := cmt.cm.CheckConsistency(references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Consistent || len(report.Issues) != 0 || report.UnreferencedSectors != 0 || report.CountMismatches != 0 {
+		t.Fatalf("unexpected report for a healthy contract manager: %+v", report)
+	}
+	if len(report.Folders) != 1 || report.Folders[0].UsedSlots != 5 {
+		t.Fatalf("unexpected folder checks: %+v", report.Folders)
+	}
+
+	// A root that is referenced by an obligation but not stored should be
+	// reported as missing.
+	var missing crypto.Hash
+	fastrand.Read(missing[:])
+	references[missing] = 1
+	report, err = cmt.cm.CheckConsistency(references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Consistent || len(report.MissingSectors) != 1 || report.MissingSectors[0] != missing {
+		t.Fatalf("missing sector was not reported: %+v", report)
+	}
+	delete(references, missing)
+
+	// Damage the contract manager: drop the location of the second sector,
+	// store a second copy of the first sector in a free slot, and add a
+	// location that points to a free slot.
+	firstID := cmt.cm.managedSectorID(roots[0])
+	secondID := cmt.cm.managedSectorID(roots[1])
+	var danglingID sectorID
+	fastrand.Read(danglingID[:])
+	cmt.cm.wal.mu.Lock()
+	sl := cmt.cm.sectorLocations[firstID]
+	sf := cmt.cm.storageFolders[sl.storageFolder]
+	delete(cmt.cm.sectorLocations, secondID)
+	duplicateIndex, err := randFreeSector(sf.usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf.setUsage(duplicateIndex)
+	err = writeSectorMetadata(sf.metadataFile, duplicateIndex, firstID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	danglingIndex, err := randFreeSector(sf.usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.sectorLocations[danglingID] = sectorLocation{
+		index:         danglingIndex,
+		storageFolder: sf.index,
+		count:         1,
+	}
+	cmt.cm.wal.mu.Unlock()
+
+	report, err = cmt.cm.CheckConsistency(references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := report.Folders[0]
+	if report.Consistent || check.OrphanedSlots != 1 || check.DoublyAllocatedSlots != 1 || check.DanglingLocations != 1 {
+		t.Fatalf("damage was not reported: %+v", check)
+	}
+	if len(report.MissingSectors) != 1 || report.MissingSectors[0] != roots[1] || report.UnreferencedSectors != 1 {
+		t.Fatalf("unexpected cross-check against the references: %+v", report)
+	}
+	for _, issue := range report.Issues {
+		if issue.Problem == slotDoublyAllocated && issue.Index != duplicateIndex {
+			t.Fatal("the wrong copy of the sector was reported as doubly allocated")
+		}
+		if issue.Problem == slotDanglingLocation && (issue.Index != danglingIndex || issue.Referenced) {
+			t.Fatal("dangling location was not reported correctly:", issue)
+		}
+	}
+
+	// Repair the damage. The orphaned sector should be recovered, and the
+	// dangling location dropped.
+	report, err = cmt.cm.RepairConsistency(references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Repaired || report.SectorsRecovered != 1 || report.SectorsDropped != 1 {
+		t.Fatalf("unexpected repair report: %+v", report)
+	}
+	if len(report.MissingSectors) != 0 || report.UnreferencedSectors != 0 {
+		t.Fatalf("repaired location map does not match the references: %+v", report)
+	}
+	report, err = cmt.cm.CheckConsistency(references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Consistent || report.Folders[0].UsedSlots != 5 {
+		t.Fatalf("contract manager is not consistent after repair: %+v", report)
+	}
+	for _, root := range roots {
+		_, err = cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sf.mu.RLock()
+	sectors := sf.sectors
+	sf.mu.RUnlock()
+	if sectors != 5 {
+		t.Fatal("storage folder sector count was not repaired:", sectors)
+	}
+}
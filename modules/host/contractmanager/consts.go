@@ -50,6 +50,19 @@ const (
 	// sector counters on disk in AddSectorBatch and RemoveSectorBatch.
 	maxSectorBatchThreads = 100
 
+	// fillSectorRootsBatchSize is the number of roots that FillSectorRoots
+	// looks up per acquisition of the WAL lock.
+	fillSectorRootsBatchSize = 10e3
+
+	// tierScanBatchSize is the number of sectors of a storage folder that the
+	// tier balancer scans per acquisition of the WAL lock. It must be a
+	// multiple of storageFolderGranularity.
+	tierScanBatchSize = 1 << 16
+
+	// maxSectorReads is the value at which the read count of a sector stops
+	// increasing, preventing overflow.
+	maxSectorReads = 1 << 30
+
 	// sectorMetadataDiskSize defines the number of bytes it takes to store the
 	// metadata of a single sector on disk.
 	sectorMetadataDiskSize = 14
@@ -64,6 +77,16 @@ const (
 	// which is a high granluarity relative the to the TiBs of storage that
 	// hosts are expected to provide.
 	storageFolderGranularity = 64
+
+	// tierHeadroomDivisor determines how much of the fast storage tier is
+	// kept free for new sectors. Cold sectors are demoted to the slow tier
+	// until at least 1/tierHeadroomDivisor of the fast tier is free.
+	tierHeadroomDivisor = 10
+
+	// walSyncInterval is the amount of time that the sync loop waits between
+	// commits of the WAL. Changes made to the contract manager are batched
+	// and committed together at the next commit.
+	walSyncInterval = 500 * time.Millisecond
 )
 
 var (
@@ -128,4 +151,47 @@ var (
 		Standard: time.Second * 60 * 5,
 		Testing:  time.Second * 8,
 	}).(time.Duration)
+
+	// hotSectorReads is the number of reads after which a sector is
+	// considered hot and is moved to the fast storage tier. Read counts are
+	// halved after every tier balancing pass.
+	hotSectorReads = build.Select(build.Var{
+		Dev:      uint32(4),
+		Standard: uint32(8),
+		Testing:  uint32(3),
+	}).(uint32)
+
+	// maxTierMovesPerPass limits the number of sectors that are moved between
+	// the storage tiers in a single tier balancing pass.
+	maxTierMovesPerPass = build.Select(build.Var{
+		Dev:      int(1e3),
+		Standard: int(10e3),
+		Testing:  int(100),
+	}).(int)
+
+	// scrubBytesPerSecond limits the rate at which the background scrubber
+	// reads sectors from disk, so that scrubbing does not compete with
+	// renters for disk bandwidth.
+	scrubBytesPerSecond = build.Select(build.Var{
+		Dev:      uint64(64 << 20),
+		Standard: uint64(16 << 20),
+		Testing:  uint64(1 << 30),
+	}).(uint64)
+
+	// scrubInterval specifies how long the contract manager waits between
+	// background scrubs of every sector. The first scrub starts one interval
+	// after startup.
+	scrubInterval = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: time.Hour * 24 * 7,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// tierInterval specifies how long the contract manager waits between
+	// passes that move sectors between the storage tiers.
+	tierInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
 )
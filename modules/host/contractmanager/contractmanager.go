@@ -92,6 +92,21 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// corruptSectors contains the sectors that the scrubber found to no
+	// longer match their ids. It is protected by the WAL mutex, and is
+	// persisted in the saved settings whenever the WAL commits.
+	corruptSectors map[sectorID]struct{}
+	scrubber       scrubber
+
+	// sectorReads counts the reads of each sector, and is used to decide
+	// which storage tier a sector belongs on. The counts are halved after
+	// every tier balancing pass so that sectors which stop being read cool
+	// down. It is protected by the WAL mutex, and is not persisted.
+	sectorReads map[sectorID]uint32
+
+	// sectorCache holds recently read sectors in memory.
+	sectorCache *sectorCache
+
 	// Utilities.
 	dependencies
 	log        *persist.Logger
@@ -112,12 +127,16 @@ func newContractManager(dependencies dependencies, persistDir string) (*Contract
 		storageFolders:  make(map[uint16]*storageFolder),
 		sectorLocations: make(map[sectorID]sectorLocation),
 
-		lockedSectors: make(map[sectorID]*sectorLock),
+		lockedSectors:  make(map[sectorID]*sectorLock),
+		corruptSectors: make(map[sectorID]struct{}),
+		sectorReads:    make(map[sectorID]uint32),
+		sectorCache:    newSectorCache(),
 
 		dependencies: dependencies,
 		persistDir:   persistDir,
 	}
 	cm.wal.cm = cm
+	cm.scrubber.start = make(chan struct{}, 1)
 
 	dependencies.init()
 	cm.tg.AfterStop(func() {
@@ -213,6 +232,13 @@ func newContractManager(dependencies dependencies, persistDir string) (*Contract
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically checks every sector for
+	// corruption.
+	go cm.threadedScrubLoop()
+
+	// Spin up the thread that moves sectors between the storage tiers.
+	go cm.threadedTierLoop()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
@@ -1,12 +1,72 @@
 package contractmanager
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/fastrand"
 )
 
+const (
+	// slowDiskWriteLatency is the time that a simulated disk takes to write a
+	// sector.
+	slowDiskWriteLatency = 5 * time.Millisecond
+)
+
+type (
+	// dependencySlowDisks is a mocked dependency that places the data file of
+	// every storage folder on a simulated disk, which takes
+	// slowDiskWriteLatency to write a sector and can only write one sector at
+	// a time. If serial is set, all of the simulated disks share a single
+	// lock, so that only one sector is written at a time across the whole
+	// contract manager.
+	dependencySlowDisks struct {
+		productionDependencies
+		serial bool
+		mu     sync.Mutex
+	}
+
+	// slowDiskFile is a file on a simulated disk.
+	slowDiskFile struct {
+		*os.File
+		mu *sync.Mutex
+	}
+)
+
+// createFile returns a file on a simulated disk for the data files of storage
+// folders, and a normal file otherwise.
+func (d *dependencySlowDisks) createFile(s string) (file, error) {
+	f, err := os.Create(s)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(s, sectorFile) {
+		return f, nil
+	}
+	if d.serial {
+		return &slowDiskFile{File: f, mu: &d.mu}, nil
+	}
+	return &slowDiskFile{File: f, mu: new(sync.Mutex)}, nil
+}
+
+// WriteAt serializes sector writes to the file, taking slowDiskWriteLatency
+// for each sector.
+func (f *slowDiskFile) WriteAt(b []byte, offset int64) (int, error) {
+	if uint64(len(b)) == modules.SectorSize {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		time.Sleep(slowDiskWriteLatency)
+	}
+	return f.File.WriteAt(b, offset)
+}
+
 // BenchmarkSectorLocations explores the cost of creating the sectorLocations
 // map when there are 24 million elements to load. 24 million elements would
 // cover 96 TiB of data storage.
@@ -77,3 +137,95 @@ func BenchmarkStorageFolders(b *testing.B) {
 		randFreeSector(usage)
 	}
 }
+
+// BenchmarkAddSectorParallel adds batches of sectors concurrently to a
+// contract manager whose storage folders are each on their own simulated disk.
+// Sector data is written without holding the WAL lock, so concurrent writes to
+// different storage folders proceed in parallel. This is compared against
+// writing one sector at a time across all storage folders, which is how the
+// contract manager would behave if sector data were written under the WAL
+// lock. The parallel writes get faster as storage folders are added, until
+// they are bounded by the WAL sync interval, while the serial writes do not.
+//
+// With build tags 'testing debug':
+//
+//	writes=parallel/folders=1  1.61 s/op
+//	writes=parallel/folders=2  1.12 s/op
+//	writes=parallel/folders=4  0.61 s/op
+//	writes=parallel/folders=8  0.61 s/op
+//	writes=serial/folders=1    1.61 s/op
+//	writes=serial/folders=2    1.63 s/op
+//	writes=serial/folders=4    1.63 s/op
+//	writes=serial/folders=8    1.61 s/op
+func BenchmarkAddSectorParallel(b *testing.B) {
+	const batchSize = 256
+	for _, writes := range []string{"parallel", "serial"} {
+		for _, folders := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("writes=%v/folders=%v", writes, folders), func(b *testing.B) {
+				benchmarkAddSectorParallel(b, writes == "serial", folders, batchSize)
+			})
+		}
+	}
+}
+
+// benchmarkAddSectorParallel adds batches of batchSize sectors concurrently to
+// a contract manager with the provided number of storage folders, each on its
+// own simulated disk. If serial is set, the simulated disks only write one
+// sector at a time between them.
+func benchmarkAddSectorParallel(b *testing.B, serial bool, folders, batchSize int) {
+	cmt, err := newMockedContractManagerTester(&dependencySlowDisks{serial: serial}, b.Name())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cmt.panicClose()
+	for i := 0; i < folders; i++ {
+		storageFolderDir := filepath.Join(cmt.persistDir, fmt.Sprintf("storageFolder%v", i))
+		err = os.MkdirAll(storageFolderDir, 0700)
+		if err != nil {
+			b.Fatal(err)
+		}
+		err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*uint64(batchSize))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+
+	b.SetBytes(int64(batchSize) * int64(modules.SectorSize))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roots := make([]crypto.Hash, batchSize)
+		for j := range roots {
+			fastrand.Read(roots[j][:])
+		}
+
+		// Add the batch of sectors concurrently.
+		var wg sync.WaitGroup
+		for _, root := range roots {
+			wg.Add(1)
+			go func(root crypto.Hash) {
+				defer wg.Done()
+				err := cmt.cm.AddSector(root, data)
+				if err != nil {
+					b.Error(err)
+				}
+			}(root)
+		}
+		wg.Wait()
+
+		// Delete the batch to make room for the next one.
+		b.StopTimer()
+		for _, root := range roots {
+			wg.Add(1)
+			go func(root crypto.Hash) {
+				defer wg.Done()
+				err := cmt.cm.DeleteSector(root)
+				if err != nil {
+					b.Error(err)
+				}
+			}(root)
+		}
+		wg.Wait()
+		b.StartTimer()
+	}
+}
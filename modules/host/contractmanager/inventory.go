@@ -0,0 +1,169 @@
+package contractmanager
+
+import (
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// SectorLocation returns the storage folder and index at which a sector is
+// stored, along with the number of virtual sectors that reference it.
+func (cm *ContractManager) SectorLocation(root crypto.Hash) (modules.StoredSector, error) {
+	err := cm.tg.Add()
+	if err != nil {
+		return modules.StoredSector{}, err
+	}
+	defer cm.tg.Done()
+	id := cm.managedSectorID(root)
+
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	sl, exists := cm.sectorLocations[id]
+	if !exists {
+		return modules.StoredSector{}, ErrSectorNotFound
+	}
+	return modules.StoredSector{
+		Root:          root,
+		StorageFolder: sl.storageFolder,
+		Index:         sl.index,
+		Count:         sl.count,
+	}, nil
+}
+
+// StorageFolderSectors returns up to 'limit' of the sectors stored in the
+// storage folder with the provided index, ordered by their index within the
+// folder and starting at 'offset'. The total number of sectors in the folder
+// is returned as well. The contract manager only knows the salted ids of the
+// sectors, so the roots of the returned sectors are left empty; they can be
+// filled in using FillSectorRoots.
+func (cm *ContractManager) StorageFolderSectors(index uint16, offset, limit uint64) ([]modules.StoredSector, uint64, error) {
+	err := cm.tg.Add()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cm.tg.Done()
+
+	// Walk the usage of the folder in order of sector index, skipping the
+	// sectors that are marked as used but have been freed.
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists {
+		cm.wal.mu.Unlock()
+		return nil, 0, errStorageFolderNotFound
+	}
+	total := uint64(len(sf.sectorIDs))
+	sectors := []modules.StoredSector{}
+	var skipped uint64
+	for i := 0; i < len(sf.usage) && uint64(len(sectors)) < limit; i++ {
+		if sf.usage[i] == 0 {
+			continue
+		}
+		for j := uint32(0); j < storageFolderGranularity && uint64(len(sectors)) < limit; j++ {
+			if sf.usage[i]&(1<<j) == 0 {
+				continue
+			}
+			sectorIndex := uint32(i)*storageFolderGranularity + j
+			id, exists := sf.sectorIDs[sectorIndex]
+			if !exists {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			sectors = append(sectors, modules.StoredSector{
+				StorageFolder: index,
+				Index:         sectorIndex,
+				Count:         cm.sectorLocations[id].count,
+			})
+		}
+	}
+	cm.wal.mu.Unlock()
+	return sectors, total, nil
+}
+
+// FillSectorRoots fills in the root of each of the provided sectors whose root
+// is among the provided roots, matching the sectors by their location. Only
+// the locations of the provided sectors are indexed, and the WAL lock is held
+// for one batch of roots at a time.
+func (cm *ContractManager) FillSectorRoots(sectors []modules.StoredSector, roots []crypto.Hash) {
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	type location struct {
+		storageFolder uint16
+		index         uint32
+	}
+	positions := make(map[location]int, len(sectors))
+	for i, s := range sectors {
+		positions[location{s.StorageFolder, s.Index}] = i
+	}
+	for len(roots) > 0 && len(positions) > 0 {
+		batch := roots
+		if len(batch) > fillSectorRootsBatchSize {
+			batch = batch[:fillSectorRootsBatchSize]
+		}
+		roots = roots[len(batch):]
+
+		ids := make([]sectorID, len(batch))
+		for i, root := range batch {
+			ids[i] = cm.managedSectorID(root)
+		}
+		cm.wal.mu.Lock()
+		for i, id := range ids {
+			sl, exists := cm.sectorLocations[id]
+			if !exists {
+				continue
+			}
+			loc := location{sl.storageFolder, sl.index}
+			if j, exists := positions[loc]; exists {
+				sectors[j].Root = batch[i]
+				delete(positions, loc)
+			}
+		}
+		cm.wal.mu.Unlock()
+	}
+}
+
+// StorageFolderUsage returns statistics about the sectors stored in each
+// storage folder, ordered by storage folder index.
+func (cm *ContractManager) StorageFolderUsage() []modules.StorageFolderUsage {
+	err := cm.tg.Add()
+	if err != nil {
+		return nil
+	}
+	defer cm.tg.Done()
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+
+	usage := make(map[uint16]*modules.StorageFolderUsage)
+	for _, sf := range cm.storageFolders {
+		usage[sf.index] = &modules.StorageFolderUsage{
+			Index:             sf.index,
+			Path:              sf.path,
+			CountDistribution: make(map[uint16]uint64),
+		}
+	}
+	for _, sl := range cm.sectorLocations {
+		u, exists := usage[sl.storageFolder]
+		if !exists {
+			continue
+		}
+		u.PhysicalSectors++
+		u.VirtualSectors += uint64(sl.count)
+		u.CountDistribution[sl.count]++
+	}
+
+	sfus := make([]modules.StorageFolderUsage, 0, len(usage))
+	for _, u := range usage {
+		sfus = append(sfus, *u)
+	}
+	sort.Slice(sfus, func(i, j int) bool {
+		return sfus[i].Index < sfus[j].Index
+	})
+	return sfus
+}
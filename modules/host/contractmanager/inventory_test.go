@@ -0,0 +1,138 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestStorageFolderSectors checks that the sectors of a storage folder can be
+// enumerated page by page in order of their index, that the roots of the
+// returned sectors are filled out, and that the usage statistics count
+// virtual sectors.
+func TestStorageFolderSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := cmt.cm.StorageFolders()[0].Index
+
+	// Add five sectors, adding the first one three times.
+	var roots []crypto.Hash
+	for i := 0; i < 5; i++ {
+		var root crypto.Hash
+		fastrand.Read(root[:])
+		err = cmt.cm.AddSector(root, fastrand.Bytes(int(modules.SectorSize)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	err = cmt.cm.AddSectorBatch([]crypto.Hash{roots[0], roots[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Page through the sectors two at a time. Only the roots of the first
+	// three sectors are provided.
+	seen := make(map[crypto.Hash]struct{})
+	var lastIndex uint32
+	for offset := uint64(0); offset < 6; offset += 2 {
+		sectors, total, err := cmt.cm.StorageFolderSectors(index, offset, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmt.cm.FillSectorRoots(sectors, roots[:3])
+		if total != 5 {
+			t.Fatal("expected 5 sectors, got", total)
+		}
+		if len(sectors) != 2 && !(offset == 4 && len(sectors) == 1) {
+			t.Fatalf("unexpected page size at offset %v: %v", offset, len(sectors))
+		}
+		for _, s := range sectors {
+			if s.StorageFolder != index {
+				t.Fatalf("unexpected sector: %+v", s)
+			}
+			if len(seen) > 0 && s.Index <= lastIndex {
+				t.Fatal("sectors are not ordered by index")
+			}
+			lastIndex = s.Index
+			seen[s.Root] = struct{}{}
+		}
+	}
+	if len(seen) != 4 {
+		t.Fatal("paging did not return every sector once, with the provided roots")
+	}
+	for _, root := range roots[:3] {
+		if _, exists := seen[root]; !exists {
+			t.Fatal("root of a returned sector was not filled out")
+		}
+	}
+	_, _, err = cmt.cm.StorageFolderSectors(index+1, 0, 10)
+	if err != errStorageFolderNotFound {
+		t.Fatal("expected errStorageFolderNotFound, got", err)
+	}
+
+	// The location of the first sector should report its virtual sectors.
+	sl, err := cmt.cm.SectorLocation(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl.Root != roots[0] || sl.Count != 3 || sl.StorageFolder != index {
+		t.Fatalf("unexpected sector location: %+v", sl)
+	}
+	_, err = cmt.cm.SectorLocation(crypto.Hash{})
+	if err != ErrSectorNotFound {
+		t.Fatal("expected ErrSectorNotFound, got", err)
+	}
+
+	usage := cmt.cm.StorageFolderUsage()
+	if len(usage) != 1 {
+		t.Fatal("expected usage for 1 storage folder, got", len(usage))
+	}
+	u := usage[0]
+	if u.Path != storageFolderDir || u.PhysicalSectors != 5 || u.VirtualSectors != 7 {
+		t.Fatalf("unexpected storage folder usage: %+v", u)
+	}
+	if u.CountDistribution[1] != 4 || u.CountDistribution[3] != 1 {
+		t.Fatal("unexpected count distribution:", u.CountDistribution)
+	}
+
+	// A deleted sector should no longer be listed.
+	err = cmt.cm.DeleteSector(roots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectors, total, err := cmt.cm.StorageFolderSectors(index, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.FillSectorRoots(sectors, roots)
+	if total != 4 || len(sectors) != 4 {
+		t.Fatalf("expected 4 sectors after deletion, got %v of %v", len(sectors), total)
+	}
+	for _, s := range sectors {
+		if s.Root == roots[1] {
+			t.Fatal("deleted sector is still listed")
+		}
+	}
+}
@@ -19,6 +19,9 @@ type (
 		Index uint16
 		Path  string
 		Usage []uint64
+
+		// Fast indicates that the storage folder is on the fast tier.
+		Fast bool
 	}
 
 	// savedSettings contains fields that are saved atomically to disk inside
@@ -26,6 +29,10 @@ type (
 	savedSettings struct {
 		SectorSalt     crypto.Hash
 		StorageFolders []savedStorageFolder
+
+		// CorruptSectors contains the sectors that the scrubber found to no
+		// longer match their roots.
+		CorruptSectors []sectorID
 	}
 )
 
@@ -35,6 +42,7 @@ func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 		Index: sf.index,
 		Path:  sf.path,
 		Usage: make([]uint64, len(sf.usage)),
+		Fast:  sf.fast,
 	}
 	copy(ssf.Usage, sf.usage)
 	return ssf
@@ -71,11 +79,15 @@ func (cm *ContractManager) loadSettings() error {
 
 	// Copy the saved settings into the contract manager.
 	cm.sectorSalt = ss.SectorSalt
+	for _, id := range ss.CorruptSectors {
+		cm.corruptSectors[id] = struct{}{}
+	}
 	for i := range ss.StorageFolders {
 		sf := new(storageFolder)
 		sf.index = ss.StorageFolders[i].Index
 		sf.path = ss.StorageFolders[i].Path
 		sf.usage = ss.StorageFolders[i].Usage
+		sf.fast = ss.StorageFolders[i].Fast
 		sf.metadataFile, err = cm.dependencies.openFile(filepath.Join(ss.StorageFolders[i].Path, metadataFile), os.O_RDWR, 0700)
 		if err != nil {
 			// Mark the folder as unavailable and log an error.
@@ -92,6 +104,7 @@ func (cm *ContractManager) loadSettings() error {
 			}
 		}
 		sf.availableSectors = make(map[sectorID]uint32)
+		sf.sectorIDs = make(map[uint32]sectorID)
 		cm.storageFolders[sf.index] = sf
 	}
 	return nil
@@ -128,6 +141,7 @@ func (cm *ContractManager) loadSectorLocations(sf *storageFolder) {
 
 		// Add the sector to the sector location map.
 		cm.sectorLocations[id] = sl
+		sf.sectorIDs[sectorIndex] = id
 		sf.sectors++
 	}
 	atomic.StoreUint64(&sf.atomicUnavailable, 0)
@@ -153,5 +167,8 @@ func (cm *ContractManager) savedSettings() savedSettings {
 			sf.setUsage(sectorIndex)
 		}
 	}
+	for id := range cm.corruptSectors {
+		ss.CorruptSectors = append(ss.CorruptSectors, id)
+	}
 	return ss
 }
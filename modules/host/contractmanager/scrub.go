@@ -0,0 +1,266 @@
+package contractmanager
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errScrubInProgress is returned if a scrub is started while another
+	// scrub is already running.
+	errScrubInProgress = errors.New("a scrub is already in progress")
+
+	// errNoScrubInProgress is returned if a scrub is stopped while no scrub is
+	// running.
+	errNoScrubInProgress = errors.New("no scrub is in progress")
+)
+
+// scrubber tracks the state of the background sector scrubber. The set of
+// corrupt sectors is kept on the contract manager, protected by the WAL
+// mutex, so that it can be read alongside the sector locations. It is saved
+// with the contract manager settings each time the WAL is committed.
+type scrubber struct {
+	running           bool
+	sectorsScrubbed   uint64
+	sectorsTotal      uint64
+	passesCompleted   uint64
+	lastPassStarted   time.Time
+	lastPassCompleted time.Time
+	nextPass          time.Time
+
+	// start is used to trigger an immediate scrub, and stop is closed to
+	// abort the scrub that is in progress.
+	start chan struct{}
+	stop  chan struct{}
+
+	mu sync.Mutex
+}
+
+// corruptSectorCount returns the number of known corrupt sectors. Sectors
+// that have been removed since they were found to be corrupt are not counted.
+func (cm *ContractManager) corruptSectorCount() (corrupt uint64) {
+	for id := range cm.corruptSectors {
+		if _, exists := cm.sectorLocations[id]; exists {
+			corrupt++
+		}
+	}
+	return corrupt
+}
+
+// managedScrubSector reads a single sector from disk and checks that its data
+// still matches the sector id. Sectors that do not match are added to the set
+// of corrupt sectors, and sectors that match are removed from it, which covers
+// sectors that were corrupt but have since been rewritten. The sector cache is
+// bypassed, so that the data on disk is what gets checked.
+func (cm *ContractManager) managedScrubSector(id sectorID) error {
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	cm.wal.mu.Lock()
+	sl, exists1 := cm.sectorLocations[id]
+	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	var sectorFile file
+	if exists2 {
+		sectorFile = sf.sectorFile
+	}
+	cm.wal.mu.Unlock()
+	if !exists1 {
+		// The sector was removed since the scrub started.
+		cm.wal.mu.Lock()
+		delete(cm.corruptSectors, id)
+		cm.wal.mu.Unlock()
+		return ErrSectorNotFound
+	}
+	if !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		// The storage folder is missing, the sector will be checked during
+		// the next scrub.
+		return ErrSectorNotFound
+	}
+
+	sectorData, err := readSector(sectorFile, sl.index)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		cm.log.Printf("Scrub: unable to read sector at index %v of storage folder %v: %v", sl.index, sf.path, err)
+		return err
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	corrupt := cm.managedSectorID(crypto.MerkleRoot(sectorData)) != id
+
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	_, known := cm.corruptSectors[id]
+	if corrupt && !known {
+		cm.log.Printf("Scrub: sector at index %v of storage folder %v does not match its root", sl.index, sf.path)
+		cm.corruptSectors[id] = struct{}{}
+	} else if !corrupt && known {
+		delete(cm.corruptSectors, id)
+	}
+	if corrupt {
+		return ErrSectorCorrupt
+	}
+	return nil
+}
+
+// managedScrub reads every sector in the contract manager, checking each
+// against its id. The scrub is throttled to scrubBytesPerSecond, and can be
+// aborted by closing the stop channel.
+func (cm *ContractManager) managedScrub(stop <-chan struct{}) {
+	cm.wal.mu.Lock()
+	ids := make([]sectorID, 0, len(cm.sectorLocations))
+	for id := range cm.sectorLocations {
+		ids = append(ids, id)
+	}
+	cm.wal.mu.Unlock()
+
+	cm.scrubber.mu.Lock()
+	cm.scrubber.sectorsScrubbed = 0
+	cm.scrubber.sectorsTotal = uint64(len(ids))
+	cm.scrubber.lastPassStarted = time.Now()
+	cm.scrubber.mu.Unlock()
+	cm.log.Printf("Scrub: started checking %v sectors", len(ids))
+
+	sectorDelay := time.Duration(modules.SectorSize) * time.Second / time.Duration(scrubBytesPerSecond)
+	for _, id := range ids {
+		start := time.Now()
+		cm.managedScrubSector(id)
+		cm.scrubber.mu.Lock()
+		cm.scrubber.sectorsScrubbed++
+		cm.scrubber.mu.Unlock()
+
+		select {
+		case <-stop:
+			cm.log.Println("Scrub: stopped before completion")
+			return
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(sectorDelay - time.Since(start)):
+		}
+	}
+
+	cm.wal.mu.Lock()
+	corrupt := cm.corruptSectorCount()
+	cm.wal.mu.Unlock()
+	cm.scrubber.mu.Lock()
+	cm.scrubber.passesCompleted++
+	cm.scrubber.lastPassCompleted = time.Now()
+	cm.scrubber.mu.Unlock()
+	cm.log.Printf("Scrub: finished checking %v sectors, %v corrupt sectors known", len(ids), corrupt)
+}
+
+// threadedScrubLoop runs a scrub every scrubInterval, or when triggered by
+// StartScrub. The thread group is only held while a scrub is running, so that
+// calls to Flush do not wait for the loop itself.
+func (cm *ContractManager) threadedScrubLoop() {
+	for {
+		cm.scrubber.mu.Lock()
+		cm.scrubber.nextPass = time.Now().Add(scrubInterval)
+		cm.scrubber.mu.Unlock()
+
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-cm.scrubber.start:
+		case <-time.After(scrubInterval):
+		}
+
+		if cm.tg.Add() != nil {
+			return
+		}
+		stop := make(chan struct{})
+		cm.scrubber.mu.Lock()
+		cm.scrubber.running = true
+		cm.scrubber.stop = stop
+		cm.scrubber.mu.Unlock()
+
+		cm.managedScrub(stop)
+
+		cm.scrubber.mu.Lock()
+		cm.scrubber.running = false
+		cm.scrubber.stop = nil
+		cm.scrubber.mu.Unlock()
+		cm.tg.Done()
+	}
+}
+
+// ScrubStatus returns the progress of the background scrubber.
+func (cm *ContractManager) ScrubStatus() modules.StorageScrubStatus {
+	cm.wal.mu.Lock()
+	corrupt := cm.corruptSectorCount()
+	cm.wal.mu.Unlock()
+
+	cm.scrubber.mu.Lock()
+	defer cm.scrubber.mu.Unlock()
+	return modules.StorageScrubStatus{
+		Running:           cm.scrubber.running,
+		SectorsScrubbed:   cm.scrubber.sectorsScrubbed,
+		SectorsTotal:      cm.scrubber.sectorsTotal,
+		PassesCompleted:   cm.scrubber.passesCompleted,
+		LastPassStarted:   cm.scrubber.lastPassStarted,
+		LastPassCompleted: cm.scrubber.lastPassCompleted,
+		NextPass:          cm.scrubber.nextPass,
+
+		CorruptSectors: corrupt,
+	}
+}
+
+// SectorCorrupt returns true if the scrubber found that the data stored for
+// the sector no longer matches the sector root.
+func (cm *ContractManager) SectorCorrupt(root crypto.Hash) bool {
+	id := cm.managedSectorID(root)
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	_, corrupt := cm.corruptSectors[id]
+	return corrupt
+}
+
+// CheckSector reads a sector from disk, bypassing the sector cache, and
+// checks that its data still matches the sector root. ErrSectorNotFound is
+// returned if the sector is missing or its storage folder is unavailable, and
+// ErrSectorCorrupt if the data does not match. The set of corrupt sectors is
+// updated the same way as during a scrub.
+func (cm *ContractManager) CheckSector(root crypto.Hash) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	return cm.managedScrubSector(cm.managedSectorID(root))
+}
+
+// StartScrub starts a scrub of every sector immediately.
+func (cm *ContractManager) StartScrub() error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.scrubber.mu.Lock()
+	defer cm.scrubber.mu.Unlock()
+	if cm.scrubber.running {
+		return errScrubInProgress
+	}
+	select {
+	case cm.scrubber.start <- struct{}{}:
+	default:
+		// A start request is already pending.
+	}
+	return nil
+}
+
+// StopScrub stops the scrub that is in progress.
+func (cm *ContractManager) StopScrub() error {
+	cm.scrubber.mu.Lock()
+	defer cm.scrubber.mu.Unlock()
+	if !cm.scrubber.running || cm.scrubber.stop == nil {
+		return errNoScrubInProgress
+	}
+	close(cm.scrubber.stop)
+	cm.scrubber.stop = nil
+	return nil
+}
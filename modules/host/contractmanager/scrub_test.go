@@ -0,0 +1,184 @@
+package contractmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestScrub corrupts a sector on disk and checks that the scrubber finds it,
+// and only it.
+func TestScrub(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder and fill it with a few sectors.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	// A scrub of healthy sectors should not find anything.
+	err = cmt.cm.StopScrub()
+	if err != errNoScrubInProgress {
+		t.Fatal("expected errNoScrubInProgress, got", err)
+	}
+	err = cmt.cm.StartScrub()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 50*time.Millisecond, func() error {
+		if cmt.cm.ScrubStatus().PassesCompleted != 1 {
+			return errors.New("scrub did not complete")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := cmt.cm.ScrubStatus()
+	if status.CorruptSectors != 0 || status.SectorsScrubbed != 5 || status.SectorsTotal != 5 {
+		t.Fatalf("unexpected scrub status: %+v", status)
+	}
+
+	// Overwrite part of the second sector on disk.
+	id := cmt.cm.managedSectorID(roots[1])
+	cmt.cm.wal.mu.Lock()
+	sl := cmt.cm.sectorLocations[id]
+	sf := cmt.cm.storageFolders[sl.storageFolder]
+	cmt.cm.wal.mu.Unlock()
+	_, err = sf.sectorFile.WriteAt(fastrand.Bytes(64), int64(uint64(sl.index)*modules.SectorSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Scrub again, the corrupt sector should be found.
+	err = cmt.cm.StartScrub()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 50*time.Millisecond, func() error {
+		if cmt.cm.ScrubStatus().PassesCompleted != 2 {
+			return errors.New("scrub did not complete")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmt.cm.ScrubStatus().CorruptSectors != 1 {
+		t.Fatal("scrub did not find the corrupt sector")
+	}
+	for i, root := range roots {
+		if cmt.cm.SectorCorrupt(root) != (i == 1) {
+			t.Fatal("wrong corruption status for sector", i)
+		}
+	}
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].CorruptSectors != 1 {
+		t.Fatal("storage folder does not report the corrupt sector")
+	}
+
+	// Removing the corrupt sector should clear it from the counts.
+	err = cmt.cm.RemoveSector(roots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmt.cm.ScrubStatus().CorruptSectors != 0 {
+		t.Fatal("removed sector is still counted as corrupt")
+	}
+	if cmt.cm.StorageFolders()[0].CorruptSectors != 0 {
+		t.Fatal("removed sector is still counted as corrupt by the storage folder")
+	}
+}
+
+// TestCorruptSectorsPersist checks that the set of corrupt sectors survives a
+// restart of the contract manager, and that removed sectors are dropped from
+// it.
+func TestCorruptSectorsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := make([]crypto.Hash, 2)
+	for i := range roots {
+		fastrand.Read(roots[i][:])
+		err = cmt.cm.AddSector(roots[i], fastrand.Bytes(int(modules.SectorSize)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mark both sectors as corrupt, then remove the second one.
+	cmt.cm.wal.mu.Lock()
+	for _, root := range roots {
+		cmt.cm.corruptSectors[cmt.cm.managedSectorID(root)] = struct{}{}
+	}
+	cmt.cm.wal.mu.Unlock()
+	err = cmt.cm.RemoveSector(roots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the contract manager.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmt.cm.SectorCorrupt(roots[0]) {
+		t.Fatal("corrupt sector was not persisted")
+	}
+	if cmt.cm.SectorCorrupt(roots[1]) {
+		t.Fatal("removed sector is still marked as corrupt")
+	}
+	if cmt.cm.ScrubStatus().CorruptSectors != 1 {
+		t.Fatal("wrong number of corrupt sectors after restart")
+	}
+}
@@ -31,6 +31,10 @@ var (
 	// the maximum number of virtual sectors for that sector id already exist.
 	errMaxVirtualSectors = errors.New("sector collides with a physical sector that already has the maximum allowed number of virtual sectors")
 
+	// ErrSectorCorrupt is returned when the data stored for a sector no
+	// longer matches the sector root.
+	ErrSectorCorrupt = errors.New("sector data does not match the sector root")
+
 	// ErrSectorNotFound is returned when a lookup for a sector fails.
 	ErrSectorNotFound = errors.New("could not find the desired sector")
 )
@@ -139,6 +143,15 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 	cm.wal.mu.Lock()
 	sl, exists1 := cm.sectorLocations[id]
 	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	var sectorFile file
+	if exists2 {
+		// The file handle is fetched under lock, as it is swapped out when
+		// the storage folder is moved.
+		sectorFile = sf.sectorFile
+	}
+	if exists1 && cm.sectorReads[id] < maxSectorReads {
+		cm.sectorReads[id]++
+	}
 	cm.wal.mu.Unlock()
 	if !exists1 {
 		return nil, ErrSectorNotFound
@@ -147,18 +160,22 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 		cm.log.Critical("Unable to load storage folder despite having sector metadata")
 		return nil, ErrSectorNotFound
 	}
+	if data, cached := cm.sectorCache.get(id); cached {
+		return data, nil
+	}
 	if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 		// TODO: Pick a new error instead.
 		return nil, ErrSectorNotFound
 	}
 
 	// Read the sector.
-	sectorData, err := readSector(sf.sectorFile, sl.index)
+	sectorData, err := readSector(sectorFile, sl.index)
 	if err != nil {
 		atomic.AddUint64(&sf.atomicFailedReads, 1)
 		return nil, build.ExtendErr("unable to fetch sector", err)
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	cm.sectorCache.add(id, sectorData)
 	return sectorData, nil
 }
 
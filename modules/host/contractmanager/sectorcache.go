@@ -0,0 +1,132 @@
+package contractmanager
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+type (
+	// sectorCache is a size-bounded cache of recently read sectors, evicting
+	// the least recently used sector when it is full. The cache is disabled
+	// when its capacity is zero.
+	sectorCache struct {
+		capacity uint64 // in sectors
+		entries  map[sectorID]*list.Element
+		lru      *list.List // front is the most recently used sector
+
+		hits      uint64
+		misses    uint64
+		evictions uint64
+
+		mu sync.Mutex
+	}
+
+	// sectorCacheEntry is the value of each element in the LRU list.
+	sectorCacheEntry struct {
+		id   sectorID
+		data []byte
+	}
+)
+
+// newSectorCache returns an empty, disabled sector cache.
+func newSectorCache() *sectorCache {
+	return &sectorCache{
+		entries: make(map[sectorID]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// evict removes the least recently used sectors until the cache is within its
+// capacity. The caller must hold the cache lock.
+func (sc *sectorCache) evict() {
+	for uint64(sc.lru.Len()) > sc.capacity {
+		e := sc.lru.Back()
+		sc.lru.Remove(e)
+		delete(sc.entries, e.Value.(*sectorCacheEntry).id)
+		sc.evictions++
+	}
+}
+
+// add places a sector in the cache. The data is copied, so that the caller
+// may modify it afterwards.
+func (sc *sectorCache) add(id sectorID, data []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.capacity == 0 {
+		return
+	}
+	if e, exists := sc.entries[id]; exists {
+		sc.lru.MoveToFront(e)
+		return
+	}
+	entry := &sectorCacheEntry{
+		id:   id,
+		data: make([]byte, len(data)),
+	}
+	copy(entry.data, data)
+	sc.entries[id] = sc.lru.PushFront(entry)
+	sc.evict()
+}
+
+// get returns a copy of a cached sector, and marks it as recently used.
+func (sc *sectorCache) get(id sectorID) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.capacity == 0 {
+		return nil, false
+	}
+	e, exists := sc.entries[id]
+	if !exists {
+		sc.misses++
+		return nil, false
+	}
+	sc.hits++
+	sc.lru.MoveToFront(e)
+	entry := e.Value.(*sectorCacheEntry)
+	data := make([]byte, len(entry.data))
+	copy(data, entry.data)
+	return data, true
+}
+
+// remove drops a sector from the cache.
+func (sc *sectorCache) remove(id sectorID) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if e, exists := sc.entries[id]; exists {
+		sc.lru.Remove(e)
+		delete(sc.entries, id)
+	}
+}
+
+// setCapacity sets the number of sectors that the cache can hold, evicting
+// sectors if the cache has shrunk.
+func (sc *sectorCache) setCapacity(capacity uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.capacity = capacity
+	sc.evict()
+}
+
+// SectorCacheMetrics returns the size and the hit and miss counts of the
+// sector cache.
+func (cm *ContractManager) SectorCacheMetrics() modules.StorageCacheMetrics {
+	cm.sectorCache.mu.Lock()
+	defer cm.sectorCache.mu.Unlock()
+	return modules.StorageCacheMetrics{
+		Capacity:  cm.sectorCache.capacity * modules.SectorSize,
+		Size:      uint64(cm.sectorCache.lru.Len()) * modules.SectorSize,
+		Sectors:   uint64(cm.sectorCache.lru.Len()),
+		Hits:      cm.sectorCache.hits,
+		Misses:    cm.sectorCache.misses,
+		Evictions: cm.sectorCache.evictions,
+	}
+}
+
+// SetSectorCacheSize sets the maximum number of bytes of sector data that are
+// kept in the sector cache. The size is rounded down to a whole number of
+// sectors, and a size of zero disables the cache.
+func (cm *ContractManager) SetSectorCacheSize(size uint64) {
+	cm.sectorCache.setCapacity(size / modules.SectorSize)
+}
@@ -0,0 +1,154 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestSectorCacheLRU checks that the sector cache evicts the least recently
+// used sectors.
+func TestSectorCacheLRU(t *testing.T) {
+	sc := newSectorCache()
+	ids := make([]sectorID, 3)
+	for i := range ids {
+		ids[i][0] = byte(i)
+	}
+
+	// A disabled cache holds nothing.
+	sc.add(ids[0], []byte{0})
+	if _, cached := sc.get(ids[0]); cached {
+		t.Fatal("disabled cache returned a sector")
+	}
+	if sc.hits != 0 || sc.misses != 0 {
+		t.Fatal("disabled cache should not count hits or misses")
+	}
+
+	sc.setCapacity(2)
+	sc.add(ids[0], []byte{0})
+	sc.add(ids[1], []byte{1})
+	if _, cached := sc.get(ids[0]); !cached {
+		t.Fatal("sector was not cached")
+	}
+	// ids[1] is now the least recently used sector.
+	sc.add(ids[2], []byte{2})
+	if _, cached := sc.get(ids[1]); cached {
+		t.Fatal("least recently used sector was not evicted")
+	}
+	data, cached := sc.get(ids[0])
+	if !cached || !bytes.Equal(data, []byte{0}) {
+		t.Fatal("recently used sector was evicted")
+	}
+	if sc.hits != 2 || sc.misses != 1 || sc.evictions != 1 {
+		t.Fatalf("wrong metrics: %v hits, %v misses, %v evictions", sc.hits, sc.misses, sc.evictions)
+	}
+
+	// Shrinking the cache evicts sectors.
+	sc.setCapacity(1)
+	if sc.lru.Len() != 1 || len(sc.entries) != 1 {
+		t.Fatal("cache was not shrunk")
+	}
+	sc.remove(ids[0])
+	sc.remove(ids[2])
+	if sc.lru.Len() != 0 || len(sc.entries) != 0 {
+		t.Fatal("sectors were not removed from the cache")
+	}
+}
+
+// TestSectorCache checks that sector reads are served from the cache, and
+// that removed sectors are dropped from the cache.
+func TestSectorCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := make([]crypto.Hash, 3)
+	datas := make([][]byte, 3)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		err = cmt.cm.AddSector(roots[i], datas[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmt.cm.SetSectorCacheSize(2*modules.SectorSize + 1)
+	if cmt.cm.SectorCacheMetrics().Capacity != 2*modules.SectorSize {
+		t.Fatal("cache size was not rounded down to whole sectors")
+	}
+
+	// The first read of each sector misses, the second read hits.
+	for i := 0; i < 2; i++ {
+		data, err := cmt.cm.ReadSector(roots[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[0]) {
+			t.Fatal("read returned the wrong data")
+		}
+		// Modifying the returned data should not affect the cache.
+		data[0]++
+	}
+	scm := cmt.cm.SectorCacheMetrics()
+	if scm.Hits != 1 || scm.Misses != 1 || scm.Sectors != 1 || scm.Size != modules.SectorSize {
+		t.Fatalf("wrong cache metrics: %+v", scm)
+	}
+
+	// Reading the other sectors should evict the first.
+	for _, root := range roots[1:] {
+		_, err = cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := cmt.cm.ReadSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, datas[0]) {
+		t.Fatal("read returned the wrong data")
+	}
+	scm = cmt.cm.SectorCacheMetrics()
+	if scm.Hits != 1 || scm.Misses != 4 || scm.Evictions != 2 {
+		t.Fatalf("wrong cache metrics: %+v", scm)
+	}
+
+	// A removed sector should not be served from the cache.
+	err = cmt.cm.RemoveSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cmt.cm.ReadSector(roots[0])
+	if err != ErrSectorNotFound {
+		t.Fatal("expected ErrSectorNotFound, got", err)
+	}
+	err = cmt.cm.DeleteSector(roots[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cmt.cm.ReadSector(roots[2])
+	if err != ErrSectorNotFound {
+		t.Fatal("expected ErrSectorNotFound, got", err)
+	}
+	if cmt.cm.SectorCacheMetrics().Sectors != 0 {
+		t.Fatal("removed sectors are still in the cache")
+	}
+}
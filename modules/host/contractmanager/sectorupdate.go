@@ -132,6 +132,7 @@ func (wal *writeAheadLog) managedAddPhysicalSector(id sectorID, data []byte, cou
 			})
 			delete(wal.cm.storageFolders[su.Folder].availableSectors, id)
 			wal.cm.sectorLocations[id] = sl
+			sf.sectorIDs[sectorIndex] = id
 			syncChan = wal.syncChan
 			wal.mu.Unlock()
 			return nil
@@ -216,6 +217,8 @@ func (wal *writeAheadLog) managedAddVirtualSector(id sectorID, location sectorLo
 
 // managedDeleteSector will delete a sector (physical) from the contract manager.
 func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
+	wal.cm.sectorCache.remove(id)
+
 	// Write the sector delete to the WAL.
 	var location sectorLocation
 	var syncChan chan struct{}
@@ -248,6 +251,8 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 
 		// Delete the sector and mark the usage as available.
 		delete(wal.cm.sectorLocations, id)
+		delete(wal.cm.corruptSectors, id)
+		delete(sf.sectorIDs, location.index)
 		sf.availableSectors[id] = location.index
 
 		// Block until the change has been committed.
@@ -271,6 +276,8 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 // managedRemoveSector will remove a sector (virtual or physical) from the
 // contract manager.
 func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
+	wal.cm.sectorCache.remove(id)
+
 	// Inform the WAL of the removed sector.
 	var location sectorLocation
 	var su sectorUpdate
@@ -309,6 +316,8 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 		if location.count == 0 {
 			// Delete the sector and mark it as available.
 			delete(wal.cm.sectorLocations, id)
+			delete(wal.cm.corruptSectors, id)
+			delete(sf.sectorIDs, location.index)
 			sf.availableSectors[id] = location.index
 		} else {
 			// Reduce the sector usage.
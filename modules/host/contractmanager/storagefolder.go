@@ -98,10 +98,11 @@ type storageFolder struct {
 	// an error if it is queried.
 	atomicUnavailable uint64 // uint64 for alignment
 
-	// The index, path, and usage are all saved directly to disk.
+	// The index, path, usage, and tier are all saved directly to disk.
 	index uint16
 	path  string
 	usage []uint64
+	fast  bool
 
 	// availableSectors indicates sectors which are marked as consumed in the
 	// usage field but are actually available. They cannot be marked as free in
@@ -113,6 +114,11 @@ type storageFolder struct {
 	availableSectors map[sectorID]uint32
 	sectors          uint64
 
+	// sectorIDs maps the index of each sector stored in the folder to the id
+	// of the sector, so that the sectors of a folder can be listed in order
+	// without scanning the sectorLocations map.
+	sectorIDs map[uint32]sectorID
+
 	// mu needs to be RLocked to safetly write new sectors into the storage
 	// folder. mu needs to be Locked when the folder is being added, removed,
 	// or resized.
@@ -208,31 +214,42 @@ func usageSectors(usage []uint64) (usageSectors []uint32) {
 // vacancyStorageFolder takes a set of storage folders and returns a storage
 // folder with vacancy for a sector along with its index. 'nil' and '-1' are
 // returned if none of the storage folders are available to accept a sector.
-// The returned storage folder will be holding an RLock on its mutex.
+// The returned storage folder will be holding an RLock on its mutex. Storage
+// folders on the fast tier are preferred over storage folders on the slow
+// tier.
 func vacancyStorageFolder(sfs []*storageFolder) (*storageFolder, int) {
 	enoughRoom := false
 	var winningIndex int
 
-	// Go through the folders in random order.
-	for _, index := range fastrand.Perm(len(sfs)) {
-		sf := sfs[index]
+	// Go through the fast folders in random order, followed by the slow
+	// folders in random order.
+	for _, fast := range []bool{true, false} {
+		for _, index := range fastrand.Perm(len(sfs)) {
+			sf := sfs[index]
+			if sf.fast != fast {
+				continue
+			}
 
-		// Skip past this storage folder if there is not enough room for at
-		// least one sector.
-		if sf.sectors >= uint64(len(sf.usage))*storageFolderGranularity {
-			continue
-		}
+			// Skip past this storage folder if there is not enough room for
+			// at least one sector.
+			if sf.sectors >= uint64(len(sf.usage))*storageFolderGranularity {
+				continue
+			}
 
-		// Skip past this storage folder if it's not available to receive new
-		// data.
-		if !sf.mu.TryRLock() {
-			continue
-		}
+			// Skip past this storage folder if it's not available to receive
+			// new data.
+			if !sf.mu.TryRLock() {
+				continue
+			}
 
-		// Select this storage folder.
-		enoughRoom = true
-		winningIndex = index
-		break
+			// Select this storage folder.
+			enoughRoom = true
+			winningIndex = index
+			break
+		}
+		if enoughRoom {
+			break
+		}
 	}
 	if !enoughRoom {
 		return nil, -1
@@ -398,6 +415,22 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 	cm.wal.mu.Lock()
 	defer cm.wal.mu.Unlock()
 
+	// Tally the corrupt sectors found by the scrubber in each storage folder.
+	corruptSectors := make(map[uint16]uint64)
+	for id := range cm.corruptSectors {
+		if sl, exists := cm.sectorLocations[id]; exists {
+			corruptSectors[sl.storageFolder]++
+		}
+	}
+
+	// Tally the hot sectors in each storage folder.
+	hotSectors := make(map[uint16]uint64)
+	for id, reads := range cm.sectorReads {
+		if sl, exists := cm.sectorLocations[id]; exists && reads >= hotSectorReads {
+			hotSectors[sl.storageFolder]++
+		}
+	}
+
 	// Iterate over the storage folders that are in memory first, and then
 	// suppliment them with the storage folders that are not in memory.
 	var smfs []modules.StorageFolderMetadata
@@ -411,6 +444,9 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			FailedWrites:     atomic.LoadUint64(&sf.atomicFailedWrites),
 			SuccessfulReads:  atomic.LoadUint64(&sf.atomicSuccessfulReads),
 			SuccessfulWrites: atomic.LoadUint64(&sf.atomicSuccessfulWrites),
+			CorruptSectors:   corruptSectors[sf.index],
+			HotSectors:       hotSectors[sf.index],
+			Tier:             modules.StorageTierSlow,
 
 			Capacity:          modules.SectorSize * 64 * uint64(len(sf.usage)),
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
@@ -418,6 +454,10 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			Path:              sf.path,
 		}
 
+		if sf.fast {
+			sfm.Tier = modules.StorageTierFast
+		}
+
 		// Set some of the values to extreme numbers if the storage folder is
 		// unavailable, to flag the user's attention.
 		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
@@ -308,8 +308,10 @@ func (wal *writeAheadLog) commitAddStorageFolder(ssf savedStorageFolder) {
 		index: ssf.Index,
 		path:  ssf.Path,
 		usage: ssf.Usage,
+		fast:  ssf.Fast,
 
 		availableSectors: make(map[sectorID]uint32),
+		sectorIDs:        make(map[uint32]sectorID),
 	}
 
 	var err error
@@ -366,6 +368,7 @@ func (cm *ContractManager) AddStorageFolder(path string, size uint64) error {
 		usage: make([]uint64, sectors/64),
 
 		availableSectors: make(map[sectorID]uint32),
+		sectorIDs:        make(map[uint32]sectorID),
 	}
 	err = cm.wal.managedAddStorageFolder(newSF)
 	if err != nil {
@@ -21,6 +21,15 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
+	wal.mu.Lock()
+	storageFolders := wal.cm.availableStorageFolders()
+	wal.mu.Unlock()
+	return wal.managedRelocateSector(id, storageFolders)
+}
+
+// managedRelocateSector will move a sector from its current storage folder to
+// one of the provided storage folders. The caller must hold the sector lock.
+func (wal *writeAheadLog) managedRelocateSector(id sectorID, storageFolders []*storageFolder) error {
 	// Find the sector to be moved.
 	wal.mu.Lock()
 	oldLocation, exists1 := wal.cm.sectorLocations[id]
@@ -48,9 +57,6 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	}
 
 	// Place the sector into its new folder and add the atomic move to the WAL.
-	wal.mu.Lock()
-	storageFolders := wal.cm.availableStorageFolders()
-	wal.mu.Unlock()
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
@@ -130,9 +136,11 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 				SectorUpdates: []sectorUpdate{oldSU, su},
 			})
 			oldFolder.clearUsage(oldLocation.index)
+			delete(oldFolder.sectorIDs, oldLocation.index)
 			delete(wal.cm.sectorLocations, oldSU.ID)
 			delete(sf.availableSectors, id)
 			wal.cm.sectorLocations[id] = sl
+			sf.sectorIDs[sectorIndex] = id
 			wal.mu.Unlock()
 			return nil
 		}()
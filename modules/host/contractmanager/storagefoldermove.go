@@ -0,0 +1,409 @@
+package contractmanager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errMoveSamePath is returned if a storage folder is moved to the path
+	// that it is already at.
+	errMoveSamePath = errors.New("storage folder is already at the provided path")
+
+	// errMoveTargetExists is returned if a storage folder is moved to a path
+	// that already contains storage folder files.
+	errMoveTargetExists = errors.New("the provided path already contains storage folder files")
+
+	// errMoveVerification is returned if the copy of a storage folder does
+	// not match the original after it has been written to the new path.
+	errMoveVerification = errors.New("the copied storage folder does not match the original")
+)
+
+type (
+	// storageFolderMove is the data saved to the WAL to indicate that a
+	// storage folder is being moved, or has been moved, from OldPath to
+	// NewPath.
+	storageFolderMove struct {
+		Index   uint16
+		OldPath string
+		NewPath string
+	}
+)
+
+// findUnfinishedStorageFolderMoves will scroll through a set of state changes
+// and pull out all of the storage folder moves which have not yet completed.
+func findUnfinishedStorageFolderMoves(scs []stateChange) []storageFolderMove {
+	usfmMap := make(map[uint16]storageFolderMove)
+	for _, sc := range scs {
+		for _, usfm := range sc.UnfinishedStorageFolderMoves {
+			usfmMap[usfm.Index] = usfm
+		}
+		for _, sfm := range sc.StorageFolderMoves {
+			delete(usfmMap, sfm.Index)
+		}
+		for _, index := range sc.ErroredStorageFolderMoves {
+			delete(usfmMap, index)
+		}
+		for _, sfr := range sc.StorageFolderRemovals {
+			delete(usfmMap, sfr.Index)
+		}
+	}
+
+	// Return the active unfinished storage folder moves as a slice.
+	usfms := make([]storageFolderMove, 0, len(usfmMap))
+	for _, usfm := range usfmMap {
+		usfms = append(usfms, usfm)
+	}
+	return usfms
+}
+
+// cleanupUnfinishedStorageFolderMoves will remove the partial copies left
+// behind by storage folder moves that were interrupted in the previous run.
+// The storage folder is still at its old path, so no data is lost.
+func (wal *writeAheadLog) cleanupUnfinishedStorageFolderMoves(scs []stateChange) {
+	usfms := findUnfinishedStorageFolderMoves(scs)
+	for _, usfm := range usfms {
+		sf, exists := wal.cm.storageFolders[usfm.Index]
+		if exists && sf.path == usfm.NewPath {
+			wal.cm.log.Critical("unfinished storage folder move exists where the storage folder has already been moved")
+			continue
+		}
+		wal.removeStorageFolderFiles(usfm.NewPath)
+
+		// Append an error call to the changeset, indicating that the storage
+		// folder move was not completed successfully.
+		wal.appendChange(stateChange{
+			ErroredStorageFolderMoves: []uint16{usfm.Index},
+		})
+	}
+}
+
+// commitStorageFolderMove will point a storage folder at its new path,
+// opening the files at the new path if the storage folder was loaded from its
+// old path, and removing the files at the old path. The commit is idempotent.
+func (wal *writeAheadLog) commitStorageFolderMove(sfm storageFolderMove) {
+	sf, exists := wal.cm.storageFolders[sfm.Index]
+	if !exists {
+		wal.cm.log.Critical("ERROR: storage folder move provided for storage folder that does not exist")
+		return
+	}
+
+	if sf.path != sfm.NewPath {
+		metadata, err := wal.cm.dependencies.openFile(filepath.Join(sfm.NewPath, metadataFile), os.O_RDWR, 0700)
+		if err != nil {
+			wal.cm.log.Printf("ERROR: unable to open the moved sector metadata file for %v: %v\n", sfm.NewPath, err)
+			return
+		}
+		sectors, err := wal.cm.dependencies.openFile(filepath.Join(sfm.NewPath, sectorFile), os.O_RDWR, 0700)
+		if err != nil {
+			wal.cm.log.Printf("ERROR: unable to open the moved sector file for %v: %v\n", sfm.NewPath, err)
+			metadata.Close()
+			return
+		}
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 0 {
+			sf.metadataFile.Close()
+			sf.sectorFile.Close()
+		}
+		sf.metadataFile = metadata
+		sf.sectorFile = sectors
+		sf.path = sfm.NewPath
+		atomic.StoreUint64(&sf.atomicUnavailable, 0)
+	}
+	wal.removeStorageFolderFiles(sfm.OldPath)
+}
+
+// removeStorageFolderFiles removes the storage folder files at the provided
+// path, ignoring files that do not exist. Nothing is removed if a storage
+// folder has since been added at the path.
+func (wal *writeAheadLog) removeStorageFolderFiles(path string) {
+	for _, sf := range wal.cm.storageFolders {
+		if sf.path == path {
+			return
+		}
+	}
+	for _, name := range []string{metadataFile, sectorFile} {
+		err := wal.cm.dependencies.removeFile(filepath.Join(path, name))
+		if err != nil && !os.IsNotExist(err) {
+			wal.cm.log.Printf("ERROR: unable to remove %v from %v: %v\n", name, path, err)
+		}
+	}
+}
+
+// copyFileData copies 'size' bytes from src to dst one sector at a time,
+// returning the hash of the data that was copied.
+func copyFileData(dst, src file, size int64, progress *uint64) (crypto.Hash, error) {
+	h := crypto.NewHash()
+	buf := make([]byte, modules.SectorSize)
+	for offset := int64(0); offset < size; offset += int64(len(buf)) {
+		if size-offset < int64(len(buf)) {
+			buf = buf[:size-offset]
+		}
+		n, err := src.ReadAt(buf, offset)
+		if err != nil && !(err == io.EOF && n == len(buf)) {
+			return crypto.Hash{}, build.ExtendErr("unable to read from storage folder", err)
+		}
+		_, err = dst.WriteAt(buf, offset)
+		if err != nil {
+			return crypto.Hash{}, build.ExtendErr("unable to write to new storage folder", err)
+		}
+		h.Write(buf)
+		atomic.AddUint64(progress, uint64(len(buf)))
+	}
+	var sum crypto.Hash
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// hashFileData returns the hash of the first 'size' bytes of f.
+func hashFileData(f file, size int64, progress *uint64) (crypto.Hash, error) {
+	h := crypto.NewHash()
+	buf := make([]byte, modules.SectorSize)
+	for offset := int64(0); offset < size; offset += int64(len(buf)) {
+		if size-offset < int64(len(buf)) {
+			buf = buf[:size-offset]
+		}
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && !(err == io.EOF && n == len(buf)) {
+			return crypto.Hash{}, build.ExtendErr("unable to read from new storage folder", err)
+		}
+		h.Write(buf)
+		atomic.AddUint64(progress, uint64(len(buf)))
+	}
+	var sum crypto.Hash
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// managedMoveStorageFolder copies the files of a storage folder to a new path,
+// verifies the copies, and then swaps the storage folder over to the new files.
+//
+// The storage folder is locked for the duration of the move, which prevents
+// new sectors from being written to it, but reads continue to be served from
+// the old files until the swap. Sector metadata can still change while the
+// sector file is being copied, so the metadata file is copied under the WAL
+// lock during the swap, and the in-memory sector locations are written over
+// the copy to pick up any metadata writes that have not yet reached the old
+// file.
+func (wal *writeAheadLog) managedMoveStorageFolder(index uint16, newPath string) (err error) {
+	// Retrieve the specified storage folder.
+	wal.mu.Lock()
+	sf, exists := wal.cm.storageFolders[index]
+	wal.mu.Unlock()
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return errStorageFolderNotFound
+	}
+
+	// Lock the storage folder for the duration of the operation.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	newMetadataName := filepath.Join(newPath, metadataFile)
+	newSectorName := filepath.Join(newPath, sectorFile)
+	sectorFileSize := int64(len(sf.usage)) * storageFolderGranularity * int64(modules.SectorSize)
+	metadataFileSize := int64(len(sf.usage)) * storageFolderGranularity * sectorMetadataDiskSize
+
+	// Write the intention to move the storage folder to the WAL, so that the
+	// partial copies can be removed in the event of unclean shutdown.
+	var oldPath string
+	var syncChan chan struct{}
+	err = func() error {
+		wal.mu.Lock()
+		defer wal.mu.Unlock()
+
+		if sf.path == newPath {
+			return errMoveSamePath
+		}
+		for _, csf := range wal.cm.storageFolders {
+			if csf.path == newPath {
+				return ErrRepeatFolder
+			}
+		}
+		for _, usfm := range findUnfinishedStorageFolderMoves(wal.uncommittedChanges) {
+			if usfm.NewPath == newPath {
+				return ErrRepeatFolder
+			}
+		}
+		for _, name := range []string{newMetadataName, newSectorName} {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				return errMoveTargetExists
+			}
+		}
+
+		oldPath = sf.path
+		wal.appendChange(stateChange{
+			UnfinishedStorageFolderMoves: []storageFolderMove{{
+				Index:   index,
+				OldPath: oldPath,
+				NewPath: newPath,
+			}},
+		})
+		syncChan = wal.syncChan
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	<-syncChan
+
+	// If there's an error in the rest of the function, remove the partial
+	// copies and signal in the WAL that the move has failed.
+	var newMetadata, newSector file
+	defer func() {
+		if err != nil {
+			if newMetadata != nil {
+				newMetadata.Close()
+			}
+			if newSector != nil {
+				newSector.Close()
+			}
+			wal.mu.Lock()
+			wal.removeStorageFolderFiles(newPath)
+			wal.appendChange(stateChange{
+				ErroredStorageFolderMoves: []uint16{index},
+			})
+			wal.mu.Unlock()
+		}
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	}()
+
+	// Copy the sector file to the new path and read it back to verify it.
+	atomic.StoreUint64(&sf.atomicProgressDenominator, uint64(2*sectorFileSize+metadataFileSize))
+	newMetadata, err = wal.cm.dependencies.createFile(newMetadataName)
+	if err != nil {
+		return build.ExtendErr("could not create storage folder file", err)
+	}
+	newSector, err = wal.cm.dependencies.createFile(newSectorName)
+	if err != nil {
+		return build.ExtendErr("could not create storage folder file", err)
+	}
+	copiedHash, err := copyFileData(newSector, sf.sectorFile, sectorFileSize, &sf.atomicProgressNumerator)
+	if err != nil {
+		return err
+	}
+	err = newSector.Sync()
+	if err != nil {
+		return build.ExtendErr("unable to sync the new sector file", err)
+	}
+	writtenHash, err := hashFileData(newSector, sectorFileSize, &sf.atomicProgressNumerator)
+	if err != nil {
+		return err
+	}
+	if copiedHash != writtenHash {
+		err = errMoveVerification
+		return err
+	}
+
+	// Simulate power failure at this point for some testing scenarios.
+	if wal.cm.dependencies.disrupt("incompleteMoveStorageFolder") {
+		newMetadata.Close()
+		newSector.Close()
+		return nil
+	}
+
+	// Copy the metadata file and swap the storage folder over to the new
+	// files.
+	wal.mu.Lock()
+	err = func() error {
+		numSectors := int(metadataFileSize / sectorMetadataDiskSize)
+		metadata, err := readFullMetadata(sf.metadataFile, numSectors)
+		if err != nil {
+			return err
+		}
+		for id, sl := range wal.cm.sectorLocations {
+			if sl.storageFolder != index {
+				continue
+			}
+			head := sectorMetadataDiskSize * int(sl.index)
+			copy(metadata[head:], id[:])
+			binary.LittleEndian.PutUint16(metadata[head+12:], sl.count)
+		}
+		_, err = newMetadata.WriteAt(metadata, 0)
+		if err != nil {
+			return build.ExtendErr("unable to write the new sector metadata file", err)
+		}
+		err = newMetadata.Sync()
+		if err != nil {
+			return build.ExtendErr("unable to sync the new sector metadata file", err)
+		}
+		written, err := readFullMetadata(newMetadata, numSectors)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(written, metadata) {
+			return errMoveVerification
+		}
+		return nil
+	}()
+	if err != nil {
+		wal.mu.Unlock()
+		return err
+	}
+	atomic.AddUint64(&sf.atomicProgressNumerator, uint64(metadataFileSize))
+	oldMetadata, oldSector := sf.metadataFile, sf.sectorFile
+	sf.metadataFile, sf.sectorFile = newMetadata, newSector
+	sf.path = newPath
+	wal.appendChange(stateChange{
+		StorageFolderMoves: []storageFolderMove{{
+			Index:   index,
+			OldPath: oldPath,
+			NewPath: newPath,
+		}},
+	})
+	syncChan = wal.syncChan
+	wal.mu.Unlock()
+
+	// Wait until the move has been synchronized, which also gives any reads
+	// that were in progress on the old files time to complete, and then
+	// remove the old files.
+	<-syncChan
+	err = build.ComposeErrors(oldMetadata.Close(), oldSector.Close())
+	if err != nil {
+		wal.cm.log.Printf("ERROR: unable to close the files of moved storage folder %v: %v\n", oldPath, err)
+		err = nil
+	}
+	wal.mu.Lock()
+	wal.removeStorageFolderFiles(oldPath)
+	wal.mu.Unlock()
+	wal.cm.log.Printf("Moved storage folder %v to %v\n", oldPath, newPath)
+	return nil
+}
+
+// MoveStorageFolder moves a storage folder to a new path, copying its files
+// and swapping them in atomically through the WAL. Sectors in the folder can
+// be read while the move is in progress.
+func (cm *ContractManager) MoveStorageFolder(index uint16, newPath string) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	// Check that the new path is an absolute path to an existing folder.
+	if !filepath.IsAbs(newPath) {
+		return errRelativePath
+	}
+	pathInfo, err := os.Stat(newPath)
+	if err != nil {
+		return err
+	}
+	if !pathInfo.Mode().IsDir() {
+		return errStorageFolderNotFolder
+	}
+
+	err = cm.wal.managedMoveStorageFolder(index, newPath)
+	if err != nil {
+		cm.log.Println("Call to MoveStorageFolder has failed:", err)
+		return err
+	}
+	return nil
+}
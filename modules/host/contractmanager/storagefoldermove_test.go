@@ -0,0 +1,236 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestMoveStorageFolder moves a storage folder while its sectors are being
+// read, and checks that the sectors survive the move and a restart.
+func TestMoveStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder with a few sectors, one of them virtual.
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	for _, dir := range []string{storageFolderOne, storageFolderTwo} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := cmt.cm.StorageFolders()[0].Index
+	roots := make([]crypto.Hash, 5)
+	datas := make([][]byte, 5)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		err = cmt.cm.AddSector(roots[i], datas[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddSector(roots[0], datas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid moves should be rejected.
+	err = cmt.cm.MoveStorageFolder(index, storageFolderOne)
+	if err != errMoveSamePath {
+		t.Fatal("expected errMoveSamePath, got", err)
+	}
+	err = cmt.cm.MoveStorageFolder(index, "relative/path")
+	if err != errRelativePath {
+		t.Fatal("expected errRelativePath, got", err)
+	}
+	err = cmt.cm.MoveStorageFolder(index+1, storageFolderTwo)
+	if err != errStorageFolderNotFound {
+		t.Fatal("expected errStorageFolderNotFound, got", err)
+	}
+
+	// Read the sectors continuously while the folder is moved.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for i, root := range roots {
+				data, err := cmt.cm.ReadSector(root)
+				if err != nil {
+					t.Error("sector read failed during the move:", err)
+					return
+				}
+				if !bytes.Equal(data, datas[i]) {
+					t.Error("sector read returned the wrong data during the move")
+					return
+				}
+			}
+		}
+	}()
+	err = cmt.cm.MoveStorageFolder(index, storageFolderTwo)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The folder should be at the new path, with the old files removed.
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != storageFolderTwo || sfs[0].Index != index {
+		t.Fatalf("storage folder was not moved: %+v", sfs)
+	}
+	if sfs[0].ProgressNumerator != 0 || sfs[0].ProgressDenominator != 0 {
+		t.Error("progress was not reset after the move")
+	}
+	for _, name := range []string{metadataFile, sectorFile} {
+		if _, err := os.Stat(filepath.Join(storageFolderOne, name)); !os.IsNotExist(err) {
+			t.Error("old storage folder file was not removed:", name)
+		}
+	}
+
+	// Restart the contract manager and check that the sectors are intact.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != storageFolderTwo {
+		t.Fatalf("storage folder move was not persisted: %+v", sfs)
+	}
+	for i, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector has the wrong data after the move")
+		}
+	}
+	sl, err := cmt.cm.SectorLocation(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl.Count != 2 {
+		t.Fatal("virtual sector count was lost in the move:", sl.Count)
+	}
+
+	// A path that already holds storage folder files cannot be used.
+	err = cmt.cm.MoveStorageFolder(index, storageFolderOne)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderTwo, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.MoveStorageFolder(index, storageFolderTwo)
+	if err != ErrRepeatFolder {
+		t.Fatal("expected ErrRepeatFolder, got", err)
+	}
+}
+
+// dependencyMoveNoFinalize will interrupt a storage folder move after the
+// files have been copied, before the move is committed to the WAL.
+type dependencyMoveNoFinalize struct {
+	productionDependencies
+}
+
+// disrupt will prevent the storage folder move from being finalized, and will
+// leave the WAL behind at shutdown.
+func (dependencyMoveNoFinalize) disrupt(s string) bool {
+	return s == "incompleteMoveStorageFolder" || s == "cleanWALFile"
+}
+
+// TestMoveStorageFolderShutdownAfterCopy simulates an unclean shutdown that
+// occurs after the storage folder files have been copied, but before the move
+// has been committed. After restart the storage folder should be at its old
+// path, and the partial copies should be removed.
+func TestMoveStorageFolderShutdownAfterCopy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyMoveNoFinalize)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	for _, dir := range []string{storageFolderOne, storageFolderTwo} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.MoveStorageFolder(cmt.cm.StorageFolders()[0].Index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the contract manager.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != storageFolderOne {
+		t.Fatalf("interrupted move should leave the storage folder in place: %+v", sfs)
+	}
+	for _, name := range []string{metadataFile, sectorFile} {
+		if _, err := os.Stat(filepath.Join(storageFolderTwo, name)); !os.IsNotExist(err) {
+			t.Error("partial copy was not removed:", name)
+		}
+	}
+	readData, err := cmt.cm.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, data) {
+		t.Fatal("sector has the wrong data after the interrupted move")
+	}
+}
@@ -0,0 +1,325 @@
+package contractmanager
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errInvalidTier is returned if a storage folder is assigned a tier other
+	// than modules.StorageTierFast or modules.StorageTierSlow.
+	errInvalidTier = errors.New("storage tier must be either 'fast' or 'slow'")
+
+	// errSourceFolderBusy is returned if a sector cannot be moved between the
+	// storage tiers because its storage folder is being modified.
+	errSourceFolderBusy = errors.New("the storage folder holding the sector is busy")
+)
+
+// storageFolderTierChange sets the tier of a storage folder.
+type storageFolderTierChange struct {
+	Index uint16
+	Fast  bool
+}
+
+// commitStorageFolderTierChange commits a tier change to the state.
+func (wal *writeAheadLog) commitStorageFolderTierChange(sftc storageFolderTierChange) {
+	sf, exists := wal.cm.storageFolders[sftc.Index]
+	if !exists {
+		wal.cm.log.Printf("ERROR: tier change for unknown storage folder %v\n", sftc.Index)
+		return
+	}
+	sf.fast = sftc.Fast
+}
+
+// managedMoveSectorToFolders moves a sector into one of the provided storage
+// folders, unless the sector is already in one of them. The storage folder
+// currently holding the sector is read locked during the move, which prevents
+// it from being moved, resized, or removed while the sector is read from it.
+func (wal *writeAheadLog) managedMoveSectorToFolders(id sectorID, targets []*storageFolder) error {
+	wal.managedLockSector(id)
+	defer wal.managedUnlockSector(id)
+
+	wal.mu.Lock()
+	sl, exists1 := wal.cm.sectorLocations[id]
+	sf, exists2 := wal.cm.storageFolders[sl.storageFolder]
+	wal.mu.Unlock()
+	if !exists1 || !exists2 {
+		return ErrSectorNotFound
+	}
+	for _, target := range targets {
+		if target == sf {
+			return nil
+		}
+	}
+	if !sf.mu.TryRLock() {
+		return errSourceFolderBusy
+	}
+	defer sf.mu.RUnlock()
+
+	// managedRelocateSector modifies the slice it is given.
+	storageFolders := make([]*storageFolder, len(targets))
+	copy(storageFolders, targets)
+	return wal.managedRelocateSector(id, storageFolders)
+}
+
+// managedColdSectors returns up to maxTierMovesPerPass of the sectors in the
+// provided fast storage folders that are not hot, ordered from least to most
+// read. The folders are scanned in batches, and the WAL lock is released
+// between batches so that the scan does not block other operations.
+func (cm *ContractManager) managedColdSectors(folders []*storageFolder) []sectorID {
+	// Candidates are bucketed by their read count. No bucket needs to hold
+	// more than maxTierMovesPerPass sectors, and the scan can stop as soon as
+	// the bucket of unread sectors is full.
+	buckets := make([][]sectorID, hotSectorReads)
+	for _, sf := range folders {
+		for start := 0; len(buckets[0]) < maxTierMovesPerPass; start += tierScanBatchSize / storageFolderGranularity {
+			cm.wal.mu.Lock()
+			if cm.storageFolders[sf.index] != sf || start >= len(sf.usage) {
+				cm.wal.mu.Unlock()
+				break
+			}
+			end := start + tierScanBatchSize/storageFolderGranularity
+			if end > len(sf.usage) {
+				end = len(sf.usage)
+			}
+			for i := start; i < end; i++ {
+				if sf.usage[i] == 0 {
+					continue
+				}
+				for j := uint32(0); j < storageFolderGranularity; j++ {
+					if sf.usage[i]&(1<<j) == 0 {
+						continue
+					}
+					id, exists := sf.sectorIDs[uint32(i)*storageFolderGranularity+j]
+					if !exists {
+						continue
+					}
+					reads := cm.sectorReads[id]
+					if reads < hotSectorReads && len(buckets[reads]) < maxTierMovesPerPass {
+						buckets[reads] = append(buckets[reads], id)
+					}
+				}
+			}
+			cm.wal.mu.Unlock()
+		}
+	}
+
+	var cold []sectorID
+	for _, bucket := range buckets {
+		cold = append(cold, bucket...)
+		if len(cold) >= maxTierMovesPerPass {
+			return cold[:maxTierMovesPerPass]
+		}
+	}
+	return cold
+}
+
+// managedBalanceTiers moves cold sectors from the fast storage tier to the
+// slow storage tier, and hot sectors from the slow tier to the fast tier.
+// Cold sectors are demoted until the fast tier has enough free space for the
+// hot sectors plus some headroom for new sectors. Afterwards the read counts
+// of all sectors are halved.
+func (cm *ContractManager) managedBalanceTiers() {
+	cm.wal.mu.Lock()
+	// Drop the read counts of sectors that have been removed.
+	for id := range cm.sectorReads {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			delete(cm.sectorReads, id)
+		}
+	}
+
+	// Split the storage folders by tier.
+	var fastFolders, slowFolders []*storageFolder
+	var fastCapacity, fastFree uint64
+	for _, sf := range cm.availableStorageFolders() {
+		if !sf.fast {
+			slowFolders = append(slowFolders, sf)
+			continue
+		}
+		fastFolders = append(fastFolders, sf)
+		capacity := uint64(len(sf.usage)) * storageFolderGranularity
+		fastCapacity += capacity
+		fastFree += capacity - sf.sectors
+	}
+	if len(fastFolders) == 0 || len(slowFolders) == 0 {
+		cm.wal.mu.Unlock()
+		cm.managedHalveSectorReads()
+		return
+	}
+
+	// Hot sectors have been read recently, so they are found among the
+	// sectors with read counts, and the most read sectors are promoted first.
+	var hot []sectorID
+	for id, reads := range cm.sectorReads {
+		if reads < hotSectorReads {
+			continue
+		}
+		sf, exists := cm.storageFolders[cm.sectorLocations[id].storageFolder]
+		if exists && !sf.fast {
+			hot = append(hot, id)
+		}
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		return cm.sectorReads[hot[i]] > cm.sectorReads[hot[j]]
+	})
+	cm.wal.mu.Unlock()
+
+	// Cold sectors are found by scanning the fast tier, and the least read
+	// sectors are demoted first.
+	cold := cm.managedColdSectors(fastFolders)
+
+	// Determine how many sectors to move.
+	headroom := fastCapacity / tierHeadroomDivisor
+	if len(hot) > maxTierMovesPerPass {
+		hot = hot[:maxTierMovesPerPass]
+	}
+	var demotions uint64
+	if needed := headroom + uint64(len(hot)); needed > fastFree {
+		demotions = needed - fastFree
+	}
+	if demotions > uint64(len(cold)) {
+		demotions = uint64(len(cold))
+	}
+	if demotions > uint64(maxTierMovesPerPass) {
+		demotions = uint64(maxTierMovesPerPass)
+	}
+
+	// Demote the cold sectors. The moves are stopped early at shutdown, but
+	// the moves which have been made still need to be synced.
+	stopped := func() bool {
+		select {
+		case <-cm.tg.StopChan():
+			return true
+		default:
+			return false
+		}
+	}
+	var demoted, promoted uint64
+	for _, id := range cold[:demotions] {
+		if stopped() {
+			break
+		}
+		err := cm.wal.managedMoveSectorToFolders(id, slowFolders)
+		if err == errInsufficientStorageForSector {
+			cm.log.Println("Tiers: the slow tier is full, unable to demote more sectors")
+			break
+		} else if err != nil {
+			cm.log.Println("Tiers: unable to demote sector:", err)
+			continue
+		}
+		demoted++
+	}
+
+	// Promote the hot sectors, leaving the headroom free.
+	if fastFree+demoted > headroom {
+		promotions := fastFree + demoted - headroom
+		if promotions < uint64(len(hot)) {
+			hot = hot[:promotions]
+		}
+	} else {
+		hot = nil
+	}
+	for _, id := range hot {
+		if stopped() {
+			break
+		}
+		err := cm.wal.managedMoveSectorToFolders(id, fastFolders)
+		if err == errInsufficientStorageForSector {
+			cm.log.Println("Tiers: the fast tier is full, unable to promote more sectors")
+			break
+		} else if err != nil {
+			cm.log.Println("Tiers: unable to promote sector:", err)
+			continue
+		}
+		promoted++
+	}
+
+	// Halve the read counts so that sectors which are no longer being read
+	// cool down.
+	syncChan := cm.managedHalveSectorReads()
+	if demoted == 0 && promoted == 0 {
+		return
+	}
+
+	// Wait for the moves to be synced to disk.
+	<-syncChan
+	cm.log.Printf("Tiers: demoted %v cold sectors and promoted %v hot sectors", demoted, promoted)
+}
+
+// managedHalveSectorReads halves the read counts of all sectors, dropping the
+// sectors that reach zero. The current sync channel of the WAL is returned.
+func (cm *ContractManager) managedHalveSectorReads() chan struct{} {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	for id, reads := range cm.sectorReads {
+		if reads <= 1 {
+			delete(cm.sectorReads, id)
+		} else {
+			cm.sectorReads[id] = reads / 2
+		}
+	}
+	return cm.wal.syncChan
+}
+
+// threadedTierLoop periodically moves sectors between the storage tiers.
+func (cm *ContractManager) threadedTierLoop() {
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(tierInterval):
+		}
+
+		if cm.tg.Add() != nil {
+			return
+		}
+		cm.managedBalanceTiers()
+		cm.tg.Done()
+	}
+}
+
+// SetStorageFolderTier sets the tier of the storage folder with the provided
+// index. Sectors are moved between the tiers in the background.
+func (cm *ContractManager) SetStorageFolderTier(index uint16, tier string) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	var fast bool
+	switch tier {
+	case modules.StorageTierFast:
+		fast = true
+	case modules.StorageTierSlow:
+	default:
+		return errInvalidTier
+	}
+
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists {
+		cm.wal.mu.Unlock()
+		return errStorageFolderNotFound
+	}
+	if sf.fast == fast {
+		cm.wal.mu.Unlock()
+		return nil
+	}
+	sftc := storageFolderTierChange{
+		Index: index,
+		Fast:  fast,
+	}
+	cm.wal.appendChange(stateChange{
+		StorageFolderTierChanges: []storageFolderTierChange{sftc},
+	})
+	cm.wal.commitStorageFolderTierChange(sftc)
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+	cm.log.Printf("Set the tier of storage folder %v to %v\n", sf.path, tier)
+	return nil
+}
@@ -0,0 +1,158 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestStorageTiers checks that new sectors are placed on the fast tier, and
+// that hot and cold sectors are moved between the tiers.
+func TestStorageTiers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a slow storage folder and a fast storage folder.
+	slowDir := filepath.Join(cmt.persistDir, "slow")
+	fastDir := filepath.Join(cmt.persistDir, "fast")
+	for _, dir := range []string{slowDir, fastDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var slowIndex, fastIndex uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Tier != modules.StorageTierSlow {
+			t.Fatal("storage folders should be slow by default")
+		}
+		if sf.Path == fastDir {
+			fastIndex = sf.Index
+		} else {
+			slowIndex = sf.Index
+		}
+	}
+	err = cmt.cm.SetStorageFolderTier(fastIndex, "medium")
+	if err != errInvalidTier {
+		t.Fatal("expected errInvalidTier, got", err)
+	}
+	err = cmt.cm.SetStorageFolderTier(fastIndex+slowIndex+1, modules.StorageTierFast)
+	if err != errStorageFolderNotFound {
+		t.Fatal("expected errStorageFolderNotFound, got", err)
+	}
+	err = cmt.cm.SetStorageFolderTier(fastIndex, modules.StorageTierFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill the fast storage folder, and then add a few sectors which will
+	// have to go to the slow folder.
+	extra := 10
+	roots := make([]crypto.Hash, storageFolderGranularity+extra)
+	datas := make([][]byte, len(roots))
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		err = cmt.cm.AddSector(roots[i], datas[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sl, err := cmt.cm.SectorLocation(roots[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < storageFolderGranularity && sl.StorageFolder != fastIndex {
+			t.Fatal("new sector was not placed on the fast tier")
+		} else if i >= storageFolderGranularity && sl.StorageFolder != slowIndex {
+			t.Fatal("new sector was not placed on the slow tier once the fast tier was full")
+		}
+	}
+
+	// Read a few of the sectors on the slow tier often enough to make them
+	// hot.
+	hot := roots[storageFolderGranularity : storageFolderGranularity+3]
+	for i := uint32(0); i < hotSectorReads; i++ {
+		for _, root := range hot {
+			_, err = cmt.cm.ReadSector(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == slowIndex && sf.HotSectors != uint64(len(hot)) {
+			t.Fatal("wrong number of hot sectors reported:", sf.HotSectors)
+		}
+	}
+
+	// Balance the tiers. The hot sectors should be promoted, and enough cold
+	// sectors demoted to leave headroom on the fast tier.
+	cmt.cm.managedBalanceTiers()
+	for _, root := range hot {
+		sl, err := cmt.cm.SectorLocation(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sl.StorageFolder != fastIndex {
+			t.Fatal("hot sector was not promoted to the fast tier")
+		}
+	}
+	headroom := storageFolderGranularity / tierHeadroomDivisor
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == fastIndex && sf.CapacityRemaining != uint64(headroom)*modules.SectorSize {
+			t.Fatal("fast tier does not have the expected headroom:", sf.CapacityRemaining/modules.SectorSize)
+		}
+		if sf.HotSectors != 0 {
+			t.Fatal("read counts should have cooled down after balancing")
+		}
+	}
+	for i, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector has the wrong data after balancing the tiers")
+		}
+	}
+
+	// The tier should persist across a restart.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == fastIndex && sf.Tier != modules.StorageTierFast {
+			t.Fatal("fast tier was not persisted")
+		} else if sf.Index == slowIndex && sf.Tier != modules.StorageTierSlow {
+			t.Fatal("slow tier was not persisted")
+		}
+	}
+	for i, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector has the wrong data after restart")
+		}
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 )
 
@@ -56,6 +58,17 @@ type (
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
+		// Moving a storage folder follows the same pattern. The move is
+		// logged as an 'UnfinishedStorageFolderMove' while the files are
+		// being copied, and then as a 'StorageFolderMove' once the storage
+		// folder has been swapped over to the new files.
+		ErroredStorageFolderMoves    []uint16
+		StorageFolderMoves           []storageFolderMove
+		UnfinishedStorageFolderMoves []storageFolderMove
+
+		// Changes to the tier of a storage folder.
+		StorageFolderTierChanges []storageFolderTierChange
+
 		// Updates to the sector metadata. Careful ordering of events ensures
 		// that a sector update will not make it into the synced WAL unless the
 		// sector data is already on-disk and synced.
@@ -94,6 +107,14 @@ type (
 		syncChan           chan struct{}
 		uncommittedChanges []stateChange
 
+		// Diagnostics. fileWALTmpSize is the number of bytes written to the
+		// tmp WAL file since it was created, checkpoint describes the most
+		// recent commit of the WAL, and recoveredChanges is the number of
+		// changes that were replayed at startup.
+		checkpoint       modules.WALCheckpoint
+		fileWALTmpSize   uint64
+		recoveredChanges uint64
+
 		// Utilities. The WAL needs access to the ContractManager because all
 		// mutations to ACID fields of the contract manager happen through the
 		// WAL.
@@ -119,17 +140,18 @@ func readWALMetadata(decoder *json.Decoder) error {
 	return nil
 }
 
-// writeWALMetadata writes WAL metadata to the input file.
-func writeWALMetadata(f file) error {
+// writeWALMetadata writes WAL metadata to the input file, returning the number
+// of bytes written.
+func writeWALMetadata(f file) (int, error) {
 	changeBytes, err := json.MarshalIndent(walMetadata, "", "\t")
 	if err != nil {
-		return build.ExtendErr("could not marshal WAL metadata", err)
+		return 0, build.ExtendErr("could not marshal WAL metadata", err)
 	}
-	_, err = f.Write(changeBytes)
+	n, err := f.Write(changeBytes)
 	if err != nil {
-		return build.ExtendErr("unable to write WAL metadata", err)
+		return n, build.ExtendErr("unable to write WAL metadata", err)
 	}
-	return nil
+	return n, nil
 }
 
 // appendChange will add a change to the WAL, writing the details of the change
@@ -151,6 +173,7 @@ func (wal *writeAheadLog) appendChange(sc stateChange) {
 		wal.cm.log.Severe("Unable to write state change to WAL:", err)
 		panic("unable to append a change to the WAL, crashing to prevent corruption")
 	}
+	wal.fileWALTmpSize += uint64(len(changeBytes))
 
 	// Update the WAL to include the new storage folder in the uncommitted
 	// changes.
@@ -185,6 +208,16 @@ func (wal *writeAheadLog) commitChange(sc stateChange) {
 			wal.commitStorageFolderRemoval(sfr)
 		}
 	}
+	for _, sfm := range sc.StorageFolderMoves {
+		for i := uint64(0); i < wal.cm.dependencies.atLeastOne(); i++ {
+			wal.commitStorageFolderMove(sfm)
+		}
+	}
+	for _, sftc := range sc.StorageFolderTierChanges {
+		for i := uint64(0); i < wal.cm.dependencies.atLeastOne(); i++ {
+			wal.commitStorageFolderTierChange(sftc)
+		}
+	}
 	for _, su := range sc.SectorUpdates {
 		for i := uint64(0); i < wal.cm.dependencies.atLeastOne(); i++ {
 			wal.commitUpdateSector(su)
@@ -201,17 +234,21 @@ func (wal *writeAheadLog) createWALTmp() {
 		wal.cm.log.Severe("Unable to create WAL temporary file:", err)
 		panic("unable to create WAL temporary file, crashing to avoid corruption")
 	}
-	err = writeWALMetadata(wal.fileWALTmp)
+	n, err := writeWALMetadata(wal.fileWALTmp)
 	if err != nil {
 		wal.cm.log.Severe("Unable to write WAL metadata:", err)
 		panic("unable to create WAL temporary file, crashing to prevent corruption")
 	}
+	wal.fileWALTmpSize = uint64(n)
 }
 
 // recoverWAL will read a previous WAL and re-commit all of the changes inside,
 // restoring the program to consistency after an unclean shutdown. The tmp WAL
-// file needs to be open before this function is called.
+// file needs to be open before this function is called. Each recovery step is
+// logged as a set of key=value pairs.
 func (wal *writeAheadLog) recoverWAL(walFile file) error {
+	start := time.Now()
+
 	// Read the WAL metadata to make sure that the version is correct.
 	decoder := json.NewDecoder(walFile)
 	err := readWALMetadata(decoder)
@@ -223,22 +260,39 @@ func (wal *writeAheadLog) recoverWAL(walFile file) error {
 	// Read changes from the WAL one at a time and load them back into memory.
 	// A full list of changes is kept so that modifications to long running
 	// changes can be parsed properly.
-	var sc stateChange
 	var scs []stateChange
+	var size uint64
 	for err == nil {
-		err = decoder.Decode(&sc)
-		if err == nil {
-			// The uncommitted changes are loaded into memory using a simple
-			// append, because the tmp WAL file has not been created yet, and
-			// will not be created until the sync loop is spawned. The sync
-			// loop spawner will make sure that the uncommitted changes are
-			// written to the tmp WAL file.
-			wal.commitChange(sc)
-			scs = append(scs, sc)
+		var raw json.RawMessage
+		err = decoder.Decode(&raw)
+		if err != nil {
+			break
+		}
+		var sc stateChange
+		err = json.Unmarshal(raw, &sc)
+		if err != nil {
+			break
+		}
+		size += uint64(len(raw))
+
+		// The uncommitted changes are loaded into memory using a simple
+		// append, because the tmp WAL file has not been created yet, and will
+		// not be created until the sync loop is spawned. The sync loop spawner
+		// will make sure that the uncommitted changes are written to the tmp
+		// WAL file.
+		wc := modules.WALChange{
+			Size:       uint64(len(raw)),
+			Operations: walOperations(sc),
+		}
+		if len(wc.Operations) > 0 {
+			wal.cm.log.Printf("WAL recovery: step=replay change=%v %v\n", len(scs)+1, walChangeFields(wc))
+			wal.recoveredChanges++
 		}
+		wal.commitChange(sc)
+		scs = append(scs, sc)
 	}
 	if err != io.EOF {
-		wal.cm.log.Println("ERROR: could not load WAL json:", err)
+		wal.cm.log.Printf("ERROR: could not load WAL json after %v changes: %v\n", len(scs), err)
 		return build.ExtendErr("error loading WAL json", err)
 	}
 
@@ -246,8 +300,12 @@ func (wal *writeAheadLog) recoverWAL(walFile file) error {
 	// task cleanup cannot be handled in the 'commitChange' loop because future
 	// state changes may indicate that the long running task has actually been
 	// completed.
+	wal.cm.log.Printf("WAL recovery: step=cleanup unfinishedadditions=%v unfinishedextensions=%v unfinishedmoves=%v\n",
+		len(findUnfinishedStorageFolderAdditions(scs)), len(findUnfinishedStorageFolderExtensions(scs)), len(findUnfinishedStorageFolderMoves(scs)))
 	wal.cleanupUnfinishedStorageFolderAdditions(scs)
 	wal.cleanupUnfinishedStorageFolderExtensions(scs)
+	wal.cleanupUnfinishedStorageFolderMoves(scs)
+	wal.cm.log.Printf("WAL recovery: step=complete changes=%v replayed=%v size=%v duration=%v\n", len(scs), wal.recoveredChanges, size, time.Since(start))
 	return nil
 }
 
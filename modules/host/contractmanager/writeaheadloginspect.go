@@ -0,0 +1,216 @@
+package contractmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// writeaheadloginspect.go provides visibility into the WAL. The operations in
+// a state change can be summarized, a WAL file can be inspected without
+// applying it, and the size and last checkpoint of the running WAL can be
+// reported.
+
+// walOperations summarizes the operations in a state change, grouped by type.
+// Types without any operations are left out.
+func walOperations(sc stateChange) []modules.WALOperation {
+	var ops []modules.WALOperation
+	add := func(opType string, folders []uint16) {
+		if len(folders) == 0 {
+			return
+		}
+		op := modules.WALOperation{
+			Type:  opType,
+			Count: uint64(len(folders)),
+		}
+		seen := make(map[uint16]struct{})
+		for _, folder := range folders {
+			if _, exists := seen[folder]; !exists {
+				seen[folder] = struct{}{}
+				op.Folders = append(op.Folders, folder)
+			}
+		}
+		sort.Slice(op.Folders, func(i, j int) bool {
+			return op.Folders[i] < op.Folders[j]
+		})
+		ops = append(ops, op)
+	}
+
+	var folders []uint16
+	for _, sfa := range sc.UnfinishedStorageFolderAdditions {
+		folders = append(folders, sfa.Index)
+	}
+	add("unfinished storage folder addition", folders)
+	folders = nil
+	for _, sfa := range sc.StorageFolderAdditions {
+		folders = append(folders, sfa.Index)
+	}
+	add("storage folder addition", folders)
+	add("errored storage folder addition", sc.ErroredStorageFolderAdditions)
+
+	folders = nil
+	for _, usfe := range sc.UnfinishedStorageFolderExtensions {
+		folders = append(folders, usfe.Index)
+	}
+	add("unfinished storage folder extension", folders)
+	folders = nil
+	for _, sfe := range sc.StorageFolderExtensions {
+		folders = append(folders, sfe.Index)
+	}
+	add("storage folder extension", folders)
+	add("errored storage folder extension", sc.ErroredStorageFolderExtensions)
+
+	folders = nil
+	for _, sfr := range sc.StorageFolderReductions {
+		folders = append(folders, sfr.Index)
+	}
+	add("storage folder reduction", folders)
+	folders = nil
+	for _, sfr := range sc.StorageFolderRemovals {
+		folders = append(folders, sfr.Index)
+	}
+	add("storage folder removal", folders)
+
+	folders = nil
+	for _, sfm := range sc.UnfinishedStorageFolderMoves {
+		folders = append(folders, sfm.Index)
+	}
+	add("unfinished storage folder move", folders)
+	folders = nil
+	for _, sfm := range sc.StorageFolderMoves {
+		folders = append(folders, sfm.Index)
+	}
+	add("storage folder move", folders)
+	add("errored storage folder move", sc.ErroredStorageFolderMoves)
+
+	folders = nil
+	for _, sftc := range sc.StorageFolderTierChanges {
+		folders = append(folders, sftc.Index)
+	}
+	add("storage folder tier change", folders)
+	folders = nil
+	for _, su := range sc.SectorUpdates {
+		folders = append(folders, su.Folder)
+	}
+	add("sector update", folders)
+	return ops
+}
+
+// walChangeFields formats a summarized change as key=value pairs for logging.
+func walChangeFields(wc modules.WALChange) string {
+	var ops []string
+	var folders []string
+	seen := make(map[uint16]struct{})
+	for _, op := range wc.Operations {
+		ops = append(ops, fmt.Sprintf("%v:%v", op.Type, op.Count))
+		for _, folder := range op.Folders {
+			if _, exists := seen[folder]; !exists {
+				seen[folder] = struct{}{}
+				folders = append(folders, fmt.Sprint(folder))
+			}
+		}
+	}
+	return fmt.Sprintf("size=%v operations=%q folders=%v", wc.Size, strings.Join(ops, ","), strings.Join(folders, ","))
+}
+
+// InspectWAL reads the WAL in the contract manager's persist directory and
+// summarizes the changes that would be replayed at startup, without applying
+// any of them. The WAL only exists while the contract manager is running, or
+// after an unclean shutdown. If the WAL cannot be read completely, the
+// changes that were read are returned along with the error.
+func InspectWAL(persistDir string) (modules.WALInspection, error) {
+	inspection := modules.WALInspection{
+		Path: filepath.Join(persistDir, walFile),
+	}
+	f, err := os.Open(inspection.Path)
+	if os.IsNotExist(err) {
+		return inspection, nil
+	} else if err != nil {
+		return inspection, build.ExtendErr("unable to open the WAL", err)
+	}
+	defer f.Close()
+	inspection.Exists = true
+	fi, err := f.Stat()
+	if err != nil {
+		return inspection, build.ExtendErr("unable to stat the WAL", err)
+	}
+	inspection.Size = uint64(fi.Size())
+
+	decoder := json.NewDecoder(f)
+	err = readWALMetadata(decoder)
+	if err != nil {
+		return inspection, err
+	}
+	affected := make(map[uint16]struct{})
+	for {
+		var raw json.RawMessage
+		err = decoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return inspection, build.ExtendErr("error reading WAL json", err)
+		}
+		var sc stateChange
+		err = json.Unmarshal(raw, &sc)
+		if err != nil {
+			return inspection, build.ExtendErr("error decoding WAL change", err)
+		}
+		inspection.TotalChanges++
+
+		ops := walOperations(sc)
+		if len(ops) == 0 {
+			continue
+		}
+		inspection.Changes = append(inspection.Changes, modules.WALChange{
+			Size:       uint64(len(raw)),
+			Operations: ops,
+		})
+		for _, op := range ops {
+			for _, folder := range op.Folders {
+				affected[folder] = struct{}{}
+			}
+		}
+	}
+	for folder := range affected {
+		inspection.Folders = append(inspection.Folders, folder)
+	}
+	sort.Slice(inspection.Folders, func(i, j int) bool {
+		return inspection.Folders[i] < inspection.Folders[j]
+	})
+	return inspection, nil
+}
+
+// WALStatus returns the size of the WAL, the changes that will be committed at
+// the next checkpoint, and the last committed checkpoint.
+func (cm *ContractManager) WALStatus() modules.WALStatus {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+
+	status := modules.WALStatus{
+		Size:             cm.wal.fileWALTmpSize,
+		SyncInterval:     walSyncInterval,
+		LastCheckpoint:   cm.wal.checkpoint,
+		RecoveredChanges: cm.wal.recoveredChanges,
+	}
+	for _, sc := range cm.wal.uncommittedChanges {
+		ops := walOperations(sc)
+		if len(ops) == 0 {
+			continue
+		}
+		// The change was marshalled successfully when it was appended, so
+		// the error can be ignored.
+		changeBytes, _ := json.MarshalIndent(sc, "", "\t")
+		status.PendingChanges = append(status.PendingChanges, modules.WALChange{
+			Size:       uint64(len(changeBytes)),
+			Operations: ops,
+		})
+	}
+	return status
+}
@@ -0,0 +1,110 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestInspectWAL leaves a sector update in the WAL through an unclean
+// shutdown, and checks that the update is reported by the WAL inspector and
+// counted when the WAL is recovered.
+func TestInspectWAL(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyNoSettingsSave)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+	cmDir := filepath.Join(cmt.persistDir, modules.ContractManagerDir)
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := cmt.cm.StorageFolders()[0].Index
+
+	// Add a sector, then prevent the WAL from being overwritten, so that the
+	// sector update remains in the WAL.
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	err = cmt.cm.AddSector(root, fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.mu.Lock()
+	d.triggered = true
+	d.mu.Unlock()
+
+	status := cmt.cm.WALStatus()
+	if status.LastCheckpoint.Number == 0 || status.LastCheckpoint.Time.IsZero() {
+		t.Fatalf("no checkpoint reported: %+v", status.LastCheckpoint)
+	}
+	if status.SyncInterval != walSyncInterval || status.Size == 0 {
+		t.Fatalf("unexpected WAL status: %+v", status)
+	}
+	if status.RecoveredChanges != 0 {
+		t.Fatal("changes reported as recovered after a clean start:", status.RecoveredChanges)
+	}
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inspection, err := InspectWAL(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inspection.Exists || inspection.Size == 0 || inspection.TotalChanges == 0 {
+		t.Fatalf("WAL was not inspected: %+v", inspection)
+	}
+	found := false
+	for _, wc := range inspection.Changes {
+		if wc.Size == 0 {
+			t.Error("change reported without a size")
+		}
+		for _, op := range wc.Operations {
+			if op.Type == "sector update" && op.Count == 1 && len(op.Folders) == 1 && op.Folders[0] == index {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("sector update not found in the WAL: %+v", inspection.Changes)
+	}
+	if len(inspection.Folders) != 1 || inspection.Folders[0] != index {
+		t.Fatal("unexpected affected folders:", inspection.Folders)
+	}
+
+	// Recovering the WAL should replay the sector update.
+	cmt.cm, err = New(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmt.cm.WALStatus().RecoveredChanges == 0 {
+		t.Fatal("recovered changes were not counted")
+	}
+	_, err = cmt.cm.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a WAL, there is nothing to inspect.
+	inspection, err = InspectWAL(filepath.Join(cmt.persistDir, "nonexistent"))
+	if err != nil || inspection.Exists {
+		t.Fatal("expected an empty inspection:", inspection, err)
+	}
+}
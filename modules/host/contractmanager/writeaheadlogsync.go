@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
 )
 
 // syncResources will call Sync on all resources that the WAL has open. The
@@ -143,12 +144,28 @@ func (wal *writeAheadLog) syncResources() {
 //
 // commit should only be called from threadedSyncLoop.
 func (wal *writeAheadLog) commit() {
+	// Describe the checkpoint that is created by this commit.
+	start := time.Now()
+	checkpoint := modules.WALCheckpoint{
+		Number: wal.checkpoint.Number + 1,
+		Size:   wal.fileWALTmpSize,
+	}
+	for _, sc := range wal.uncommittedChanges {
+		if len(walOperations(sc)) > 0 {
+			checkpoint.Changes++
+		}
+	}
+
 	// Sync all open, non-WAL files on the host.
 	wal.syncResources()
+	checkpoint.Time = time.Now()
+	checkpoint.Duration = checkpoint.Time.Sub(start)
+	wal.checkpoint = checkpoint
 
 	// Extract any unfinished long-running jobs from the list of WAL items.
 	unfinishedAdditions := findUnfinishedStorageFolderAdditions(wal.uncommittedChanges)
 	unfinishedExtensions := findUnfinishedStorageFolderExtensions(wal.uncommittedChanges)
+	unfinishedMoves := findUnfinishedStorageFolderMoves(wal.uncommittedChanges)
 
 	// Clear the set of uncommitted changes.
 	wal.uncommittedChanges = nil
@@ -196,15 +213,17 @@ func (wal *writeAheadLog) commit() {
 			wal.cm.log.Severe("ERROR: unable to create write-ahead-log:", err)
 		}
 		// Write the metadata into the WAL.
-		err = writeWALMetadata(wal.fileWALTmp)
+		n, err := writeWALMetadata(wal.fileWALTmp)
 		if err != nil {
 			wal.cm.log.Severe("Unable to properly initialize WAL file, crashing to prevent corruption:", err)
 		}
+		wal.fileWALTmpSize = uint64(n)
 
 		// Append all of the remaining long running uncommitted changes to the WAL.
 		wal.appendChange(stateChange{
 			UnfinishedStorageFolderAdditions:  unfinishedAdditions,
 			UnfinishedStorageFolderExtensions: unfinishedExtensions,
+			UnfinishedStorageFolderMoves:      unfinishedMoves,
 		})
 	}()
 	wg.Wait()
@@ -263,13 +282,12 @@ func (wal *writeAheadLog) threadedSyncLoop(threadsStopped chan struct{}, syncLoo
 		return
 	}
 
-	syncInterval := 500 * time.Millisecond
 	for {
 		select {
 		case <-threadsStopped:
 			close(syncLoopStopped)
 			return
-		case <-time.After(syncInterval):
+		case <-time.After(walSyncInterval):
 			// Commit all of the changes in the WAL to disk, and then apply the
 			// changes.
 			wal.mu.Lock()
@@ -0,0 +1,207 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errBadSubnet is returned if a denylist entry is neither an IP address
+	// nor a subnet in CIDR notation.
+	errBadSubnet = errors.New("denylist subnets must be IP addresses or subnets in CIDR notation")
+
+	// errBadRenterKey is returned if a renter key added to the denylist is
+	// empty.
+	errBadRenterKey = errors.New("denylist renter keys must not be empty")
+
+	// errDeniedRenter is returned to renters whose public key is on the
+	// host's denylist.
+	errDeniedRenter = ErrorCommunication("rejected because the renter is on the host's denylist")
+)
+
+// parseSubnet parses a denylist subnet. A single IP address is treated as a
+// subnet containing only that address.
+func parseSubnet(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errBadSubnet
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, subnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, errBadSubnet
+	}
+	return subnet, nil
+}
+
+// setDenylist replaces the host's denylist. Subnets that fail to parse are
+// dropped, which can only happen if the persist file was edited by hand.
+func (h *Host) setDenylist(dl modules.HostDenylist) {
+	h.denylist = modules.HostDenylist{RenterKeys: dl.RenterKeys}
+	h.deniedSubnets = nil
+	for _, s := range dl.Subnets {
+		subnet, err := parseSubnet(s)
+		if err != nil {
+			h.log.Printf("WARN: dropping invalid denylist subnet %q: %v", s, err)
+			continue
+		}
+		h.denylist.Subnets = append(h.denylist.Subnets, subnet.String())
+		h.deniedSubnets = append(h.deniedSubnets, subnet)
+	}
+}
+
+// managedIPDenied returns true if the address belongs to a subnet on the
+// host's denylist.
+func (h *Host) managedIPDenied(addr net.Addr) bool {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, subnet := range h.deniedSubnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedRenterDenied returns true if the renter's public key is on the
+// host's denylist. The key must already have been checked against the unlock
+// conditions of the renter's file contract.
+func (h *Host) managedRenterDenied(renterPK crypto.PublicKey) bool {
+	spk := types.Ed25519PublicKey(renterPK)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, key := range h.denylist.RenterKeys {
+		if key.String() == spk.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// AddToDenylist adds IP subnets and renter public keys to the host's
+// denylist. Entries that are already on the denylist are ignored.
+func (h *Host) AddToDenylist(dl modules.HostDenylist) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	for _, s := range dl.Subnets {
+		if _, err := parseSubnet(s); err != nil {
+			return err
+		}
+	}
+	for _, key := range dl.RenterKeys {
+		if len(key.Key) == 0 {
+			return errBadRenterKey
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	newDenylist := modules.HostDenylist{
+		Subnets:    append([]string(nil), h.denylist.Subnets...),
+		RenterKeys: append([]types.SiaPublicKey(nil), h.denylist.RenterKeys...),
+	}
+	for _, s := range dl.Subnets {
+		subnet, _ := parseSubnet(s)
+		if !containsString(newDenylist.Subnets, subnet.String()) {
+			newDenylist.Subnets = append(newDenylist.Subnets, subnet.String())
+		}
+	}
+	for _, key := range dl.RenterKeys {
+		if !containsKey(newDenylist.RenterKeys, key) {
+			newDenylist.RenterKeys = append(newDenylist.RenterKeys, key)
+		}
+	}
+	h.setDenylist(newDenylist)
+	h.log.Printf("Denylist: added %v subnets and %v renter keys", len(dl.Subnets), len(dl.RenterKeys))
+	return h.saveSync()
+}
+
+// Denylist returns the IP subnets and renter public keys that the host refuses
+// to do business with.
+func (h *Host) Denylist() modules.HostDenylist {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return modules.HostDenylist{
+		Subnets:    append([]string{}, h.denylist.Subnets...),
+		RenterKeys: append([]types.SiaPublicKey{}, h.denylist.RenterKeys...),
+	}
+}
+
+// RemoveFromDenylist removes IP subnets and renter public keys from the host's
+// denylist. Entries that are not on the denylist are ignored.
+func (h *Host) RemoveFromDenylist(dl modules.HostDenylist) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	var remove []string
+	for _, s := range dl.Subnets {
+		subnet, err := parseSubnet(s)
+		if err != nil {
+			return err
+		}
+		remove = append(remove, subnet.String())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var newDenylist modules.HostDenylist
+	for _, s := range h.denylist.Subnets {
+		if !containsString(remove, s) {
+			newDenylist.Subnets = append(newDenylist.Subnets, s)
+		}
+	}
+	for _, key := range h.denylist.RenterKeys {
+		if !containsKey(dl.RenterKeys, key) {
+			newDenylist.RenterKeys = append(newDenylist.RenterKeys, key)
+		}
+	}
+	h.setDenylist(newDenylist)
+	h.log.Printf("Denylist: removed %v subnets and %v renter keys", len(dl.Subnets), len(dl.RenterKeys))
+	return h.saveSync()
+}
+
+// containsString returns true if the slice contains the string.
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKey returns true if the slice contains the public key.
+func containsKey(keys []types.SiaPublicKey, key types.SiaPublicKey) bool {
+	for _, k := range keys {
+		if k.String() == key.String() {
+			return true
+		}
+	}
+	return false
+}
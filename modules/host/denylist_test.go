@@ -0,0 +1,127 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestParseSubnet probes the parseSubnet function.
+func TestParseSubnet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.7/32"},
+		{"203.0.113.0/24", "203.0.113.0/24"},
+		{"203.0.113.7/24", "203.0.113.0/24"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"2001:db8::/32", "2001:db8::/32"},
+	}
+	for _, test := range tests {
+		subnet, err := parseSubnet(test.in)
+		if err != nil {
+			t.Errorf("parseSubnet(%q) failed: %v", test.in, err)
+			continue
+		}
+		if subnet.String() != test.want {
+			t.Errorf("parseSubnet(%q): expected %v, got %v", test.in, test.want, subnet)
+		}
+	}
+	for _, bad := range []string{"", "foo", "203.0.113.0/33", "203.0.113"} {
+		if _, err := parseSubnet(bad); err != errBadSubnet {
+			t.Errorf("parseSubnet(%q): expected errBadSubnet, got %v", bad, err)
+		}
+	}
+}
+
+// TestDenylist checks that the host refuses connections and renters on its
+// denylist, and that the denylist persists across restarts.
+func TestDenylist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	_, pk := crypto.GenerateKeyPair()
+	renterKey := types.Ed25519PublicKey(pk)
+	err = ht.host.AddToDenylist(modules.HostDenylist{Subnets: []string{"bogus"}})
+	if err != errBadSubnet {
+		t.Fatal("expected errBadSubnet, got", err)
+	}
+	err = ht.host.AddToDenylist(modules.HostDenylist{
+		Subnets:    []string{"127.0.0.1", "203.0.113.0/24"},
+		RenterKeys: []types.SiaPublicKey{renterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.managedRenterDenied(pk) {
+		t.Fatal("renter key should be denied")
+	}
+	if !ht.host.managedIPDenied(&net.TCPAddr{IP: net.ParseIP("203.0.113.9"), Port: 9981}) {
+		t.Fatal("address in denied subnet should be denied")
+	}
+	if ht.host.managedIPDenied(&net.TCPAddr{IP: net.ParseIP("203.0.114.9"), Port: 9981}) {
+		t.Fatal("address outside of denied subnets should not be denied")
+	}
+
+	// Connections from localhost should be closed without being served.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if ht.host.NetworkMetrics().DeniedConnections != 0 {
+			return nil
+		}
+		conn, err := net.Dial("tcp", string(ht.host.ExternalSettings().NetAddress))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		conn.Read(make([]byte, 1))
+		return errors.New("connection was not denied")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the host; the denylist should be unchanged.
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl := ht.host.Denylist()
+	if len(dl.Subnets) != 2 || dl.Subnets[0] != "127.0.0.1/32" || len(dl.RenterKeys) != 1 {
+		t.Fatal("denylist was not persisted:", dl)
+	}
+
+	// Remove the entries again.
+	err = ht.host.RemoveFromDenylist(modules.HostDenylist{
+		Subnets:    []string{"127.0.0.1/32"},
+		RenterKeys: []types.SiaPublicKey{renterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl = ht.host.Denylist()
+	if len(dl.Subnets) != 1 || dl.Subnets[0] != "203.0.113.0/24" || len(dl.RenterKeys) != 0 {
+		t.Fatal("entries were not removed from the denylist:", dl)
+	}
+	if ht.host.managedRenterDenied(pk) {
+		t.Fatal("removed renter key is still denied")
+	}
+}
@@ -326,3 +326,26 @@ func TestManagedLogError(t *testing.T) {
 		t.Error("probabilisitic logging is not clamping correctly:", baseLines, logLines, logsNeeded, remaining)
 	}
 }
+
+// TestNegotiationErrorCode checks that errors sent to renters are given the
+// right error codes, even after being extended.
+func TestNegotiationErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code modules.NegotiationErrorCode
+	}{
+		{extendErr("unable to verify updated contract: ", errLowHostValidOutput), modules.NegotiationErrorPrice},
+		{extendErr("contract verification failed: ", errMaxCollateralReached), modules.NegotiationErrorCollateral},
+		{errInsufficientStorage, modules.NegotiationErrorStorage},
+		{errDeniedRenter, modules.NegotiationErrorDenied},
+		{extendErr("bad revision: ", errBadRevisionNumber), modules.NegotiationErrorRevision},
+		{errSmallWindow, modules.NegotiationErrorBadContract},
+		{ErrorInternal("disk failure"), modules.NegotiationErrorInternal},
+		{errors.New("unknown"), modules.NegotiationErrorUnknown},
+	}
+	for _, test := range tests {
+		if code := negotiationErrorCode(test.err); code != test.code {
+			t.Errorf("%v was given code %v, expected %v", test.err, code, test.code)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package host
+
+// feebump.go raises the fees of the revision and storage proof transactions
+// that the host submits for its storage obligations. While a transaction is
+// unconfirmed, the host keeps resubmitting it, and once the deadline of the
+// transaction is within feeBumpWindow blocks, the host rebuilds the
+// transaction with a higher fee, up to the host's fee cap. Storage proof
+// transactions cannot have outputs, so the fees cannot be raised by spending
+// an output of the original transaction. The rebuilt transaction conflicts
+// with the original, which means that transaction pools still holding the
+// original reject it until the original expires. The host therefore stops
+// resubmitting a transaction txnPoolMaxAge blocks before the fee bump window
+// opens, so that the transaction has left the pool by the time the rebuilt
+// transaction is submitted.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errFeeCapReached is returned if the fee of a transaction cannot be
+	// raised any further without exceeding the host's fee cap.
+	errFeeCapReached = errors.New("the fee of the transaction has reached the fee cap")
+)
+
+// submittedTransaction is a transaction set that the host has submitted to
+// the transaction pool, along with the fees that the set pays.
+type submittedTransaction struct {
+	TransactionSet []types.Transaction
+	Fees           types.Currency
+	Bumps          uint64
+}
+
+// feeBumpCap returns the most that the host will pay in fees for a
+// transaction of the storage obligation. The host never pays more than the
+// obligation is worth.
+func feeBumpCap(settings modules.HostInternalSettings, so storageObligation) types.Currency {
+	if settings.FeeBumpCap.Cmp(so.value()) > 0 {
+		return so.value()
+	}
+	return settings.FeeBumpCap
+}
+
+// bumpedFee returns the fee that a rebuilt transaction should pay to replace a
+// transaction set paying the provided fees. The fees are doubled, or raised to
+// minFees if that is higher, but never beyond the cap.
+func bumpedFee(fees, minFees, cap types.Currency) (types.Currency, error) {
+	target := fees.Mul64(2)
+	if target.Cmp(minFees) < 0 {
+		target = minFees
+	}
+	if target.Cmp(cap) > 0 {
+		target = cap
+	}
+	if target.Cmp(fees) <= 0 {
+		return types.ZeroCurrency, errFeeCapReached
+	}
+	return target, nil
+}
+
+// managedSubmitTransaction signs the transaction in the builder and submits
+// it to the transaction pool. The builder is dropped if the transaction is not
+// accepted, releasing the outputs that were used to fund it.
+func (h *Host) managedSubmitTransaction(builder modules.TransactionBuilder, fee types.Currency) (submittedTransaction, error) {
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		builder.Drop()
+		return submittedTransaction{}, err
+	}
+	err = h.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		builder.Drop()
+		return submittedTransaction{}, err
+	}
+	return submittedTransaction{
+		TransactionSet: txnSet,
+		Fees:           fee,
+	}, nil
+}
+
+// managedSubmitRevision adds a miner fee to the revision transaction of the
+// storage obligation and submits it to the transaction pool.
+func (h *Host) managedSubmitRevision(so storageObligation, fee types.Currency) (submittedTransaction, error) {
+	revisionTxnIndex := len(so.RevisionTransactionSet) - 1
+	revisionParents := so.RevisionTransactionSet[:revisionTxnIndex]
+	revisionTxn := so.RevisionTransactionSet[revisionTxnIndex]
+	builder := h.wallet.RegisterTransaction(revisionTxn, revisionParents)
+	err := builder.FundSiacoins(fee)
+	if err != nil {
+		builder.Drop()
+		return submittedTransaction{}, err
+	}
+	builder.AddMinerFee(fee)
+	return h.managedSubmitTransaction(builder, fee)
+}
+
+// managedSubmitStorageProof builds a transaction holding the storage proof and
+// a miner fee, and submits it to the transaction pool. The transaction has no
+// outputs, as required for transactions with storage proofs.
+func (h *Host) managedSubmitStorageProof(sp types.StorageProof, fee types.Currency) (submittedTransaction, error) {
+	builder := h.wallet.StartTransaction()
+	err := builder.FundSiacoins(fee)
+	if err != nil {
+		builder.Drop()
+		return submittedTransaction{}, err
+	}
+	builder.AddMinerFee(fee)
+	builder.AddStorageProof(sp)
+	return h.managedSubmitTransaction(builder, fee)
+}
+
+// submittedStorageProof returns the storage proof of a submitted storage proof
+// transaction set.
+func submittedStorageProof(st submittedTransaction) types.StorageProof {
+	for _, txn := range st.TransactionSet {
+		if len(txn.StorageProofs) > 0 {
+			return txn.StorageProofs[0]
+		}
+	}
+	build.Critical("submitted storage proof transaction set has no storage proof")
+	return types.StorageProof{}
+}
+
+// managedBumpFee rebuilds a submitted transaction set with a higher fee using
+// the provided rebuild function, and submits the rebuilt set to the
+// transaction pool.
+func (h *Host) managedBumpFee(st submittedTransaction, cap types.Currency, rebuild func(fee types.Currency) (submittedTransaction, error)) (submittedTransaction, error) {
+	// The fees of the rebuilt set should at least meet the current fee
+	// recommendation.
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	setSize := uint64(len(encoding.Marshal(st.TransactionSet)))
+	fee, err := bumpedFee(st.Fees, feeRecommendation.Mul64(setSize), cap)
+	if err != nil {
+		return st, err
+	}
+	bumped, err := rebuild(fee)
+	if err != nil {
+		return st, err
+	}
+	bumped.Bumps = st.Bumps + 1
+	return bumped, nil
+}
+
+// managedWatchSubmission is called for a submitted transaction set that has
+// not yet been confirmed. If the deadline of the set is close, the set is
+// rebuilt with a higher fee. Otherwise the set is resubmitted in case the
+// transaction pool dropped it, unless the fee bump window opens within
+// txnPoolMaxAge blocks, in which case the set is left to expire from the pool
+// so that it does not block the rebuilt set. The updated submission and the
+// extra fees are returned. An empty submission is returned if the set can no
+// longer be confirmed.
+func (h *Host) managedWatchSubmission(so storageObligation, st submittedTransaction, kind string, deadline types.BlockHeight, rebuild func(fee types.Currency) (submittedTransaction, error)) (submittedTransaction, types.Currency) {
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	settings := h.settings
+	h.mu.RUnlock()
+
+	feeBumps := !settings.FeeBumpCap.IsZero()
+	if feeBumps && blockHeight+feeBumpWindow >= deadline {
+		bumped, err := h.managedBumpFee(st, feeBumpCap(settings, so), rebuild)
+		if err == nil {
+			extraFees := bumped.Fees.Sub(st.Fees)
+			h.log.Printf("Raised the fees of the %v transaction for obligation %v by %v to %v, %v blocks before the deadline\n", kind, so.id(), extraFees, bumped.Fees, deadline-blockHeight)
+			return bumped, extraFees
+		}
+		if _, conflict := err.(modules.ConsensusConflict); conflict {
+			h.log.Printf("WARN: the %v transaction for obligation %v conflicts with consensus: %v\n", kind, so.id(), err)
+			return submittedTransaction{}, types.ZeroCurrency
+		}
+		h.log.Printf("WARN: unable to raise the fees of the %v transaction for obligation %v: %v\n", kind, so.id(), err)
+	}
+	if feeBumps && blockHeight+feeBumpWindow+txnPoolMaxAge >= deadline {
+		return st, types.ZeroCurrency
+	}
+	err := h.tpool.AcceptTransactionSet(st.TransactionSet)
+	if _, conflict := err.(modules.ConsensusConflict); conflict {
+		// The set can no longer be confirmed, for example because a reorg
+		// changed the storage proof segment. Forget the set so that the
+		// transaction is rebuilt at the next check.
+		h.log.Printf("WARN: the %v transaction for obligation %v conflicts with consensus: %v\n", kind, so.id(), err)
+		return submittedTransaction{}, types.ZeroCurrency
+	} else if err != nil && err != modules.ErrDuplicateTransactionSet {
+		h.log.Debugf("Unable to resubmit the %v transaction for obligation %v: %v\n", kind, so.id(), err)
+	}
+	return st, types.ZeroCurrency
+}
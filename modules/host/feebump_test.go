@@ -0,0 +1,233 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestBumpedFee probes the bumpedFee function.
+func TestBumpedFee(t *testing.T) {
+	tests := []struct {
+		fees, minFees, cap types.Currency
+		fee                types.Currency
+		err                error
+	}{
+		// The fees are doubled.
+		{types.NewCurrency64(10), types.NewCurrency64(0), types.NewCurrency64(100), types.NewCurrency64(20), nil},
+		// The fees are raised to the minimum if it is more than double.
+		{types.NewCurrency64(10), types.NewCurrency64(50), types.NewCurrency64(100), types.NewCurrency64(50), nil},
+		// The fees never exceed the cap.
+		{types.NewCurrency64(10), types.NewCurrency64(500), types.NewCurrency64(15), types.NewCurrency64(15), nil},
+		// Nothing can be added once the cap is reached.
+		{types.NewCurrency64(15), types.NewCurrency64(0), types.NewCurrency64(15), types.ZeroCurrency, errFeeCapReached},
+		{types.NewCurrency64(10), types.NewCurrency64(0), types.ZeroCurrency, types.ZeroCurrency, errFeeCapReached},
+	}
+	for _, test := range tests {
+		fee, err := bumpedFee(test.fees, test.minFees, test.cap)
+		if err != test.err {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+		if fee.Cmp(test.fee) != 0 {
+			t.Errorf("expected fee %v, got %v", test.fee, fee)
+		}
+	}
+}
+
+// TestWatchSubmission checks that managedWatchSubmission resubmits a
+// transaction until the fee bump window is within txnPoolMaxAge blocks, then
+// leaves it to expire from the transaction pool, and rebuilds it once the fee
+// bump window opens.
+func TestWatchSubmission(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// newSubmission returns a signed transaction set that has not been
+	// submitted to the transaction pool.
+	newSubmission := func() submittedTransaction {
+		fee := types.SiacoinPrecision
+		builder := ht.wallet.StartTransaction()
+		err := builder.FundSiacoins(fee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		builder.AddMinerFee(fee)
+		txnSet, err := builder.Sign(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return submittedTransaction{
+			TransactionSet: txnSet,
+			Fees:           fee,
+		}
+	}
+	inPool := func(st submittedTransaction) bool {
+		_, _, exists := ht.tpool.Transaction(st.TransactionSet[len(st.TransactionSet)-1].ID())
+		return exists
+	}
+	var rebuilds int
+	rebuild := func(fee types.Currency) (submittedTransaction, error) {
+		rebuilds++
+		return submittedTransaction{Fees: fee}, nil
+	}
+	so := storageObligation{
+		ContractCost:         types.SiacoinPrecision.Mul64(100),
+		OriginTransactionSet: []types.Transaction{{FileContracts: []types.FileContract{{}}}},
+	}
+	ht.host.mu.RLock()
+	height := ht.host.blockHeight
+	ht.host.mu.RUnlock()
+
+	// Far from the fee bump window, the transaction is resubmitted.
+	st := newSubmission()
+	watched, extraFees := ht.host.managedWatchSubmission(so, st, "test", height+feeBumpWindow+txnPoolMaxAge+1, rebuild)
+	if !inPool(st) || rebuilds != 0 || !extraFees.IsZero() || watched.Fees.Cmp(st.Fees) != 0 {
+		t.Fatal("transaction should have been resubmitted without raising its fees")
+	}
+
+	// Close to the fee bump window, the transaction is not resubmitted, so
+	// that it expires before it is rebuilt.
+	st = newSubmission()
+	watched, extraFees = ht.host.managedWatchSubmission(so, st, "test", height+feeBumpWindow+txnPoolMaxAge, rebuild)
+	if inPool(st) || rebuilds != 0 || !extraFees.IsZero() || watched.Fees.Cmp(st.Fees) != 0 {
+		t.Fatal("transaction should have been left to expire")
+	}
+
+	// Inside the fee bump window, the transaction is rebuilt.
+	watched, extraFees = ht.host.managedWatchSubmission(so, st, "test", height+feeBumpWindow, rebuild)
+	if inPool(st) || rebuilds != 1 || extraFees.IsZero() || watched.Fees.Cmp(st.Fees) <= 0 {
+		t.Fatal("transaction should have been rebuilt with a higher fee")
+	}
+}
+
+// TestStorageProofSubmission checks that the storage proof transaction built
+// by threadedHandleActionItem is accepted by the transaction pool and gets
+// confirmed.
+func TestStorageProofSubmission(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation holding a single sector, and confirm its
+	// revision.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	sectorCost := types.SiacoinPrecision.Mul64(550)
+	so.PotentialStorageRevenue = so.PotentialStorageRevenue.Add(sectorCost)
+	validPayouts, missedPayouts := so.payouts()
+	validPayouts[0].Value = validPayouts[0].Value.Sub(sectorCost)
+	validPayouts[1].Value = validPayouts[1].Value.Add(sectorCost)
+	missedPayouts[0].Value = missedPayouts[0].Value.Sub(sectorCost)
+	missedPayouts[1].Value = missedPayouts[1].Value.Add(sectorCost)
+	revisionSet := []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:          so.id(),
+			UnlockConditions:  types.UnlockConditions{},
+			NewRevisionNumber: 1,
+
+			NewFileSize:           uint64(len(sectorData)),
+			NewFileMerkleRoot:     sectorRoot,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{sectorRoot}, [][]byte{sectorData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+	err = ht.tpool.AcceptTransactionSet(revisionSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clear the action items, so that the storage proof is only submitted by
+	// the call to threadedHandleActionItem below.
+	err = ht.host.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(bucketActionItems)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucket(bucketActionItems)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ht.host.blockHeight < so.expiration()+resubmissionTimeout {
+		_, err := ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Submit the storage proof, and check that the transaction pool holds the
+	// proof transaction, which must not have any outputs.
+	ht.host.threadedHandleActionItem(so.id())
+	err = ht.host.db.View(func(tx *bolt.Tx) error {
+		so, err = getStorageObligation(tx, so.id())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(so.ProofSubmission.TransactionSet) == 0 {
+		t.Fatal("storage proof was not submitted")
+	}
+	proofTxn := so.ProofSubmission.TransactionSet[len(so.ProofSubmission.TransactionSet)-1]
+	if len(proofTxn.StorageProofs) != 1 || proofTxn.StorageProofs[0].ParentID != so.id() {
+		t.Fatal("last transaction of the submitted set does not hold the storage proof")
+	}
+	if len(proofTxn.SiacoinOutputs) != 0 || len(proofTxn.MinerFees) == 0 {
+		t.Fatal("storage proof transaction should have a miner fee and no outputs")
+	}
+	if _, _, exists := ht.tpool.Transaction(proofTxn.ID()); !exists {
+		t.Fatal("storage proof transaction is not in the transaction pool")
+	}
+
+	// Mine a block to confirm the storage proof.
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.db.View(func(tx *bolt.Tx) error {
+		so, err = getStorageObligation(tx, so.id())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !so.ProofConfirmed {
+		t.Fatal("storage proof was not confirmed")
+	}
+}
@@ -121,9 +121,24 @@ type Host struct {
 	atomicFormContractCalls uint64
 	atomicRenewCalls        uint64
 	atomicReviseCalls       uint64
+	atomicSectorRootsCalls  uint64
 	atomicSettingsCalls     uint64
 	atomicUnrecognizedCalls uint64
 
+	// Connection limit metrics. These values are not persistent.
+	atomicRejectedConnections   uint64
+	atomicRejectedIPConnections uint64
+
+	// Denylist metrics. These values are not persistent.
+	atomicDeniedConnections uint64
+	atomicDeniedRenters     uint64
+
+	// Secure session metrics. These values are not persistent.
+	atomicSecureSessions uint64
+
+	// Contract acceptance policy metrics. These values are not persistent.
+	atomicPolicyRejections uint64
+
 	// Error management. There are a few different types of errors returned by
 	// the host. These errors intentionally not persistent, so that the logging
 	// limits of each error type will be reset each time the host is reset.
@@ -155,6 +170,9 @@ type Host struct {
 	// otherwise are not critical to always be correct.
 	autoAddress          modules.NetAddress // Determined using automatic tooling in network.go
 	financialMetrics     modules.HostFinancialMetrics
+	lastPriceUpdate      types.BlockHeight
+	maintenance          bool
+	pricingSettings      modules.HostPricingSettings
 	settings             modules.HostInternalSettings
 	revisionNumber       uint64
 	workingStatus        modules.HostWorkingStatus
@@ -165,6 +183,33 @@ type Host struct {
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*siasync.TryMutex
 
+	// Bandwidth and connection limits. The rate limiters are shared by every
+	// connection, and are configured using the host's internal settings.
+	downloadLimiter rateLimiter
+	uploadLimiter   rateLimiter
+	openConns       uint64
+	openConnsPerIP  map[string]uint64
+
+	// The denylist of IP subnets and renter keys that the host refuses to do
+	// business with. deniedSubnets holds the parsed form of the subnets in
+	// the denylist.
+	denylist      modules.HostDenylist
+	deniedSubnets []*net.IPNet
+
+	// The storage folders that are being evacuated in maintenance mode,
+	// keyed by path.
+	evacuations map[string]*modules.HostFolderEvacuation
+
+	// The result of the most recent storage proof readiness self-check.
+	// proofCheckMu ensures that only one check runs at a time.
+	lastProofCheck types.BlockHeight
+	proofCheckMu   siasync.TryMutex
+	proofReadiness modules.HostProofReadiness
+
+	// hostDB is an optional source of network prices for the automatic
+	// pricing engine.
+	hostDB HostDB
+
 	// Utilities.
 	db         *persist.BoltDatabase
 	listener   net.Listener
@@ -222,7 +267,9 @@ func newHost(dependencies dependencies, cs modules.ConsensusSet, tpool modules.T
 		wallet:       wallet,
 		dependencies: dependencies,
 
+		evacuations:              make(map[string]*modules.HostFolderEvacuation),
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
+		openConnsPerIP:           make(map[string]uint64),
 
 		persistDir: persistDir,
 	}
@@ -276,6 +323,8 @@ func newHost(dependencies dependencies, cs modules.ConsensusSet, tpool modules.T
 	if err != nil {
 		return nil, err
 	}
+	h.updateRateLimits()
+	h.StorageManager.SetSectorCacheSize(h.settings.SectorCacheSize)
 	h.tg.AfterStop(func() {
 		err = h.saveSync()
 		if err != nil {
@@ -380,6 +429,11 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	err = validatePolicySettings(settings)
+	if err != nil {
+		return errors.New("internal settings not updated, invalid contract policy: " + err.Error())
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -389,6 +443,8 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 
 	h.settings = settings
 	h.revisionNumber++
+	h.updateRateLimits()
+	h.StorageManager.SetSectorCacheSize(settings.SectorCacheSize)
 
 	err = h.saveSync()
 	if err != nil {
@@ -0,0 +1,119 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errUnknownSector is returned if a sector lookup is performed for a
+	// sector that is neither stored by the host nor referenced by any of its
+	// storage obligations.
+	errUnknownSector = errors.New("sector is not stored by the host")
+)
+
+// managedSectorObligations returns the ids of the storage obligations that
+// reference the sector with the provided root. Obligations that do not
+// contain the hex encoding of the root are skipped without being decoded.
+func (h *Host) managedSectorObligations(root crypto.Hash) []types.FileContractID {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	encodedRoot := []byte(root.String())
+	ids := []types.FileContractID{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			if !bytes.Contains(soBytes, encodedRoot) {
+				return nil
+			}
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			for _, sr := range so.SectorRoots {
+				if sr == root {
+					ids = append(ids, so.id())
+					break
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide sector roots:", err))
+	}
+	return ids
+}
+
+// managedObligationSectorRoots returns the sector roots of every storage
+// obligation. Only the sector roots of each obligation are decoded.
+func (h *Host) managedObligationSectorRoots() []crypto.Hash {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var roots []crypto.Hash
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so struct {
+				SectorRoots []crypto.Hash
+			}
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			roots = append(roots, so.SectorRoots...)
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide sector roots:", err))
+	}
+	return roots
+}
+
+// LookupSector returns the location of a sector in the storage manager, along
+// with the ids of the storage obligations that reference it.
+func (h *Host) LookupSector(root crypto.Hash) (modules.HostSectorLookup, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostSectorLookup{}, err
+	}
+	defer h.tg.Done()
+
+	lookup := modules.HostSectorLookup{
+		Obligations: h.managedSectorObligations(root),
+	}
+	lookup.Sector, err = h.StorageManager.SectorLocation(root)
+	lookup.Stored = err == nil
+	if !lookup.Stored && len(lookup.Obligations) == 0 {
+		return modules.HostSectorLookup{}, errUnknownSector
+	}
+	lookup.Sector.Root = root
+	return lookup, nil
+}
+
+// StorageFolderSectors returns a page of the sectors stored in a storage
+// folder. The storage manager only knows the salted ids of the sectors, so the
+// host fills in the roots of the sectors that belong to its storage
+// obligations.
+func (h *Host) StorageFolderSectors(index uint16, offset, limit uint64) ([]modules.StoredSector, uint64, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer h.tg.Done()
+
+	sectors, total, err := h.StorageManager.StorageFolderSectors(index, offset, limit)
+	if err != nil || len(sectors) == 0 {
+		return sectors, total, err
+	}
+	h.StorageManager.FillSectorRoots(sectors, h.managedObligationSectorRoots())
+	return sectors, total, nil
+}
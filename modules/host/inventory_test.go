@@ -0,0 +1,89 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+)
+
+// TestSectorInventory checks that the host fills in the roots of the sectors
+// that belong to its storage obligations, and that a sector can be traced back
+// to the obligations that reference it.
+func TestSectorInventory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation holding a single sector, and a second sector
+	// that does not belong to any obligation.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{sectorRoot}, [][]byte{sectorData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+	looseRoot, looseData := randSector()
+	err = ht.host.AddSector(looseRoot, looseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookup, err := ht.host.LookupSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lookup.Stored || lookup.Sector.Root != sectorRoot || lookup.Sector.Count != 1 {
+		t.Fatalf("unexpected sector lookup: %+v", lookup)
+	}
+	if len(lookup.Obligations) != 1 || lookup.Obligations[0] != so.id() {
+		t.Fatal("sector lookup did not find the obligation:", lookup.Obligations)
+	}
+	lookup, err = ht.host.LookupSector(looseRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lookup.Stored || len(lookup.Obligations) != 0 {
+		t.Fatalf("unexpected sector lookup: %+v", lookup)
+	}
+	_, err = ht.host.LookupSector(crypto.Hash{})
+	if err != errUnknownSector {
+		t.Fatal("expected errUnknownSector, got", err)
+	}
+
+	// Only the sector that belongs to the obligation should have a root.
+	var roots, unknown int
+	for _, sf := range ht.host.StorageFolders() {
+		sectors, _, err := ht.host.StorageFolderSectors(sf.Index, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range sectors {
+			if s.Root == sectorRoot {
+				roots++
+			} else if s.Root == (crypto.Hash{}) {
+				unknown++
+			} else {
+				t.Fatal("sector has an unexpected root:", s.Root)
+			}
+		}
+	}
+	if roots != 1 || unknown != 1 {
+		t.Fatalf("expected 1 sector with a root and 1 without, got %v and %v", roots, unknown)
+	}
+}
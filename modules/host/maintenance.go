@@ -0,0 +1,144 @@
+package host
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errEvacuationInProgress is returned if a storage folder is evacuated
+	// while a previous evacuation of the same folder is still running.
+	errEvacuationInProgress = errors.New("storage folder is already being evacuated")
+
+	// errNotInMaintenance is returned if a storage folder is evacuated while
+	// the host is not in maintenance mode.
+	errNotInMaintenance = errors.New("storage folders can only be evacuated in maintenance mode")
+
+	// errUnknownStorageFolder is returned if an evacuation is requested for a
+	// storage folder that the host does not have.
+	errUnknownStorageFolder = errors.New("no storage folder with the provided path")
+)
+
+// threadedEvacuateStorageFolder moves the data off of a storage folder by
+// removing it from the storage manager, which relocates the folder's sectors
+// to the remaining storage folders. If the host has already been closed, the
+// evacuation is reported as failed instead.
+func (h *Host) threadedEvacuateStorageFolder(index uint16, evacuation *modules.HostFolderEvacuation) {
+	err := h.tg.Add()
+	if err != nil {
+		h.mu.Lock()
+		evacuation.Finished = true
+		evacuation.Error = err.Error()
+		h.mu.Unlock()
+		return
+	}
+	defer h.tg.Done()
+
+	h.log.Printf("Maintenance: evacuating storage folder %v", evacuation.Path)
+	err = h.RemoveStorageFolder(index, false)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	evacuation.Finished = true
+	if err != nil {
+		evacuation.Error = err.Error()
+		h.log.Printf("Maintenance: could not evacuate storage folder %v: %v", evacuation.Path, err)
+		return
+	}
+	h.log.Printf("Maintenance: finished evacuating storage folder %v", evacuation.Path)
+}
+
+// EvacuateStorageFolder moves the data off of the storage folder at the
+// provided path and then removes the folder from the host. The host must be in
+// maintenance mode, so that new contracts do not fill the other folders while
+// the data is being moved.
+func (h *Host) EvacuateStorageFolder(path string) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	index, found := uint16(0), false
+	for _, sf := range h.StorageFolders() {
+		if sf.Path == path {
+			index, found = sf.Index, true
+			break
+		}
+	}
+	if !found {
+		return errUnknownStorageFolder
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.maintenance {
+		return errNotInMaintenance
+	}
+	if e, exists := h.evacuations[path]; exists && !e.Finished {
+		return errEvacuationInProgress
+	}
+	evacuation := &modules.HostFolderEvacuation{Path: path}
+	h.evacuations[path] = evacuation
+	go h.threadedEvacuateStorageFolder(index, evacuation)
+	return nil
+}
+
+// MaintenanceStatus returns whether the host is in maintenance mode, along with
+// the number of unresolved obligations, the height at which the last of them
+// will have been resolved, and the collateral that they still hold.
+func (h *Host) MaintenanceStatus() modules.HostMaintenanceStatus {
+	var ms modules.HostMaintenanceStatus
+	for _, so := range h.StorageObligations() {
+		if so.ObligationStatus != uint64(obligationUnresolved) {
+			continue
+		}
+		ms.UnresolvedObligations++
+		if so.ProofDeadline > ms.DrainHeight {
+			ms.DrainHeight = so.ProofDeadline
+		}
+		ms.LockedCollateral = ms.LockedCollateral.Add(so.LockedCollateral)
+		ms.RiskedCollateral = ms.RiskedCollateral.Add(so.RiskedCollateral)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ms.Enabled = h.maintenance
+	ms.Evacuations = []modules.HostFolderEvacuation{}
+	for _, e := range h.evacuations {
+		ms.Evacuations = append(ms.Evacuations, *e)
+	}
+	sort.Slice(ms.Evacuations, func(i, j int) bool {
+		return ms.Evacuations[i].Path < ms.Evacuations[j].Path
+	})
+	return ms
+}
+
+// SetMaintenance enables or disables maintenance mode. A host in maintenance
+// mode advertises that it is not accepting contracts and refuses new contracts
+// and renewals, but continues to serve downloads, revisions and storage proofs
+// for its existing obligations. Evacuations that are in progress continue if
+// maintenance mode is disabled.
+func (h *Host) SetMaintenance(enabled bool) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.maintenance == enabled {
+		return nil
+	}
+	h.maintenance = enabled
+	h.revisionNumber++
+	if enabled {
+		h.log.Println("Maintenance: enabled, refusing new contracts and renewals")
+	} else {
+		h.log.Println("Maintenance: disabled")
+	}
+	return h.saveSync()
+}
@@ -0,0 +1,147 @@
+package host
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestMaintenanceMode checks that a host in maintenance mode stops advertising
+// that it accepts contracts, reports a drain estimate for its obligations, and
+// can evacuate storage folders without losing data.
+func TestMaintenanceMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a storage obligation with some locked collateral.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	so.LockedCollateral = types.NewCurrency64(1000)
+	so.RiskedCollateral = types.NewCurrency64(100)
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	ms := ht.host.MaintenanceStatus()
+	if ms.Enabled {
+		t.Fatal("host should not start in maintenance mode")
+	}
+	if ms.UnresolvedObligations != 1 || ms.DrainHeight != so.proofDeadline() {
+		t.Fatalf("unexpected drain estimate: %v obligations, drain height %v", ms.UnresolvedObligations, ms.DrainHeight)
+	}
+	if !ms.LockedCollateral.Equals64(1000) || !ms.RiskedCollateral.Equals64(100) {
+		t.Fatal("unexpected collateral:", ms.LockedCollateral, ms.RiskedCollateral)
+	}
+
+	// Evacuating a folder requires maintenance mode.
+	sfs := ht.host.StorageFolders()
+	err = ht.host.EvacuateStorageFolder(sfs[0].Path)
+	if err != errNotInMaintenance {
+		t.Fatal("expected errNotInMaintenance, got", err)
+	}
+	err = ht.host.SetMaintenance(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host in maintenance mode should not advertise that it accepts contracts")
+	}
+	if !ht.host.InternalSettings().AcceptingContracts {
+		t.Fatal("maintenance mode should not change the internal settings")
+	}
+
+	// Store a sector and evacuate the folder that holds it.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	err = ht.host.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evacuated string
+	for _, sf := range ht.host.StorageFolders() {
+		if sf.Capacity != sf.CapacityRemaining {
+			evacuated = sf.Path
+		}
+	}
+	err = ht.host.EvacuateStorageFolder("/nonexistent")
+	if err != errUnknownStorageFolder {
+		t.Fatal("expected errUnknownStorageFolder, got", err)
+	}
+	err = ht.host.EvacuateStorageFolder(evacuated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		evacuations := ht.host.MaintenanceStatus().Evacuations
+		if len(evacuations) != 1 || !evacuations[0].Finished {
+			return errors.New("evacuation did not finish")
+		}
+		if evacuations[0].Error != "" {
+			return errors.New(evacuations[0].Error)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = ht.host.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path == evacuated {
+		t.Fatal("evacuated storage folder was not removed")
+	}
+	sectorData, err := ht.host.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sectorData, data) {
+		t.Fatal("sector data changed during evacuation")
+	}
+
+	// Disabling maintenance mode restores the advertised settings.
+	err = ht.host.SetMaintenance(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should accept contracts again after maintenance")
+	}
+}
+
+// TestEvacuateStorageFolderStopped checks that an evacuation which starts
+// after the host has been closed is reported as failed.
+func TestEvacuateStorageFolderStopped(t *testing.T) {
+	h := new(Host)
+	err := h.tg.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	evacuation := &modules.HostFolderEvacuation{Path: "foo"}
+	h.threadedEvacuateStorageFolder(0, evacuation)
+	if !evacuation.Finished || evacuation.Error == "" {
+		t.Fatal("evacuation on a closed host was not reported as failed:", evacuation)
+	}
+}
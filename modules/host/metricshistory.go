@@ -0,0 +1,88 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errBadMetricsRange is returned if the metrics history is requested for
+	// a range that ends before it starts.
+	errBadMetricsRange = errors.New("metrics history range ends before it starts")
+)
+
+// metricsSnapshotInterval returns the number of blocks between snapshots of
+// the host's metrics.
+func (h *Host) metricsSnapshotInterval() types.BlockHeight {
+	if h.settings.MetricsSnapshotInterval == 0 {
+		return defaultMetricsSnapshotInterval
+	}
+	return h.settings.MetricsSnapshotInterval
+}
+
+// putMetricsSnapshot records a snapshot of the host's current metrics at the
+// host's current height. A snapshot holds the metrics at the time it was
+// taken, so an existing snapshot at the same height is kept rather than being
+// replaced with the current metrics.
+func (h *Host) putMetricsSnapshot(tx *bolt.Tx, timestamp types.Timestamp) error {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(h.blockHeight))
+	bucket := tx.Bucket(bucketMetricsHistory)
+	if bucket.Get(heightBytes) != nil {
+		return nil
+	}
+	snapshot := modules.HostMetricsSnapshot{
+		Height:           h.blockHeight,
+		Timestamp:        timestamp,
+		FinancialMetrics: h.financialMetrics,
+		NetworkMetrics:   h.networkMetrics(),
+	}
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(heightBytes, snapshotBytes)
+}
+
+// deleteMetricsSnapshot removes the snapshot at the host's current height, if
+// there is one. It is called when the block at that height is reverted.
+func (h *Host) deleteMetricsSnapshot(tx *bolt.Tx) error {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(h.blockHeight))
+	return tx.Bucket(bucketMetricsHistory).Delete(heightBytes)
+}
+
+// MetricsHistory returns the snapshots of the host's metrics that were taken
+// between the two heights, inclusive, sorted by height.
+func (h *Host) MetricsHistory(from, to types.BlockHeight) (snapshots []modules.HostMetricsSnapshot, err error) {
+	if err = h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	if to < from {
+		return nil, errBadMetricsRange
+	}
+
+	fromBytes, toBytes := make([]byte, 8), make([]byte, 8)
+	binary.BigEndian.PutUint64(fromBytes, uint64(from))
+	binary.BigEndian.PutUint64(toBytes, uint64(to))
+	err = h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketMetricsHistory).Cursor()
+		for k, v := c.Seek(fromBytes); k != nil && bytes.Compare(k, toBytes) <= 0; k, v = c.Next() {
+			var snapshot modules.HostMetricsSnapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	return snapshots, err
+}
@@ -0,0 +1,128 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestMetricsHistory checks that the host records snapshots of its metrics at
+// the configured interval, and that they can be queried by height.
+func TestMetricsHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Snapshots are recorded every defaultMetricsSnapshotInterval blocks as
+	// blocks are added.
+	height := ht.host.blockHeight
+	snapshots, err := ht.host.MetricsHistory(0, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(snapshots)) != uint64(height/defaultMetricsSnapshotInterval)+1 {
+		t.Fatalf("expected %v snapshots, got %v", height/defaultMetricsSnapshotInterval+1, len(snapshots))
+	}
+	for i, s := range snapshots {
+		if s.Height != types.BlockHeight(i)*defaultMetricsSnapshotInterval {
+			t.Fatal("snapshot has the wrong height:", s.Height)
+		}
+	}
+
+	// Change the interval and mine enough blocks for two more snapshots.
+	settings := ht.host.InternalSettings()
+	settings.MetricsSnapshotInterval = 5
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, err = ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshots, err = ht.host.MetricsHistory(height+1, height+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatal("expected 2 snapshots, got", len(snapshots))
+	}
+	for _, s := range snapshots {
+		if s.Height%5 != 0 || s.Timestamp == 0 {
+			t.Fatal("unexpected snapshot:", s.Height, s.Timestamp)
+		}
+	}
+
+	// Mine until a snapshot is due, and check that the snapshot is not
+	// replaced when it is recorded again, as happens during a rescan.
+	for ht.host.blockHeight%5 != 0 {
+		_, err = ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	height = ht.host.blockHeight
+	snapshots, err = ht.host.MetricsHistory(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatal("expected a snapshot at height", height)
+	}
+	ht.host.mu.Lock()
+	err = ht.host.db.Update(func(tx *bolt.Tx) error {
+		return ht.host.putMetricsSnapshot(tx, snapshots[0].Timestamp+1)
+	})
+	ht.host.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rescanned, err := ht.host.MetricsHistory(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rescanned) != 1 || rescanned[0].Timestamp != snapshots[0].Timestamp {
+		t.Fatal("existing snapshot was replaced")
+	}
+
+	// Reverting the block removes its snapshot, and reapplying it while the
+	// host is not synced does not record a new one.
+	block := ht.cs.CurrentBlock()
+	ht.host.ProcessConsensusChange(modules.ConsensusChange{
+		RevertedBlocks: []types.Block{block},
+	})
+	snapshots, err = ht.host.MetricsHistory(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatal("snapshot of a reverted block was kept")
+	}
+	ht.host.ProcessConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{block},
+	})
+	snapshots, err = ht.host.MetricsHistory(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatal("snapshot was recorded while the host was not synced")
+	}
+
+	// A range that ends before it starts is rejected.
+	_, err = ht.host.MetricsHistory(10, 5)
+	if err != errBadMetricsRange {
+		t.Fatal("expected errBadMetricsRange, got", err)
+	}
+}
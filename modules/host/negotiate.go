@@ -1,6 +1,8 @@
 package host
 
 import (
+	"io"
+	"strings"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -89,6 +91,10 @@ var (
 	// length.
 	errIllegalOffsetAndLength = ErrorCommunication("renter is trying to do a modify with an illegal offset and length")
 
+	// errInsufficientStorage is returned if the renter attempts to upload more
+	// sectors than the host has storage remaining for.
+	errInsufficientStorage = ErrorCommunication("host does not have enough storage remaining to accept the sectors")
+
 	// errLargeSector is returned if the renter sends a RevisionAction that has
 	// data which creates a sector that is larger than what the host uses.
 	errLargeSector = ErrorCommunication("renter has sent a sector that exceeds the host's sector size")
@@ -134,6 +140,70 @@ var (
 	errUnknownModification = ErrorCommunication("renter is attempting an action that the host does not understand")
 )
 
+// negotiationErrorCodes maps the errors that the host sends to renters to the
+// codes that tell the renter why the negotiation was rejected.
+var negotiationErrorCodes = []struct {
+	err  error
+	code modules.NegotiationErrorCode
+}{
+	{errHighRenterMissedOutput, modules.NegotiationErrorPrice},
+	{errHighRenterValidOutput, modules.NegotiationErrorPrice},
+	{errLowHostMissedOutput, modules.NegotiationErrorPrice},
+	{errLowHostValidOutput, modules.NegotiationErrorPrice},
+	{errLowTransactionFees, modules.NegotiationErrorPrice},
+	{errLowVoidOutput, modules.NegotiationErrorPrice},
+
+	{errCollateralBudgetExceeded, modules.NegotiationErrorCollateral},
+	{errMaxCollateralReached, modules.NegotiationErrorCollateral},
+
+	{errInsufficientStorage, modules.NegotiationErrorStorage},
+
+	{errDeniedRenter, modules.NegotiationErrorDenied},
+
+	{errPolicyCollateralRatio, modules.NegotiationErrorPolicy},
+	{errPolicyLowPayout, modules.NegotiationErrorPolicy},
+	{errPolicyPeriodCollateral, modules.NegotiationErrorCollateral},
+	{errPolicyRenterContracts, modules.NegotiationErrorPolicy},
+	{errPolicyShortDuration, modules.NegotiationErrorPolicy},
+
+	{errBadRevisionNumber, modules.NegotiationErrorRevision},
+	{errLateRevision, modules.NegotiationErrorRevision},
+}
+
+// negotiationErrorCode returns the code that describes err to the renter.
+// Errors are compared by message, because extendErr does not preserve the
+// identity of the errors that it extends. Errors without a specific code are
+// classified by their type.
+func negotiationErrorCode(err error) modules.NegotiationErrorCode {
+	for _, nec := range negotiationErrorCodes {
+		// Compare against the message without the prefix added by the error
+		// type, as extended errors only carry the prefix at the start.
+		msg := nec.err.Error()
+		if ec, ok := nec.err.(ErrorCommunication); ok {
+			msg = string(ec)
+		} else if ei, ok := nec.err.(ErrorInternal); ok {
+			msg = string(ei)
+		}
+		if strings.Contains(err.Error(), msg) {
+			return nec.code
+		}
+	}
+	switch err.(type) {
+	case ErrorCommunication:
+		return modules.NegotiationErrorBadContract
+	case ErrorInternal:
+		return modules.NegotiationErrorInternal
+	default:
+		return modules.NegotiationErrorUnknown
+	}
+}
+
+// writeNegotiationRejection writes a rejection to w (usually a net.Conn),
+// including the code that describes err, and returns err.
+func writeNegotiationRejection(w io.Writer, err error) error {
+	return modules.WriteNegotiationError(w, negotiationErrorCode(err), err)
+}
+
 // createRevisionSignature creates a signature for a file contract revision
 // that signs on the file contract revision. The renter should have already
 // provided the signature. createRevisionSignature will check to make sure that
@@ -5,6 +5,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -21,6 +22,20 @@ var (
 	errRequestOutOfBounds = ErrorCommunication("download request has invalid sector bounds")
 )
 
+// isPartialRequest returns true if the download action asks for anything
+// other than the full sector.
+func isPartialRequest(request modules.DownloadAction) bool {
+	return request.Offset != 0 || request.Length != modules.SectorSize
+}
+
+// isProvableRequest returns true if the download action asks for a
+// segment-aligned part of a sector, which the host can prove with a Merkle
+// range proof.
+func isProvableRequest(request modules.DownloadAction) bool {
+	return isPartialRequest(request) && request.Length != 0 &&
+		request.Offset%crypto.SegmentSize == 0 && request.Length%crypto.SegmentSize == 0
+}
+
 // managedDownloadIteration is responsible for managing a single iteration of
 // the download loop for RPCDownload.
 func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) error {
@@ -65,14 +80,19 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	// for the renter.
 	existingRevision := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
 	var payload [][]byte
+	var proofs [][]crypto.Hash
+	var partial bool
+	var totalSize uint64
 	err = func() error {
 		// Check that the length of each file is in-bounds, and that the total
 		// size being requested is acceptable.
-		var totalSize uint64
 		for _, request := range requests {
 			if request.Length > modules.SectorSize || request.Offset+request.Length > modules.SectorSize {
 				return extendErr("download iteration request failed: ", errRequestOutOfBounds)
 			}
+			if isProvableRequest(request) {
+				partial = true
+			}
 			totalSize += request.Length
 		}
 		if totalSize > settings.MaxDownloadBatchSize {
@@ -87,18 +107,29 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			return extendErr("payment verification failed: ", err)
 		}
 
-		// Load the sectors and build the data payload.
+		// Load the sectors and build the data payload. Segment-aligned
+		// partial requests are accompanied by a Merkle proof that the
+		// segments belong to the sector. Full sectors can be verified against
+		// the root directly, and get an empty proof, as do unaligned requests
+		// from older renters, which cannot be proven.
 		for _, request := range requests {
 			sectorData, err := h.ReadSector(request.MerkleRoot)
 			if err != nil {
 				return extendErr("failed to load sector: ", ErrorInternal(err.Error()))
 			}
 			payload = append(payload, sectorData[request.Offset:request.Offset+request.Length])
+			var proof []crypto.Hash
+			if isProvableRequest(request) {
+				start := request.Offset / crypto.SegmentSize
+				end := (request.Offset + request.Length) / crypto.SegmentSize
+				proof = crypto.MerkleRangeProof(sectorData, start, end)
+			}
+			proofs = append(proofs, proof)
 		}
 		return nil
 	}()
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve type in extendErr
+		writeNegotiationRejection(conn, err) // Error not reported to preserve type in extendErr
 		return extendErr("download request rejected: ", err)
 	}
 	// Revision is acceptable, write acceptance.
@@ -122,9 +153,12 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 		FileContractRevisions: []types.FileContractRevision{paymentRevision},
 		TransactionSignatures: []types.TransactionSignature{renterSignature, txn.TransactionSignatures[1]},
 	}}
+	so.BytesDownloaded += totalSize
+	so.RevisionCount++
+	so.recordRenterAddress(renterAddress(conn), blockHeight)
 	err = h.modifyStorageObligation(*so, nil, nil, nil)
 	if err != nil {
-		return extendErr("failed to modify storage obligation: ", ErrorInternal(modules.WriteNegotiationRejection(conn, err).Error()))
+		return extendErr("failed to modify storage obligation: ", ErrorInternal(writeNegotiationRejection(conn, err).Error()))
 	}
 
 	// Write acceptance to the renter - the data request can be fulfilled by
@@ -142,6 +176,15 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	if err != nil {
 		return extendErr("failed to write payload: ", ErrorConnection(err.Error()))
 	}
+	// The proofs are only sent if the renter asked for segment-aligned
+	// partial sectors, so that renters which only download full sectors or
+	// unaligned ranges are unaffected.
+	if partial {
+		err = encoding.WriteObject(conn, proofs)
+		if err != nil {
+			return extendErr("failed to write range proofs: ", ErrorConnection(err.Error()))
+		}
+	}
 	return nil
 }
 
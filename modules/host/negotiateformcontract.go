@@ -2,6 +2,7 @@ package host
 
 import (
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
@@ -75,11 +76,16 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	// understand that the connection is going to be closed.
 	h.mu.RLock()
 	settings := h.settings
+	maintenance := h.maintenance
 	h.mu.RUnlock()
 	if !settings.AcceptingContracts {
 		h.log.Debugln("Turning down contract because the host is not accepting contracts.")
 		return nil
 	}
+	if maintenance {
+		h.log.Debugln("Turning down contract because the host is in maintenance mode.")
+		return nil
+	}
 
 	// Extend the deadline to meet the rest of file contract negotiation.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateFileContractTime))
@@ -112,13 +118,20 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
-		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		writeNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("contract verification failed: ", err)
 	}
+	// The contract has been verified to use renterPK in its unlock
+	// conditions, so the key can be checked against the denylist.
+	if h.managedRenterDenied(renterPK) {
+		atomic.AddUint64(&h.atomicDeniedRenters, 1)
+		writeNegotiationRejection(conn, errDeniedRenter) // Error ignored to preserve type in extendErr
+		return extendErr("renter is on the denylist: ", errDeniedRenter)
+	}
 	// The host adds collateral to the transaction.
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddCollateral(settings, txnSet)
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		writeNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("failed to add collateral: ", err)
 	}
 	// The host indicates acceptance, and then sends any new parent
@@ -175,7 +188,7 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
-		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		writeNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("contract finalization failed: ", err)
 	}
 	defer h.managedUnlockStorageObligation(newSOID)
@@ -289,6 +302,19 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 		return errBadUnlockHash
 	}
 
+	// Check that the contract is acceptable under the host's contract
+	// acceptance policy. The renter's payout is all that the host can earn
+	// from the contract, aside from the contract price.
+	err := h.managedCheckContractPolicy(settings, blockHeight, types.Ed25519PublicKey(renterPK), types.FileContractID{}, policyContract{
+		Duration:   fc.WindowStart - blockHeight,
+		Payout:     fc.ValidProofOutputs[0].Value,
+		Revenue:    fc.ValidProofOutputs[0].Value.Add(settings.MinContractPrice),
+		Collateral: expectedCollateral,
+	})
+	if err != nil {
+		return err
+	}
+
 	// Check that the transaction set has enough fees on it to get into the
 	// blockchain.
 	setFee := modules.CalculateFee(txnSet)
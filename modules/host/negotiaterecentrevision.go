@@ -129,7 +129,7 @@ func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, st
 	if err != nil {
 		// Do not disclose the original error to renter not to leak
 		// if the host has the contract with the ID sent by renter.
-		modules.WriteNegotiationRejection(conn, errVerifyChallenge)
+		writeNegotiationRejection(conn, errVerifyChallenge)
 		return types.FileContractID{}, storageObligation{}, extendErr("challenge failed: ", err)
 	}
 	// Defer a call to unlock the storage obligation in the event of an error.
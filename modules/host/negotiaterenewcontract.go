@@ -3,6 +3,7 @@ package host
 import (
 	"errors"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
@@ -92,6 +93,15 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
+	// A host in maintenance mode does not renew contracts. The renter can
+	// tell from the host settings that the connection is going to be closed.
+	h.mu.RLock()
+	maintenance := h.maintenance
+	h.mu.RUnlock()
+	if maintenance {
+		h.log.Debugln("Turning down renewal because the host is in maintenance mode.")
+		return nil
+	}
 
 	// Set the renewal deadline.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateRenewContractTime))
@@ -125,12 +135,19 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	// Verify that the transaction coming over the wire is a proper renewal.
 	err = h.managedVerifyRenewedContract(so, txnSet, renterPK)
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
+		writeNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("verification of renewal failed: ", err)
 	}
+	// The contract has been verified to use renterPK in its unlock
+	// conditions, so the key can be checked against the denylist.
+	if h.managedRenterDenied(renterPK) {
+		atomic.AddUint64(&h.atomicDeniedRenters, 1)
+		writeNegotiationRejection(conn, errDeniedRenter) // Error is ignored to preserve type for extendErr
+		return extendErr("renter is on the denylist: ", errDeniedRenter)
+	}
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddRenewCollateral(so, settings, txnSet)
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
+		writeNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("failed to add collateral: ", err)
 	}
 	// The host indicates acceptance, then sends the new parents, inputs, and
@@ -188,7 +205,7 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	h.mu.RUnlock()
 	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, renterTxnSignatures, renterRevisionSignature, so.SectorRoots, renewCollateral, renewRevenue, renewRisk)
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
+		writeNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("failed to finalize contract: ", err)
 	}
 	defer h.managedUnlockStorageObligation(newSOID)
@@ -302,6 +319,19 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		return errBadUnlockHash
 	}
 
+	// Check that the renewal is acceptable under the host's contract
+	// acceptance policy. The renewal replaces the old contract, so the old
+	// contract does not count towards the renter's contracts.
+	err := h.managedCheckContractPolicy(internalSettings, blockHeight, types.Ed25519PublicKey(renterPK), so.id(), policyContract{
+		Duration:   fc.WindowStart - blockHeight,
+		Payout:     fc.ValidProofOutputs[0].Value,
+		Revenue:    fc.ValidProofOutputs[0].Value.Add(externalSettings.ContractPrice).Add(basePrice),
+		Collateral: expectedCollateral,
+	})
+	if err != nil {
+		return err
+	}
+
 	// Check that the transaction set has enough fees on it to get into the
 	// blockchain.
 	setFee := modules.CalculateFee(txnSet)
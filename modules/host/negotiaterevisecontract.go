@@ -60,6 +60,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	// with the ability to reverse them. Then verify the file contract revision
 	// correctly accounts for the changes.
 	var bandwidthRevenue types.Currency // Upload bandwidth.
+	var bytesUploaded uint64
 	var storageRevenue types.Currency
 	var newCollateral types.Currency
 	var sectorsRemoved []crypto.Hash
@@ -80,6 +81,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 			if uint64(len(modification.Data)) > modules.SectorSize {
 				return errLargeSector
 			}
+			bytesUploaded += uint64(len(modification.Data))
 
 			switch modification.Type {
 			case modules.ActionDelete:
@@ -135,11 +137,19 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				return errUnknownModification
 			}
 		}
+		// Check that the host has room for the new sectors.
+		if len(sectorsGained) > len(sectorsRemoved) {
+			_, remaining := h.capacity()
+			if uint64(len(sectorsGained)-len(sectorsRemoved))*modules.SectorSize > remaining {
+				return errInsufficientStorage
+			}
+		}
+
 		newRevenue := storageRevenue.Add(bandwidthRevenue)
 		return extendErr("unable to verify updated contract: ", verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral))
 	}()
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
+		writeNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
 		return extendErr("rejected proposed modifications: ", err)
 	}
 	// Revision is acceptable, write an acceptance string.
@@ -157,7 +167,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	// Verify that the signature is valid and get the host's signature.
 	txn, err := createRevisionSignature(revision, renterSig, secretKey, blockHeight)
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
+		writeNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
 		return extendErr("could not create revision signature: ", err)
 	}
 
@@ -165,11 +175,14 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	so.RiskedCollateral = so.RiskedCollateral.Add(newCollateral)
 	so.PotentialUploadRevenue = so.PotentialUploadRevenue.Add(bandwidthRevenue)
 	so.RevisionTransactionSet = []types.Transaction{txn}
+	so.BytesUploaded += bytesUploaded
+	so.RevisionCount++
+	so.recordRenterAddress(renterAddress(conn), blockHeight)
 	h.mu.Lock()
 	err = h.modifyStorageObligation(*so, sectorsRemoved, sectorsGained, gainedSectorData)
 	h.mu.Unlock()
 	if err != nil {
-		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
+		writeNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
 		return extendErr("could not modify storage obligation: ", ErrorInternal(err.Error()))
 	}
 
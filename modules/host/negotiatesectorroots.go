@@ -0,0 +1,88 @@
+package host
+
+import (
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errBadSectorRootsRequest is returned when the renter requests sector
+	// roots that the storage obligation does not have, or requests more roots
+	// than fit in a single page.
+	errBadSectorRootsRequest = ErrorCommunication("sector roots request is out of bounds")
+)
+
+// managedSectorRootsIteration handles a single request for a page of sector
+// roots.
+func (h *Host) managedSectorRootsIteration(conn net.Conn, so *storageObligation) error {
+	conn.SetDeadline(time.Now().Add(modules.NegotiateDownloadTime))
+
+	// The renter will either request another page of roots, or indicate that
+	// it is finished.
+	err := modules.ReadNegotiationAcceptance(conn)
+	if err == modules.ErrStopResponse {
+		return err // managedRPCSectorRoots will catch this and exit gracefully
+	} else if err != nil {
+		return extendErr("renter did not request sector roots: ", ErrorCommunication(err.Error()))
+	}
+	var req modules.SectorRootsRequest
+	err = encoding.ReadObject(conn, &req, 16)
+	if err != nil {
+		return extendErr("failed to read sector roots request: ", ErrorConnection(err.Error()))
+	}
+
+	// Check that the requested roots exist.
+	numRoots := uint64(len(so.SectorRoots))
+	if req.NumRoots > modules.NegotiateMaxSectorRootsPage || req.Offset > numRoots || req.NumRoots > numRoots-req.Offset {
+		writeNegotiationRejection(conn, errBadSectorRootsRequest) // Error not reported to preserve type in extendErr
+		return extendErr("sector roots request rejected: ", errBadSectorRootsRequest)
+	}
+
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("failed to write acceptance for sector roots request: ", ErrorConnection(err.Error()))
+	}
+	err = encoding.WriteObject(conn, so.SectorRoots[req.Offset:req.Offset+req.NumRoots])
+	if err != nil {
+		return extendErr("failed to write sector roots: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
+
+// managedRPCSectorRoots is responsible for handling an RPC request from the
+// renter to list the sector roots of a storage obligation. The renter proves
+// ownership of the contract by signing a challenge with the contract's renter
+// key, after which it may request the roots one page at a time.
+func (h *Host) managedRPCSectorRoots(conn net.Conn) error {
+	// Get the start time to limit the length of the whole connection.
+	startTime := time.Now()
+	// Perform the file contract revision exchange, giving the renter the most
+	// recent file contract revision. The renter uses the revision to verify
+	// the sector roots.
+	_, so, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCSectorRoots: ", err)
+	}
+	// The storage obligation is returned with a lock on it. Defer a call to
+	// unlock the storage obligation.
+	defer func() {
+		h.managedUnlockStorageObligation(so.id())
+	}()
+
+	// Perform a loop that will allow the renter to request pages of roots
+	// until the maximum time for a single connection has been reached.
+	for time.Now().Before(startTime.Add(iteratedConnectionTime)) {
+		err := h.managedSectorRootsIteration(conn, &so)
+		if err == modules.ErrStopResponse {
+			// The renter has indicated that it has finished requesting roots,
+			// therefore there is no error. Return nil.
+			return nil
+		} else if err != nil {
+			return extendErr("sector roots iteration failed: ", err)
+		}
+	}
+	return nil
+}
@@ -249,17 +249,27 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 	defer h.tg.Done()
 
+	// Apply the host's bandwidth limits to the connection.
+	conn = &rateLimitedConn{Conn: conn, h: h}
+
 	// Close the conn on host.Close or when the method terminates, whichever comes
 	// first.
 	connCloseChan := make(chan struct{})
 	defer close(connCloseChan)
-	go func() {
+	go func(conn net.Conn) {
 		select {
 		case <-h.tg.StopChan():
 		case <-connCloseChan:
 		}
 		conn.Close()
-	}()
+	}(conn)
+
+	// Refuse connections from IP addresses on the denylist.
+	if h.managedIPDenied(conn.RemoteAddr()) {
+		atomic.AddUint64(&h.atomicDeniedConnections, 1)
+		h.log.Debugf("WARN: refused connection from %v: address is on the denylist", conn.RemoteAddr())
+		return
+	}
 
 	// Set an initial duration that is generous, but finite. RPCs can extend
 	// this if desired.
@@ -277,6 +287,27 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		return
 	}
 
+	// Upgrade the connection to an encrypted session if requested. The
+	// specifier of the actual RPC follows inside of the session.
+	if id == modules.RPCSecureSession {
+		h.mu.RLock()
+		sk := h.secretKey
+		h.mu.RUnlock()
+		sconn, err := modules.NewHostSecureSession(conn, sk)
+		if err != nil {
+			atomic.AddUint64(&h.atomicErroredCalls, 1)
+			h.log.Debugf("WARN: secure session handshake with %v failed: %v", conn.RemoteAddr(), err)
+			return
+		}
+		conn = sconn
+		atomic.AddUint64(&h.atomicSecureSessions, 1)
+		if err := encoding.ReadObject(conn, &id, 16); err != nil {
+			atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
+			h.log.Debugf("WARN: incoming secure session %v was malformed: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+
 	switch id {
 	case modules.RPCDownload:
 		atomic.AddUint64(&h.atomicDownloadCalls, 1)
@@ -290,6 +321,9 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	case modules.RPCReviseContract:
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
+	case modules.RPCSectorRoots:
+		atomic.AddUint64(&h.atomicSectorRootsCalls, 1)
+		err = extendErr("incoming RPCSectorRoots failed: ", h.managedRPCSectorRoots(conn))
 	case modules.RPCSettings:
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
@@ -319,7 +353,20 @@ func (h *Host) threadedListen(closeChan chan struct{}) {
 			return
 		}
 
-		go h.threadedHandleConn(conn)
+		// Refuse the connection if it would exceed the connection limits.
+		ip, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+		if err != nil {
+			ip = conn.RemoteAddr().String()
+		}
+		if !h.managedTrackConn(ip) {
+			h.log.Debugf("WARN: refused connection from %v: connection limit reached", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		go func() {
+			defer h.managedUntrackConn(ip)
+			h.threadedHandleConn(conn)
+		}()
 
 		// Soft-sleep to ratelimit the number of incoming connections.
 		select {
@@ -345,13 +392,30 @@ func (h *Host) NetAddress() modules.NetAddress {
 func (h *Host) NetworkMetrics() modules.HostNetworkMetrics {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	return h.networkMetrics()
+}
+
+// networkMetrics returns the network metrics of the host. The counters are
+// atomic, so the host mutex does not need to be held.
+func (h *Host) networkMetrics() modules.HostNetworkMetrics {
 	return modules.HostNetworkMetrics{
 		DownloadCalls:     atomic.LoadUint64(&h.atomicDownloadCalls),
 		ErrorCalls:        atomic.LoadUint64(&h.atomicErroredCalls),
 		FormContractCalls: atomic.LoadUint64(&h.atomicFormContractCalls),
 		RenewCalls:        atomic.LoadUint64(&h.atomicRenewCalls),
 		ReviseCalls:       atomic.LoadUint64(&h.atomicReviseCalls),
+		SectorRootsCalls:  atomic.LoadUint64(&h.atomicSectorRootsCalls),
 		SettingsCalls:     atomic.LoadUint64(&h.atomicSettingsCalls),
 		UnrecognizedCalls: atomic.LoadUint64(&h.atomicUnrecognizedCalls),
+
+		RejectedConnections:   atomic.LoadUint64(&h.atomicRejectedConnections),
+		RejectedIPConnections: atomic.LoadUint64(&h.atomicRejectedIPConnections),
+
+		DeniedConnections: atomic.LoadUint64(&h.atomicDeniedConnections),
+		DeniedRenters:     atomic.LoadUint64(&h.atomicDeniedRenters),
+
+		SecureSessions: atomic.LoadUint64(&h.atomicSecureSessions),
+
+		PolicyRejections: atomic.LoadUint64(&h.atomicPolicyRejections),
 	}
 }
@@ -1,12 +1,17 @@
 package host
 
 import (
+	"errors"
+	"net"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // blockingPortForward is a dependency set that causes the host port forward
@@ -143,3 +148,169 @@ func TestHostConnectabilityStatus(t *testing.T) {
 		t.Fatal("expected connectability state to flip to HostConnectabilityStatusConnectable")
 	}
 }
+
+// TestRateLimiter checks that the rate limiter spaces out reservations
+// according to its limit.
+func TestRateLimiter(t *testing.T) {
+	var rl rateLimiter
+
+	// Without a limit, waiting should not block.
+	start := time.Now()
+	rl.wait(1e9, nil)
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("unlimited rate limiter blocked")
+	}
+
+	// With a limit of 1000 bytes per second, reserving 1000 bytes three times
+	// should take at least two seconds.
+	rl.setLimit(1000)
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		rl.wait(1000, nil)
+	}
+	if elapsed := time.Since(start); elapsed < 2*time.Second-50*time.Millisecond {
+		t.Fatal("rate limiter did not limit throughput:", elapsed)
+	}
+
+	// Closing the cancel channel should unblock a waiting reservation.
+	cancel := make(chan struct{})
+	close(cancel)
+	rl.wait(1e6, nil)
+	start = time.Now()
+	rl.wait(1000, cancel)
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("cancelled wait blocked")
+	}
+}
+
+// TestConnectionLimits checks that the host refuses connections beyond its
+// overall and per-IP connection limits.
+func TestConnectionLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Limit the host to a single connection per IP.
+	settings := ht.host.InternalSettings()
+	settings.MaxConnectionsPerIP = 1
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first connection is held open; the second should be closed by the
+	// host without being served.
+	conn1, err := net.Dial("tcp", string(ht.host.ExternalSettings().NetAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn1.Close()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if ht.host.NetworkMetrics().RejectedIPConnections != 0 {
+			return nil
+		}
+		conn2, err := net.Dial("tcp", string(ht.host.ExternalSettings().NetAddress))
+		if err != nil {
+			return err
+		}
+		defer conn2.Close()
+		conn2.SetReadDeadline(time.Now().Add(time.Second))
+		conn2.Read(make([]byte, 1))
+		return errors.New("second connection was not rejected")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the per-IP limit with an overall limit of one connection; conn1
+	// is still open, so the next connection should be refused.
+	settings.MaxConnectionsPerIP = 0
+	settings.MaxConnections = 1
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn3, err := net.Dial("tcp", string(ht.host.ExternalSettings().NetAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn3.Close()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if ht.host.NetworkMetrics().RejectedConnections == 0 {
+			return errors.New("connection was not rejected")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSecureSessionSettings checks that a renter can fetch the host's settings
+// through an encrypted session when dialing with the version the host
+// reports, and that renters which treat the host as too old to support
+// encryption still get a plaintext connection.
+func TestSecureSessionSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	var pk crypto.PublicKey
+	copy(pk[:], ht.host.PublicKey().Key)
+	fetchSettings := func(version string) error {
+		conn, err := modules.DialHostSession(&net.Dialer{Timeout: 5 * time.Second}, ht.host.ExternalSettings().NetAddress, ht.host.PublicKey(), version)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		err = encoding.WriteObject(conn, modules.RPCSettings)
+		if err != nil {
+			return err
+		}
+		var settings modules.HostExternalSettings
+		err = crypto.ReadSignedObject(conn, &settings, modules.NegotiateMaxHostExternalSettingsLen, pk)
+		if err != nil {
+			return err
+		}
+		if settings.NetAddress != ht.host.ExternalSettings().NetAddress {
+			return errors.New("host sent the wrong settings")
+		}
+		return nil
+	}
+
+	// Use the version that the host reports in its settings, which is what
+	// renters learn from the host's announcement.
+	err = fetchSettings(ht.host.ExternalSettings().Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.NetworkMetrics().SecureSessions != 1 {
+		t.Fatal("settings were not fetched through a secure session")
+	}
+	err = fetchSettings("1.3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.NetworkMetrics().SecureSessions != 1 {
+		t.Fatal("old host versions should not be sent a secure session handshake")
+	}
+
+	// A renter expecting a different host key should refuse the session.
+	_, otherPK := crypto.GenerateKeyPair()
+	_, err = modules.DialHostSession(&net.Dialer{Timeout: 5 * time.Second}, ht.host.ExternalSettings().NetAddress, types.Ed25519PublicKey(otherPK), modules.SecureSessionHostVersion)
+	if err == nil {
+		t.Fatal("secure session was opened with the wrong host key")
+	}
+}
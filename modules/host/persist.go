@@ -23,7 +23,11 @@ type persistence struct {
 	// Host Identity.
 	Announced        bool                         `json:"announced"`
 	AutoAddress      modules.NetAddress           `json:"autoaddress"`
+	Denylist         modules.HostDenylist         `json:"denylist"`
 	FinancialMetrics modules.HostFinancialMetrics `json:"financialmetrics"`
+	LastPriceUpdate  types.BlockHeight            `json:"lastpriceupdate"`
+	Maintenance      bool                         `json:"maintenance"`
+	PricingSettings  modules.HostPricingSettings  `json:"pricingsettings"`
 	PublicKey        types.SiaPublicKey           `json:"publickey"`
 	RevisionNumber   uint64                       `json:"revisionnumber"`
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
@@ -41,7 +45,11 @@ func (h *Host) persistData() persistence {
 		// Host Identity.
 		Announced:        h.announced,
 		AutoAddress:      h.autoAddress,
+		Denylist:         h.denylist,
 		FinancialMetrics: h.financialMetrics,
+		LastPriceUpdate:  h.lastPriceUpdate,
+		Maintenance:      h.maintenance,
+		PricingSettings:  h.pricingSettings,
 		PublicKey:        h.publicKey,
 		RevisionNumber:   h.revisionNumber,
 		SecretKey:        h.secretKey,
@@ -50,6 +58,17 @@ func (h *Host) persistData() persistence {
 	}
 }
 
+// defaultPricingSettings returns the default configuration of the automatic
+// pricing engine, which leaves pricing to the operator.
+func defaultPricingSettings() modules.HostPricingSettings {
+	return modules.HostPricingSettings{
+		Strategy:          modules.HostPricingManual,
+		UpdateInterval:    defaultPricingUpdateInterval,
+		TargetUtilization: 0.5,
+		NetworkOffset:     1,
+	}
+}
+
 // establishDefaults configures the default settings for the host, overwriting
 // any existing settings.
 func (h *Host) establishDefaults() error {
@@ -58,17 +77,20 @@ func (h *Host) establishDefaults() error {
 		MaxDownloadBatchSize: uint64(defaultMaxDownloadBatchSize),
 		MaxDuration:          defaultMaxDuration,
 		MaxReviseBatchSize:   uint64(defaultMaxReviseBatchSize),
+		SectorCacheSize:      uint64(defaultSectorCacheSize),
 		WindowSize:           defaultWindowSize,
 
 		Collateral:       defaultCollateral,
 		CollateralBudget: defaultCollateralBudget,
 		MaxCollateral:    defaultMaxCollateral,
+		FeeBumpCap:       defaultFeeBumpCap,
 
 		MinStoragePrice:           defaultStoragePrice,
 		MinContractPrice:          defaultContractPrice,
 		MinDownloadBandwidthPrice: defaultDownloadBandwidthPrice,
 		MinUploadBandwidthPrice:   defaultUploadBandwidthPrice,
 	}
+	h.pricingSettings = defaultPricingSettings()
 
 	// Generate signing key, for revising contracts.
 	sk, pk := crypto.GenerateKeyPair()
@@ -97,7 +119,15 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.log.Printf("WARN: AutoAddress '%v' loaded from persist is invalid: %v", p.AutoAddress, err)
 		h.autoAddress = ""
 	}
+	h.setDenylist(p.Denylist)
 	h.financialMetrics = p.FinancialMetrics
+	h.lastPriceUpdate = p.LastPriceUpdate
+	h.maintenance = p.Maintenance
+	h.pricingSettings = p.PricingSettings
+	if h.pricingSettings.Strategy == "" {
+		// The host was last saved before automatic pricing existed.
+		h.pricingSettings = defaultPricingSettings()
+	}
 	h.publicKey = p.PublicKey
 	h.revisionNumber = p.RevisionNumber
 	h.secretKey = p.SecretKey
@@ -129,6 +159,7 @@ func (h *Host) initDB() (err error) {
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
 			bucketActionItems,
+			bucketMetricsHistory,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
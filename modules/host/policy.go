@@ -0,0 +1,184 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// policy.go enforces the host's contract acceptance policy. The policy is a
+// set of optional limits in the host's internal settings that are checked
+// when a renter forms or renews a contract, in addition to the checks against
+// the host's prices and collateral settings. Each rule has its own error, so
+// that the renter and the host's log can tell which rule refused a contract.
+
+var (
+	// errPolicyBadCollateralRatio is returned if the host's settings contain
+	// a negative or otherwise invalid collateral ratio.
+	errPolicyBadCollateralRatio = errors.New("maxcollateralratio must be a non-negative number")
+
+	// errPolicyBadMinDuration is returned if the host's settings contain a
+	// minimum contract duration that is longer than the maximum.
+	errPolicyBadMinDuration = errors.New("minduration must not exceed maxduration")
+
+	// errPolicyCollateralRatio is returned if a contract asks the host for
+	// more collateral per siacoin of revenue than the host allows.
+	errPolicyCollateralRatio = ErrorCommunication("contract exceeds the host's maxcollateralratio")
+
+	// errPolicyLowPayout is returned if the renter funds a contract with less
+	// than the host's minimum contract payout.
+	errPolicyLowPayout = ErrorCommunication("contract payout is below the host's mincontractpayout")
+
+	// errPolicyPeriodCollateral is returned if the collateral of the contract
+	// would exceed the collateral that the host reserves for new contracts
+	// each period.
+	errPolicyPeriodCollateral = ErrorInternal("contract collateral would exceed the host's periodcollateralbudget")
+
+	// errPolicyRenterContracts is returned if the renter already holds the
+	// maximum number of contracts with the host.
+	errPolicyRenterContracts = ErrorCommunication("renter has reached the host's maxcontractsperrenter")
+
+	// errPolicyShortDuration is returned if a contract lasts fewer blocks
+	// than the host's minimum contract duration.
+	errPolicyShortDuration = ErrorCommunication("contract duration is below the host's minduration")
+)
+
+// policyContract summarizes a proposed contract for the acceptance policy.
+type policyContract struct {
+	// Duration is the number of blocks until the proof window of the contract
+	// opens.
+	Duration types.BlockHeight
+
+	// Payout is the amount that the renter puts into the contract, and
+	// Revenue is the most that the host can earn from it. Collateral is the
+	// collateral that the host adds to the contract.
+	Payout     types.Currency
+	Revenue    types.Currency
+	Collateral types.Currency
+
+	// RenterContracts is the number of unresolved contracts that the renter
+	// already holds with the host, and PeriodCollateral is the collateral of
+	// the contracts that the host formed in the current collateral period.
+	RenterContracts  uint64
+	PeriodCollateral types.Currency
+}
+
+// validatePolicySettings checks the acceptance policy in settings for values
+// that can never be satisfied.
+func validatePolicySettings(settings modules.HostInternalSettings) error {
+	if settings.MaxCollateralRatio < 0 || math.IsNaN(settings.MaxCollateralRatio) || math.IsInf(settings.MaxCollateralRatio, 0) {
+		return errPolicyBadCollateralRatio
+	}
+	if settings.MinDuration > settings.MaxDuration {
+		return errPolicyBadMinDuration
+	}
+	return nil
+}
+
+// checkContractPolicy returns the error of the first rule in the host's
+// acceptance policy that the contract violates, or nil if the contract is
+// acceptable.
+func checkContractPolicy(settings modules.HostInternalSettings, pc policyContract) error {
+	if settings.MinDuration != 0 && pc.Duration < settings.MinDuration {
+		return errPolicyShortDuration
+	}
+	if !settings.MinContractPayout.IsZero() && pc.Payout.Cmp(settings.MinContractPayout) < 0 {
+		return errPolicyLowPayout
+	}
+	if settings.MaxCollateralRatio != 0 {
+		// The collateral may not exceed the revenue multiplied by the ratio.
+		// The comparison is done with rationals to avoid rounding the ratio.
+		ratio := new(big.Rat).SetFloat64(settings.MaxCollateralRatio)
+		maxCollateral := ratio.Mul(ratio, new(big.Rat).SetInt(pc.Revenue.Big()))
+		if new(big.Rat).SetInt(pc.Collateral.Big()).Cmp(maxCollateral) > 0 {
+			return errPolicyCollateralRatio
+		}
+	}
+	if settings.MaxContractsPerRenter != 0 && pc.RenterContracts >= settings.MaxContractsPerRenter {
+		return errPolicyRenterContracts
+	}
+	if settings.CollateralPeriod != 0 && !settings.PeriodCollateralBudget.IsZero() && pc.PeriodCollateral.Add(pc.Collateral).Cmp(settings.PeriodCollateralBudget) > 0 {
+		return errPolicyPeriodCollateral
+	}
+	return nil
+}
+
+// renterKey returns the public key of the renter that holds the storage
+// obligation, which is the first key in the unlock conditions of the
+// obligation's revisions.
+func (so storageObligation) renterKey() (types.SiaPublicKey, bool) {
+	if len(so.RevisionTransactionSet) == 0 {
+		return types.SiaPublicKey{}, false
+	}
+	txn := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1]
+	if len(txn.FileContractRevisions) == 0 || len(txn.FileContractRevisions[0].UnlockConditions.PublicKeys) == 0 {
+		return types.SiaPublicKey{}, false
+	}
+	return txn.FileContractRevisions[0].UnlockConditions.PublicKeys[0], true
+}
+
+// managedPolicyStats scans the host's storage obligations, filling in the
+// number of unresolved contracts held by renterKey and the collateral of the
+// contracts formed in the current collateral period. The obligation being
+// renewed, if any, is not counted, because the renewal replaces it. The
+// database is only scanned if the policy has a rule that needs the result.
+func (h *Host) managedPolicyStats(settings modules.HostInternalSettings, blockHeight types.BlockHeight, renterKey types.SiaPublicKey, renewing types.FileContractID, pc *policyContract) error {
+	countContracts := settings.MaxContractsPerRenter != 0
+	sumCollateral := settings.CollateralPeriod != 0 && !settings.PeriodCollateralBudget.IsZero()
+	if !countContracts && !sumCollateral {
+		return nil
+	}
+	var periodStart types.BlockHeight
+	if blockHeight > settings.CollateralPeriod {
+		periodStart = blockHeight - settings.CollateralPeriod
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.id() == renewing || so.ObligationStatus == obligationRejected {
+				return nil
+			}
+			if countContracts && so.ObligationStatus == obligationUnresolved {
+				key, ok := so.renterKey()
+				if ok && key.String() == renterKey.String() {
+					pc.RenterContracts++
+				}
+			}
+			if sumCollateral && so.NegotiationHeight > periodStart {
+				pc.PeriodCollateral = pc.PeriodCollateral.Add(so.LockedCollateral)
+			}
+			return nil
+		})
+	})
+}
+
+// managedCheckContractPolicy checks a proposed contract against the host's
+// acceptance policy. Contracts that are refused are counted in the host's
+// network metrics and logged along with the rule that refused them.
+func (h *Host) managedCheckContractPolicy(settings modules.HostInternalSettings, blockHeight types.BlockHeight, renterKey types.SiaPublicKey, renewing types.FileContractID, pc policyContract) error {
+	err := h.managedPolicyStats(settings, blockHeight, renterKey, renewing, &pc)
+	if err != nil {
+		return extendErr("unable to check contract policy: ", ErrorInternal(err.Error()))
+	}
+	err = checkContractPolicy(settings, pc)
+	if err != nil {
+		atomic.AddUint64(&h.atomicPolicyRejections, 1)
+		h.log.Printf("Contract from renter %v refused by the acceptance policy: %v\n", renterKey.String(), err)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestCheckContractPolicy probes the checkContractPolicy function.
+func TestCheckContractPolicy(t *testing.T) {
+	settings := modules.HostInternalSettings{
+		MaxDuration:            1000,
+		MinDuration:            100,
+		MinContractPayout:      types.NewCurrency64(50),
+		MaxCollateralRatio:     1.5,
+		MaxContractsPerRenter:  2,
+		CollateralPeriod:       144,
+		PeriodCollateralBudget: types.NewCurrency64(1000),
+	}
+	valid := policyContract{
+		Duration:         100,
+		Payout:           types.NewCurrency64(50),
+		Revenue:          types.NewCurrency64(100),
+		Collateral:       types.NewCurrency64(150),
+		RenterContracts:  1,
+		PeriodCollateral: types.NewCurrency64(850),
+	}
+	if err := checkContractPolicy(settings, valid); err != nil {
+		t.Fatal("contract at the limits of the policy was refused:", err)
+	}
+
+	tests := []struct {
+		modify func(*policyContract)
+		err    error
+	}{
+		{func(pc *policyContract) { pc.Duration = 99 }, errPolicyShortDuration},
+		{func(pc *policyContract) { pc.Payout = types.NewCurrency64(49) }, errPolicyLowPayout},
+		{func(pc *policyContract) { pc.Collateral = types.NewCurrency64(151) }, errPolicyCollateralRatio},
+		{func(pc *policyContract) { pc.RenterContracts = 2 }, errPolicyRenterContracts},
+		{func(pc *policyContract) { pc.PeriodCollateral = types.NewCurrency64(851) }, errPolicyPeriodCollateral},
+	}
+	for _, test := range tests {
+		pc := valid
+		test.modify(&pc)
+		if err := checkContractPolicy(settings, pc); err != test.err {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+	}
+
+	// A policy of all zeros should accept anything.
+	pc := valid
+	pc.Duration = 1
+	pc.Payout = types.ZeroCurrency
+	pc.Collateral = types.NewCurrency64(1e9)
+	pc.RenterContracts = 1e6
+	if err := checkContractPolicy(modules.HostInternalSettings{}, pc); err != nil {
+		t.Fatal("empty policy refused a contract:", err)
+	}
+}
+
+// TestValidatePolicySettings checks that policies which can never be
+// satisfied are rejected.
+func TestValidatePolicySettings(t *testing.T) {
+	settings := modules.HostInternalSettings{MaxDuration: 100, MinDuration: 100}
+	if err := validatePolicySettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	settings.MinDuration = 101
+	if err := validatePolicySettings(settings); err != errPolicyBadMinDuration {
+		t.Fatal("expected errPolicyBadMinDuration, got", err)
+	}
+	settings.MinDuration = 0
+	settings.MaxCollateralRatio = -1
+	if err := validatePolicySettings(settings); err != errPolicyBadCollateralRatio {
+		t.Fatal("expected errPolicyBadCollateralRatio, got", err)
+	}
+}
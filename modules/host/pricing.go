@@ -0,0 +1,340 @@
+package host
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errBadNetworkOffset is returned if the network pricing strategy is
+	// configured with an offset that is not positive.
+	errBadNetworkOffset = errors.New("network offset must be greater than zero")
+
+	// errBadTargetUtilization is returned if the utilization pricing strategy
+	// is configured with a target outside of (0, 1].
+	errBadTargetUtilization = errors.New("target utilization must be greater than zero and at most one")
+
+	// errBadUpdateInterval is returned if automatic pricing is enabled with
+	// an update interval of zero.
+	errBadUpdateInterval = errors.New("update interval must be at least one block")
+
+	// errFloorAboveCeiling is returned if a price floor is higher than the
+	// corresponding price ceiling.
+	errFloorAboveCeiling = errors.New("price floor is higher than price ceiling")
+
+	// errNoHostDB is returned if the network pricing strategy is used on a
+	// host that does not have access to a hostdb.
+	errNoHostDB = errors.New("network pricing requires a hostdb, which is only available when the renter is loaded")
+
+	// errNoNetworkPrices is returned if the hostdb does not know of any hosts
+	// to derive network prices from.
+	errNoNetworkPrices = errors.New("no active hosts are available to derive network prices from")
+
+	// errZeroPriceFloor is returned if an automatic pricing strategy is
+	// enabled while one of the price floors is zero. Without a floor, a host
+	// that follows the network or a long lull in utilization could end up
+	// giving its storage away.
+	errZeroPriceFloor = errors.New("automatic pricing requires nonzero price floors")
+
+	// errUnknownPricingStrategy is returned if the pricing settings name a
+	// strategy that the host does not implement.
+	errUnknownPricingStrategy = errors.New("unknown pricing strategy")
+)
+
+// A HostDB provides the host with the settings of the other hosts on the
+// network, which are used by the network pricing strategy.
+type HostDB interface {
+	// ActiveHosts returns the hosts that are currently online.
+	ActiveHosts() []modules.HostDBEntry
+}
+
+// clampPrice returns the price, raised to the floor or lowered to the ceiling
+// if it falls outside of them. A ceiling of zero is ignored.
+func clampPrice(price, floor, ceiling types.Currency) types.Currency {
+	if !ceiling.IsZero() && price.Cmp(ceiling) > 0 {
+		price = ceiling
+	}
+	if price.Cmp(floor) < 0 {
+		price = floor
+	}
+	return price
+}
+
+// clampPrices applies the floors and ceilings of the pricing settings to a
+// set of prices.
+func clampPrices(p modules.HostPrices, ps modules.HostPricingSettings) modules.HostPrices {
+	return modules.HostPrices{
+		Collateral:                clampPrice(p.Collateral, ps.CollateralFloor, ps.CollateralCeiling),
+		MinDownloadBandwidthPrice: clampPrice(p.MinDownloadBandwidthPrice, ps.DownloadBandwidthPriceFloor, ps.DownloadBandwidthPriceCeiling),
+		MinStoragePrice:           clampPrice(p.MinStoragePrice, ps.StoragePriceFloor, ps.StoragePriceCeiling),
+		MinUploadBandwidthPrice:   clampPrice(p.MinUploadBandwidthPrice, ps.UploadBandwidthPriceFloor, ps.UploadBandwidthPriceCeiling),
+	}
+}
+
+// medianPrice returns the median of a set of prices. The set must not be
+// empty.
+func medianPrice(prices []types.Currency) types.Currency {
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+	return prices[len(prices)/2]
+}
+
+// networkPrices returns the median prices of the provided hosts, with the
+// storage and bandwidth prices scaled by the offset. Collateral is matched to
+// the median without an offset, so that a host pricing itself below the
+// network does not also appear less committed than the network.
+func networkPrices(hosts []modules.HostDBEntry, offset float64) (modules.HostPrices, error) {
+	if len(hosts) == 0 {
+		return modules.HostPrices{}, errNoNetworkPrices
+	}
+	var collaterals, downloadPrices, storagePrices, uploadPrices []types.Currency
+	for _, host := range hosts {
+		collaterals = append(collaterals, host.Collateral)
+		downloadPrices = append(downloadPrices, host.DownloadBandwidthPrice)
+		storagePrices = append(storagePrices, host.StoragePrice)
+		uploadPrices = append(uploadPrices, host.UploadBandwidthPrice)
+	}
+	return modules.HostPrices{
+		Collateral:                medianPrice(collaterals),
+		MinDownloadBandwidthPrice: medianPrice(downloadPrices).MulFloat(offset),
+		MinStoragePrice:           medianPrice(storagePrices).MulFloat(offset),
+		MinUploadBandwidthPrice:   medianPrice(uploadPrices).MulFloat(offset),
+	}, nil
+}
+
+// utilizationPrices adjusts the base prices according to how far the host's
+// storage utilization is from the target. A host that is fuller than the
+// target raises its prices and a host that is emptier than the target lowers
+// them, by up to pricingMaxAdjustment. Collateral is scaled alongside the
+// storage price so that the ratio between the two is preserved.
+func utilizationPrices(base modules.HostPrices, utilization, target float64) modules.HostPrices {
+	var deviation float64
+	if utilization > target {
+		deviation = (utilization - target) / (1 - target)
+	} else {
+		deviation = (utilization - target) / target
+	}
+	factor := 1 + pricingMaxAdjustment*deviation
+	return modules.HostPrices{
+		Collateral:                base.Collateral.MulFloat(factor),
+		MinDownloadBandwidthPrice: base.MinDownloadBandwidthPrice.MulFloat(factor),
+		MinStoragePrice:           base.MinStoragePrice.MulFloat(factor),
+		MinUploadBandwidthPrice:   base.MinUploadBandwidthPrice.MulFloat(factor),
+	}
+}
+
+// zeroPrices returns true if none of the prices have been set.
+func zeroPrices(p modules.HostPrices) bool {
+	return p.Collateral.IsZero() && p.MinDownloadBandwidthPrice.IsZero() &&
+		p.MinStoragePrice.IsZero() && p.MinUploadBandwidthPrice.IsZero()
+}
+
+// validatePricingSettings checks that the pricing settings are usable.
+func validatePricingSettings(ps modules.HostPricingSettings) error {
+	switch ps.Strategy {
+	case modules.HostPricingManual:
+	case modules.HostPricingUtilization:
+		if ps.TargetUtilization <= 0 || ps.TargetUtilization > 1 {
+			return errBadTargetUtilization
+		}
+	case modules.HostPricingNetwork:
+		if ps.NetworkOffset <= 0 {
+			return errBadNetworkOffset
+		}
+	default:
+		return errUnknownPricingStrategy
+	}
+	if ps.Strategy != modules.HostPricingManual && ps.UpdateInterval == 0 {
+		return errBadUpdateInterval
+	}
+	if ps.Strategy != modules.HostPricingManual {
+		floors := []types.Currency{ps.CollateralFloor, ps.DownloadBandwidthPriceFloor, ps.StoragePriceFloor, ps.UploadBandwidthPriceFloor}
+		for _, floor := range floors {
+			if floor.IsZero() {
+				return errZeroPriceFloor
+			}
+		}
+	}
+	bounds := [][2]types.Currency{
+		{ps.CollateralFloor, ps.CollateralCeiling},
+		{ps.DownloadBandwidthPriceFloor, ps.DownloadBandwidthPriceCeiling},
+		{ps.StoragePriceFloor, ps.StoragePriceCeiling},
+		{ps.UploadBandwidthPriceFloor, ps.UploadBandwidthPriceCeiling},
+	}
+	for _, b := range bounds {
+		if !b[1].IsZero() && b[0].Cmp(b[1]) > 0 {
+			return errFloorAboveCeiling
+		}
+	}
+	return nil
+}
+
+// currentPrices returns the host's current prices.
+func (h *Host) currentPrices() modules.HostPrices {
+	return modules.HostPrices{
+		Collateral:                h.settings.Collateral,
+		MinDownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		MinStoragePrice:           h.settings.MinStoragePrice,
+		MinUploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+	}
+}
+
+// logPriceChange writes a price change to the host log if the price has
+// changed.
+func (h *Host) logPriceChange(name string, oldPrice, newPrice types.Currency, strategy modules.HostPricingStrategy) {
+	if oldPrice.Equals(newPrice) {
+		return
+	}
+	h.log.Printf("Pricing: %v strategy changed %v from %v to %v", strategy, name, oldPrice, newPrice)
+}
+
+// managedNextPrices computes the prices that the automatic pricing engine
+// would set given the host's current state.
+func (h *Host) managedNextPrices() (modules.HostPrices, error) {
+	h.mu.RLock()
+	ps := h.pricingSettings
+	current := h.currentPrices()
+	hdb := h.hostDB
+	h.mu.RUnlock()
+
+	next := current
+	switch ps.Strategy {
+	case modules.HostPricingUtilization:
+		var capacity, remaining uint64
+		for _, sf := range h.StorageFolders() {
+			capacity += sf.Capacity
+			remaining += sf.CapacityRemaining
+		}
+		// A host without storage has no utilization to react to, so its
+		// prices are left alone.
+		if capacity == 0 {
+			break
+		}
+		utilization := float64(capacity-remaining) / float64(capacity)
+		next = utilizationPrices(ps.BasePrices, utilization, ps.TargetUtilization)
+	case modules.HostPricingNetwork:
+		if hdb == nil {
+			return modules.HostPrices{}, errNoHostDB
+		}
+		var err error
+		next, err = networkPrices(hdb.ActiveHosts(), ps.NetworkOffset)
+		if err != nil {
+			return modules.HostPrices{}, err
+		}
+	}
+	return clampPrices(next, ps), nil
+}
+
+// threadedUpdatePrices runs the automatic pricing engine and applies the
+// resulting prices to the host's internal settings.
+func (h *Host) threadedUpdatePrices() {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	next, err := h.managedNextPrices()
+	if err != nil {
+		h.log.Println("WARN: automatic pricing could not compute new prices:", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The operator may have disabled automatic pricing while the prices were
+	// being computed.
+	strategy := h.pricingSettings.Strategy
+	if strategy == modules.HostPricingManual {
+		return
+	}
+	current := h.currentPrices()
+	h.logPriceChange("collateral", current.Collateral, next.Collateral, strategy)
+	h.logPriceChange("mindownloadbandwidthprice", current.MinDownloadBandwidthPrice, next.MinDownloadBandwidthPrice, strategy)
+	h.logPriceChange("minstorageprice", current.MinStoragePrice, next.MinStoragePrice, strategy)
+	h.logPriceChange("minuploadbandwidthprice", current.MinUploadBandwidthPrice, next.MinUploadBandwidthPrice, strategy)
+
+	h.settings.Collateral = next.Collateral
+	h.settings.MinDownloadBandwidthPrice = next.MinDownloadBandwidthPrice
+	h.settings.MinStoragePrice = next.MinStoragePrice
+	h.settings.MinUploadBandwidthPrice = next.MinUploadBandwidthPrice
+	h.revisionNumber++
+	err = h.saveSync()
+	if err != nil {
+		h.log.Println("ERROR: could not save host after updating prices:", err)
+	}
+}
+
+// PricePreview returns the prices that the automatic pricing engine would set
+// if it ran now.
+func (h *Host) PricePreview() (modules.HostPricePreview, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostPricePreview{}, err
+	}
+	defer h.tg.Done()
+
+	next, err := h.managedNextPrices()
+	if err != nil {
+		return modules.HostPricePreview{}, err
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return modules.HostPricePreview{
+		Strategy:         h.pricingSettings.Strategy,
+		Current:          h.currentPrices(),
+		Next:             next,
+		NextUpdateHeight: h.lastPriceUpdate + h.pricingSettings.UpdateInterval,
+	}, nil
+}
+
+// PricingSettings returns the configuration of the automatic pricing engine.
+func (h *Host) PricingSettings() modules.HostPricingSettings {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pricingSettings
+}
+
+// SetHostDB gives the host access to a hostdb, enabling the network pricing
+// strategy.
+func (h *Host) SetHostDB(hdb HostDB) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hostDB = hdb
+}
+
+// SetPricingSettings configures the automatic pricing engine. If automatic
+// pricing is enabled, the prices are updated immediately, and then once every
+// UpdateInterval blocks.
+func (h *Host) SetPricingSettings(ps modules.HostPricingSettings) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	err = validatePricingSettings(ps)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ps.Strategy == modules.HostPricingUtilization && zeroPrices(ps.BasePrices) {
+		ps.BasePrices = h.currentPrices()
+	}
+	h.log.Printf("Pricing: strategy set to %v, update interval %v blocks", ps.Strategy, ps.UpdateInterval)
+	h.pricingSettings = ps
+	h.lastPriceUpdate = h.blockHeight
+	err = h.saveSync()
+	if err != nil {
+		return err
+	}
+	if ps.Strategy != modules.HostPricingManual {
+		go h.threadedUpdatePrices()
+	}
+	return nil
+}
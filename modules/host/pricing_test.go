@@ -0,0 +1,301 @@
+package host
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// stubHostDB is a HostDB that returns a fixed set of hosts.
+type stubHostDB []modules.HostDBEntry
+
+func (hdb stubHostDB) ActiveHosts() []modules.HostDBEntry { return hdb }
+
+// TestClampPrice probes the clampPrice function.
+func TestClampPrice(t *testing.T) {
+	tests := []struct {
+		price, floor, ceiling, want uint64
+	}{
+		{5, 0, 0, 5},
+		{5, 10, 0, 10},
+		{5, 0, 3, 3},
+		{5, 1, 10, 5},
+		{5, 5, 5, 5},
+	}
+	for _, test := range tests {
+		got := clampPrice(types.NewCurrency64(test.price), types.NewCurrency64(test.floor), types.NewCurrency64(test.ceiling))
+		if !got.Equals64(test.want) {
+			t.Errorf("clampPrice(%v, %v, %v): expected %v, got %v", test.price, test.floor, test.ceiling, test.want, got)
+		}
+	}
+}
+
+// TestUtilizationPrices checks that the utilization strategy moves prices in
+// the right direction and by no more than pricingMaxAdjustment.
+func TestUtilizationPrices(t *testing.T) {
+	base := modules.HostPrices{
+		Collateral:                types.NewCurrency64(2000),
+		MinDownloadBandwidthPrice: types.NewCurrency64(1000),
+		MinStoragePrice:           types.NewCurrency64(1000),
+		MinUploadBandwidthPrice:   types.NewCurrency64(1000),
+	}
+
+	// At the target, prices should not change.
+	next := utilizationPrices(base, 0.5, 0.5)
+	if !next.MinStoragePrice.Equals(base.MinStoragePrice) || !next.Collateral.Equals(base.Collateral) {
+		t.Fatal("prices changed at target utilization:", next)
+	}
+	// A full host should raise prices by the maximum adjustment.
+	next = utilizationPrices(base, 1, 0.5)
+	if !next.MinStoragePrice.Equals64(1100) || !next.Collateral.Equals64(2200) {
+		t.Fatal("full host did not raise prices by the maximum adjustment:", next)
+	}
+	// An empty host should lower prices by the maximum adjustment.
+	next = utilizationPrices(base, 0, 0.5)
+	if !next.MinUploadBandwidthPrice.Equals64(900) || !next.Collateral.Equals64(1800) {
+		t.Fatal("empty host did not lower prices by the maximum adjustment:", next)
+	}
+	// A host slightly above its target should raise prices slightly.
+	next = utilizationPrices(base, 0.75, 0.5)
+	if !next.MinDownloadBandwidthPrice.Equals64(1050) {
+		t.Fatal("host above target did not raise prices proportionally:", next)
+	}
+}
+
+// TestNetworkPrices checks that the network strategy follows the median
+// prices of the other hosts.
+func TestNetworkPrices(t *testing.T) {
+	_, err := networkPrices(nil, 1)
+	if err != errNoNetworkPrices {
+		t.Fatal("expected errNoNetworkPrices, got", err)
+	}
+
+	var hosts []modules.HostDBEntry
+	for _, price := range []uint64{100, 300, 200} {
+		var entry modules.HostDBEntry
+		entry.Collateral = types.NewCurrency64(price * 2)
+		entry.DownloadBandwidthPrice = types.NewCurrency64(price)
+		entry.StoragePrice = types.NewCurrency64(price)
+		entry.UploadBandwidthPrice = types.NewCurrency64(price)
+		hosts = append(hosts, entry)
+	}
+	prices, err := networkPrices(hosts, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prices.MinStoragePrice.Equals64(180) || !prices.MinDownloadBandwidthPrice.Equals64(180) || !prices.MinUploadBandwidthPrice.Equals64(180) {
+		t.Fatal("prices do not match the offset median:", prices)
+	}
+	if !prices.Collateral.Equals64(400) {
+		t.Fatal("collateral does not match the median:", prices.Collateral)
+	}
+}
+
+// TestValidatePricingSettings probes the validatePricingSettings function.
+func TestValidatePricingSettings(t *testing.T) {
+	ps := defaultPricingSettings()
+	if err := validatePricingSettings(ps); err != nil {
+		t.Fatal(err)
+	}
+	bad := ps
+	bad.Strategy = "bogus"
+	if err := validatePricingSettings(bad); err != errUnknownPricingStrategy {
+		t.Fatal("expected errUnknownPricingStrategy, got", err)
+	}
+	bad = ps
+	bad.Strategy = modules.HostPricingUtilization
+	bad.TargetUtilization = 1.5
+	if err := validatePricingSettings(bad); err != errBadTargetUtilization {
+		t.Fatal("expected errBadTargetUtilization, got", err)
+	}
+	bad = ps
+	bad.Strategy = modules.HostPricingNetwork
+	bad.NetworkOffset = 0
+	if err := validatePricingSettings(bad); err != errBadNetworkOffset {
+		t.Fatal("expected errBadNetworkOffset, got", err)
+	}
+	bad = ps
+	bad.Strategy = modules.HostPricingNetwork
+	bad.UpdateInterval = 0
+	if err := validatePricingSettings(bad); err != errBadUpdateInterval {
+		t.Fatal("expected errBadUpdateInterval, got", err)
+	}
+	bad = ps
+	bad.Strategy = modules.HostPricingUtilization
+	if err := validatePricingSettings(bad); err != errZeroPriceFloor {
+		t.Fatal("expected errZeroPriceFloor, got", err)
+	}
+	bad = ps
+	bad.StoragePriceFloor = types.NewCurrency64(10)
+	bad.StoragePriceCeiling = types.NewCurrency64(5)
+	if err := validatePricingSettings(bad); err != errFloorAboveCeiling {
+		t.Fatal("expected errFloorAboveCeiling, got", err)
+	}
+}
+
+// TestHostAutomaticPricing checks that the host applies the prices chosen by
+// the pricing engine, respects floors and ceilings, and keeps updating its
+// prices as blocks are mined.
+func TestHostAutomaticPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// The network strategy cannot be previewed without a hostdb.
+	ps := ht.host.PricingSettings()
+	if ps.Strategy != modules.HostPricingManual {
+		t.Fatal("host should default to manual pricing, got", ps.Strategy)
+	}
+	ps.Strategy = modules.HostPricingNetwork
+	ps.CollateralFloor = types.NewCurrency64(1)
+	ps.DownloadBandwidthPriceFloor = types.NewCurrency64(1)
+	ps.StoragePriceFloor = types.NewCurrency64(1)
+	ps.UploadBandwidthPriceFloor = types.NewCurrency64(1)
+	err = ht.host.SetPricingSettings(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.host.PricePreview()
+	if err != errNoHostDB {
+		t.Fatal("expected errNoHostDB, got", err)
+	}
+
+	// Give the host a hostdb and cap the storage price below the network
+	// median.
+	var entry modules.HostDBEntry
+	entry.Collateral = types.NewCurrency64(4000)
+	entry.DownloadBandwidthPrice = types.NewCurrency64(1000)
+	entry.StoragePrice = types.NewCurrency64(2000)
+	entry.UploadBandwidthPrice = types.NewCurrency64(1000)
+	ht.host.SetHostDB(stubHostDB{entry})
+	ps.StoragePriceCeiling = types.NewCurrency64(1500)
+	pp, err := ht.host.PricePreview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pp.Next.MinStoragePrice.Equals64(2000) {
+		t.Fatal("preview does not match the network price:", pp.Next)
+	}
+	err = ht.host.SetPricingSettings(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		is := ht.host.InternalSettings()
+		if !is.MinStoragePrice.Equals64(1500) || !is.MinDownloadBandwidthPrice.Equals64(1000) || !is.Collateral.Equals64(4000) {
+			return errors.New("host did not apply network prices")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the network prices. The host should follow them once the
+	// update interval has passed.
+	entry.DownloadBandwidthPrice = types.NewCurrency64(3000)
+	ht.host.SetHostDB(stubHostDB{entry})
+	for i := types.BlockHeight(0); i < ps.UpdateInterval; i++ {
+		_, err = ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if !ht.host.InternalSettings().MinDownloadBandwidthPrice.Equals64(3000) {
+			return errors.New("host did not follow the network price")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Switching back to manual pricing should leave the prices alone.
+	ps.Strategy = modules.HostPricingManual
+	err = ht.host.SetPricingSettings(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.DownloadBandwidthPrice = types.NewCurrency64(5000)
+	ht.host.SetHostDB(stubHostDB{entry})
+	for i := types.BlockHeight(0); i < ps.UpdateInterval; i++ {
+		_, err = ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Second)
+	if !ht.host.InternalSettings().MinDownloadBandwidthPrice.Equals64(3000) {
+		t.Fatal("host changed prices while using manual pricing")
+	}
+}
+
+// TestHostUtilizationPricingBase checks that the utilization strategy computes
+// each update from the base prices, so that repeated updates on an idle host
+// do not keep lowering its prices.
+func TestHostUtilizationPricingBase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Enable the utilization strategy. The host's storage is empty, so its
+	// prices should drop by the maximum adjustment from the current prices,
+	// which become the base prices.
+	ps := ht.host.PricingSettings()
+	ps.Strategy = modules.HostPricingUtilization
+	ps.CollateralFloor = types.NewCurrency64(1)
+	ps.DownloadBandwidthPriceFloor = types.NewCurrency64(1)
+	ps.StoragePriceFloor = types.NewCurrency64(1)
+	ps.UploadBandwidthPriceFloor = types.NewCurrency64(1)
+	is := ht.host.InternalSettings()
+	base := modules.HostPrices{
+		Collateral:                is.Collateral,
+		MinDownloadBandwidthPrice: is.MinDownloadBandwidthPrice,
+		MinStoragePrice:           is.MinStoragePrice,
+		MinUploadBandwidthPrice:   is.MinUploadBandwidthPrice,
+	}
+	err = ht.host.SetPricingSettings(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps = ht.host.PricingSettings()
+	if !ps.BasePrices.MinStoragePrice.Equals(base.MinStoragePrice) || !ps.BasePrices.Collateral.Equals(base.Collateral) {
+		t.Fatal("current prices were not used as the base prices:", ps.BasePrices)
+	}
+	expected := utilizationPrices(base, 0, ps.TargetUtilization)
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if !ht.host.InternalSettings().MinStoragePrice.Equals(expected.MinStoragePrice) {
+			return errors.New("host did not lower its storage price")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Further updates should not lower the prices any more.
+	for i := 0; i < 3; i++ {
+		ht.host.threadedUpdatePrices()
+	}
+	is = ht.host.InternalSettings()
+	if !is.MinStoragePrice.Equals(expected.MinStoragePrice) || !is.Collateral.Equals(expected.Collateral) {
+		t.Fatal("repeated updates compounded the price adjustment:", is.MinStoragePrice, expected.MinStoragePrice)
+	}
+}
@@ -0,0 +1,173 @@
+package host
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// estimatedProofFee returns the estimated fee of the transaction containing
+// the storage proof for an obligation, using the same transaction size
+// estimate as threadedHandleActionItem.
+func estimatedProofFee(so storageObligation, feeRecommendation types.Currency) types.Currency {
+	segments := uint64(len(so.SectorRoots)) * (modules.SectorSize / crypto.SegmentSize)
+	hashSetLen := 0
+	for segments > 0 {
+		hashSetLen++
+		segments >>= 1
+	}
+	sp := types.StorageProof{
+		HashSet: make([]crypto.Hash, hashSetLen),
+	}
+	txnSize := uint64(len(encoding.Marshal(sp)) + 300)
+	return feeRecommendation.Mul64(txnSize)
+}
+
+// sectorRootsMerkleRoot returns the file Merkle root of a set of sector roots.
+func sectorRootsMerkleRoot(roots []crypto.Hash) crypto.Hash {
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	for _, root := range roots {
+		ct.Push(root)
+	}
+	return ct.Root()
+}
+
+// managedCheckProofReadiness checks each unresolved obligation that still
+// needs a storage proof, confirming that every sector can be read from an
+// available storage folder and still matches its root, that the sector roots
+// match the Merkle root of the last revision, and that the wallet can pay for
+// the proof transaction. Sectors are read from disk at no more than
+// proofCheckBytesPerSecond, bypassing the sector cache. Obligations are
+// checked in order of expiration, so that the fees of the earliest proofs are
+// counted against the wallet balance first. The caller must hold
+// proofCheckMu.
+func (h *Host) managedCheckProofReadiness() (modules.HostProofReadiness, error) {
+	var sos []storageObligation
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return err
+			}
+			if so.ObligationStatus == obligationUnresolved && !so.ProofConfirmed {
+				sos = append(sos, so)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostProofReadiness{}, err
+	}
+	sort.Slice(sos, func(i, j int) bool {
+		return sos[i].expiration() < sos[j].expiration()
+	})
+
+	h.mu.RLock()
+	height := h.blockHeight
+	h.mu.RUnlock()
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	balance, _, _ := h.wallet.ConfirmedBalance()
+	unlocked := h.wallet.Unlocked()
+
+	pr := modules.HostProofReadiness{
+		CheckHeight:        height,
+		CheckTime:          time.Now(),
+		ObligationsChecked: uint64(len(sos)),
+		Risks:              []modules.HostProofRisk{},
+	}
+	sectorDelay := time.Duration(modules.SectorSize) * time.Second / time.Duration(proofCheckBytesPerSecond)
+	var feesRequired types.Currency
+	for _, so := range sos {
+		risk := modules.HostProofRisk{
+			ObligationID:      so.id(),
+			ExpirationHeight:  so.expiration(),
+			ProofDeadline:     so.proofDeadline(),
+			EstimatedFee:      estimatedProofFee(so, feeRecommendation),
+			CollateralAtStake: so.RiskedCollateral,
+		}
+		for _, root := range so.SectorRoots {
+			start := time.Now()
+			pr.SectorsChecked++
+			err := h.CheckSector(root)
+			if err == contractmanager.ErrSectorCorrupt {
+				risk.CorruptSectors++
+			} else if err != nil {
+				risk.UnreadableSectors++
+			}
+
+			select {
+			case <-h.tg.StopChan():
+				return modules.HostProofReadiness{}, errHostClosed
+			case <-time.After(sectorDelay - time.Since(start)):
+			}
+		}
+		risk.MerkleRootMismatch = sectorRootsMerkleRoot(so.SectorRoots) != so.merkleRoot()
+		risk.FeeExceedsValue = so.value().Cmp(feeRecommendation) < 0
+		feesRequired = feesRequired.Add(risk.EstimatedFee)
+		risk.CannotFundProof = !unlocked || feesRequired.Cmp(balance) > 0
+
+		if risk.UnreadableSectors > 0 || risk.CorruptSectors > 0 || risk.MerkleRootMismatch || risk.FeeExceedsValue || risk.CannotFundProof {
+			h.log.Printf("WARN: storage proof at risk for obligation %v: %v unreadable sectors, %v corrupt sectors, merkle root mismatch %v, cannot fund proof %v, fee exceeds value %v",
+				risk.ObligationID, risk.UnreadableSectors, risk.CorruptSectors, risk.MerkleRootMismatch, risk.CannotFundProof, risk.FeeExceedsValue)
+			pr.Risks = append(pr.Risks, risk)
+			pr.CollateralAtRisk = pr.CollateralAtRisk.Add(risk.CollateralAtStake)
+		}
+	}
+
+	h.mu.Lock()
+	h.proofReadiness = pr
+	h.mu.Unlock()
+	return pr, nil
+}
+
+// threadedCheckProofReadiness runs the periodic storage proof readiness
+// self-check. The check is skipped if another check is already running.
+func (h *Host) threadedCheckProofReadiness() {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+	if !h.proofCheckMu.TryLock() {
+		return
+	}
+	defer h.proofCheckMu.Unlock()
+
+	_, err = h.managedCheckProofReadiness()
+	if err != nil {
+		h.log.Println("ERROR: storage proof readiness self-check failed:", err)
+	}
+}
+
+// CheckProofReadiness runs the storage proof readiness self-check and returns
+// the result.
+func (h *Host) CheckProofReadiness() (modules.HostProofReadiness, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostProofReadiness{}, err
+	}
+	defer h.tg.Done()
+	h.proofCheckMu.Lock()
+	defer h.proofCheckMu.Unlock()
+	return h.managedCheckProofReadiness()
+}
+
+// ProofReadiness returns the result of the most recent storage proof
+// readiness self-check.
+func (h *Host) ProofReadiness() modules.HostProofReadiness {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.proofReadiness
+}
@@ -0,0 +1,148 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestCheckProofReadiness checks that the storage proof readiness self-check
+// reports obligations with missing or corrupt sectors or a mismatched Merkle
+// root, reading sector data from disk rather than from the sector cache.
+func TestCheckProofReadiness(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation holding a single sector.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	so.ContractCost = types.SiacoinPrecision
+	so.RiskedCollateral = types.NewCurrency64(500)
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			NewRevisionNumber:     1,
+			NewFileSize:           uint64(len(sectorData)),
+			NewFileMerkleRoot:     sectorRoot,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+		}},
+	}}
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{sectorRoot}, [][]byte{sectorData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// A healthy obligation should not be reported. The check must read the
+	// sectors from disk, bypassing the sector cache.
+	cacheMetrics := ht.host.SectorCacheMetrics()
+	pr, err := ht.host.CheckProofReadiness()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.ObligationsChecked != 1 || pr.SectorsChecked != 1 {
+		t.Fatalf("expected 1 obligation and 1 sector to be checked, got %v and %v", pr.ObligationsChecked, pr.SectorsChecked)
+	}
+	if len(pr.Risks) != 0 {
+		t.Fatalf("healthy obligation reported as at risk: %+v", pr.Risks[0])
+	}
+	if ht.host.SectorCacheMetrics() != cacheMetrics {
+		t.Fatal("self-check read sector data through the sector cache")
+	}
+
+	// Overwrite the sector on disk. The check should find the corruption
+	// without waiting for the scrubber.
+	sl, err := ht.host.SectorLocation(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folderPath string
+	for _, sf := range ht.host.StorageFolders() {
+		if sf.Index == sl.StorageFolder {
+			folderPath = sf.Path
+		}
+	}
+	f, err := os.OpenFile(filepath.Join(folderPath, "siahostdata.dat"), os.O_RDWR, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt(fastrand.Bytes(int(crypto.SegmentSize)), int64(uint64(sl.Index)*modules.SectorSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err = ht.host.CheckProofReadiness()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.Risks) != 1 || pr.Risks[0].CorruptSectors != 1 || pr.Risks[0].UnreadableSectors != 0 {
+		t.Fatalf("expected a corrupt sector, got %+v", pr.Risks)
+	}
+	if !ht.host.SectorCorrupt(sectorRoot) {
+		t.Fatal("corrupt sector was not recorded")
+	}
+
+	// Point the revision at a different Merkle root.
+	so.RevisionTransactionSet[0].FileContractRevisions[0].NewFileMerkleRoot = crypto.Hash{1}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.modifyStorageObligation(so, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+	pr, err = ht.host.CheckProofReadiness()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.Risks) != 1 || !pr.Risks[0].MerkleRootMismatch || pr.Risks[0].UnreadableSectors != 0 || pr.Risks[0].CorruptSectors != 1 {
+		t.Fatalf("expected a Merkle root mismatch, got %+v", pr.Risks)
+	}
+	if !pr.CollateralAtRisk.Equals64(500) || !pr.Risks[0].CollateralAtStake.Equals64(500) {
+		t.Fatal("unexpected collateral at risk:", pr.CollateralAtRisk)
+	}
+
+	// Remove the sector from the storage manager.
+	err = ht.host.RemoveSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.host.CheckProofReadiness()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr = ht.host.ProofReadiness()
+	if len(pr.Risks) != 1 || pr.Risks[0].UnreadableSectors != 1 {
+		t.Fatalf("expected an unreadable sector, got %+v", pr.Risks)
+	}
+	if pr.Risks[0].ObligationID != so.id() || pr.Risks[0].ProofDeadline != so.proofDeadline() {
+		t.Fatal("risk does not describe the obligation")
+	}
+}
@@ -0,0 +1,134 @@
+package host
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+var (
+	// rateLimitChunkSize is the largest number of bytes that a rate limited
+	// connection will read or write at once. Splitting large transfers into
+	// chunks keeps the transfer rate smooth.
+	rateLimitChunkSize = build.Select(build.Var{
+		Dev:      64 << 10, // 64 KiB
+		Standard: 64 << 10, // 64 KiB
+		Testing:  1 << 10,  // 1 KiB
+	}).(int)
+)
+
+// A rateLimiter limits the combined throughput of every connection that
+// shares it. A limit of zero means that throughput is unlimited.
+type rateLimiter struct {
+	atomicBytesPerSecond uint64
+
+	mu   sync.Mutex
+	next time.Time // time at which the next byte may be transferred
+}
+
+// setLimit changes the limit of the rateLimiter.
+func (rl *rateLimiter) setLimit(bytesPerSecond uint64) {
+	atomic.StoreUint64(&rl.atomicBytesPerSecond, bytesPerSecond)
+}
+
+// wait reserves n bytes of throughput, and blocks until the reservation
+// begins or until cancel is closed.
+func (rl *rateLimiter) wait(n int, cancel <-chan struct{}) {
+	bps := atomic.LoadUint64(&rl.atomicBytesPerSecond)
+	if bps == 0 {
+		return
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	start := rl.next
+	rl.next = rl.next.Add(time.Duration(uint64(n) * uint64(time.Second) / bps))
+	rl.mu.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-cancel:
+		}
+	}
+}
+
+// A rateLimitedConn is a net.Conn whose reads and writes are throttled by the
+// host's download and upload rate limiters.
+type rateLimitedConn struct {
+	net.Conn
+	h *Host
+}
+
+// Read reads data from the connection, waiting on the host's download limiter
+// for every chunk that is read.
+func (c *rateLimitedConn) Read(b []byte) (int, error) {
+	if len(b) > rateLimitChunkSize {
+		b = b[:rateLimitChunkSize]
+	}
+	n, err := c.Conn.Read(b)
+	c.h.downloadLimiter.wait(n, c.h.tg.StopChan())
+	return n, err
+}
+
+// Write writes data to the connection, waiting on the host's upload limiter
+// before every chunk that is written.
+func (c *rateLimitedConn) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > rateLimitChunkSize {
+			chunk = chunk[:rateLimitChunkSize]
+		}
+		c.h.uploadLimiter.wait(len(chunk), c.h.tg.StopChan())
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// updateRateLimits applies the rate limits of the host's internal settings to
+// the host's rate limiters.
+func (h *Host) updateRateLimits() {
+	h.downloadLimiter.setLimit(h.settings.MaxDownloadSpeed)
+	h.uploadLimiter.setLimit(h.settings.MaxUploadSpeed)
+}
+
+// managedTrackConn records a new incoming connection from the provided IP
+// address. False is returned if accepting the connection would exceed the
+// host's connection limits, in which case the connection is not recorded.
+func (h *Host) managedTrackConn(ip string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.settings.MaxConnections != 0 && h.openConns >= h.settings.MaxConnections {
+		atomic.AddUint64(&h.atomicRejectedConnections, 1)
+		return false
+	}
+	if h.settings.MaxConnectionsPerIP != 0 && h.openConnsPerIP[ip] >= h.settings.MaxConnectionsPerIP {
+		atomic.AddUint64(&h.atomicRejectedIPConnections, 1)
+		return false
+	}
+	h.openConns++
+	h.openConnsPerIP[ip]++
+	return true
+}
+
+// managedUntrackConn removes a connection recorded by managedTrackConn.
+func (h *Host) managedUntrackConn(ip string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.openConns--
+	h.openConnsPerIP[ip]--
+	if h.openConnsPerIP[ip] == 0 {
+		delete(h.openConnsPerIP, ip)
+	}
+}
@@ -105,6 +105,21 @@ var (
 
 type storageObligationStatus uint64
 
+// String returns the name of the storage obligation status.
+func (sos storageObligationStatus) String() string {
+	switch sos {
+	case obligationUnresolved:
+		return "unresolved"
+	case obligationRejected:
+		return "rejected"
+	case obligationSucceeded:
+		return "succeeded"
+	case obligationFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
 // storageObligation contains all of the metadata related to a file contract
 // and the storage contained by the file contract.
 type storageObligation struct {
@@ -137,6 +152,22 @@ type storageObligation struct {
 	OriginTransactionSet   []types.Transaction
 	RevisionTransactionSet []types.Transaction
 
+	// The revision and storage proof transaction sets that the host has
+	// submitted to the transaction pool, tracked so that their fees can be
+	// raised if they are not confirmed in time.
+	RevisionSubmission submittedTransaction
+	ProofSubmission    submittedTransaction
+
+	// Bandwidth accounting for the obligation. BytesUploaded is the data
+	// that the renter sent to the host in revisions, and BytesDownloaded is
+	// the data that the host sent to the renter. RevisionCount is the number
+	// of revisions negotiated with the renter, and RenterAddresses contains
+	// the addresses that the renter connected from.
+	BytesUploaded   uint64
+	BytesDownloaded uint64
+	RevisionCount   uint64
+	RenterAddresses []modules.HostRenterAddress
+
 	// Variables indicating whether the critical transactions in a storage
 	// obligation have been confirmed on the blockchain.
 	OriginConfirmed     bool
@@ -703,39 +734,36 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			h.log.Println("Error queuing action item:", err)
 		}
 
-		// Add a miner fee to the transaction and submit it to the blockchain.
-		revisionTxnIndex := len(so.RevisionTransactionSet) - 1
-		revisionParents := so.RevisionTransactionSet[:revisionTxnIndex]
-		revisionTxn := so.RevisionTransactionSet[revisionTxnIndex]
-		builder := h.wallet.RegisterTransaction(revisionTxn, revisionParents)
-		_, feeRecommendation := h.tpool.FeeEstimation()
-		if so.value().Div64(2).Cmp(feeRecommendation) < 0 {
-			// There's no sense submitting the revision if the fee is more than
-			// half of the anticipated revenue - fee market went up
-			// unexpectedly, and the money that the renter paid to cover the
-			// fees is no longer enough.
-			return
-		}
-		txnSize := uint64(len(encoding.MarshalAll(so.RevisionTransactionSet)) + 300)
-		requiredFee := feeRecommendation.Mul64(txnSize)
-		err = builder.FundSiacoins(requiredFee)
-		if err != nil {
-			h.log.Println("Error funding transaction fees", err)
-		}
-		builder.AddMinerFee(requiredFee)
-		if err != nil {
-			h.log.Println("Error adding miner fees", err)
-		}
-		feeAddedRevisionTransactionSet, err := builder.Sign(true)
-		if err != nil {
-			h.log.Println("Error signing transaction", err)
-		}
-		err = h.tpool.AcceptTransactionSet(feeAddedRevisionTransactionSet)
-		if err != nil {
-			h.log.Println("Error submitting transaction to transaction pool", err)
+		// If the revision has already been submitted, resubmit it, raising
+		// its fees if the deadline is close.
+		if len(so.RevisionSubmission.TransactionSet) > 0 {
+			var extraFees types.Currency
+			rebuild := func(fee types.Currency) (submittedTransaction, error) {
+				return h.managedSubmitRevision(so, fee)
+			}
+			so.RevisionSubmission, extraFees = h.managedWatchSubmission(so, so.RevisionSubmission, "revision", so.expiration(), rebuild)
+			so.TransactionFeesAdded = so.TransactionFeesAdded.Add(extraFees)
+		} else {
+			// Add a miner fee to the transaction and submit it to the
+			// blockchain.
+			_, feeRecommendation := h.tpool.FeeEstimation()
+			if so.value().Div64(2).Cmp(feeRecommendation) < 0 {
+				// There's no sense submitting the revision if the fee is more
+				// than half of the anticipated revenue - fee market went up
+				// unexpectedly, and the money that the renter paid to cover
+				// the fees is no longer enough.
+				return
+			}
+			txnSize := uint64(len(encoding.MarshalAll(so.RevisionTransactionSet)) + 300)
+			requiredFee := feeRecommendation.Mul64(txnSize)
+			submission, err := h.managedSubmitRevision(so, requiredFee)
+			if err != nil {
+				h.log.Println("Error submitting revision transaction to transaction pool", err)
+			} else {
+				so.RevisionSubmission = submission
+				so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+			}
 		}
-		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
-		// return
 	}
 
 	// Check whether a storage proof is ready to be provided, and whether it
@@ -756,80 +784,90 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 
-		// Get the index of the segment, and the index of the sector containing
-		// the segment.
-		segmentIndex, err := h.cs.StorageProofSegment(so.id())
-		if err != nil {
-			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
-			return
-		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
-		if err != nil {
-			h.log.Debugln(err)
-			return
+		// Queue another action item to check on the storage proof before the
+		// deadline, so that its fees can be raised if it is stuck.
+		if blockHeight+resubmissionTimeout < so.proofDeadline() {
+			h.mu.Lock()
+			err := h.queueActionItem(blockHeight+resubmissionTimeout, so.id())
+			h.mu.Unlock()
+			if err != nil {
+				h.log.Println("Error queuing action item:", err)
+			}
 		}
 
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+		// If the storage proof has already been submitted, resubmit it,
+		// raising its fees if the deadline is close.
+		if len(so.ProofSubmission.TransactionSet) > 0 {
+			var extraFees types.Currency
+			sp := submittedStorageProof(so.ProofSubmission)
+			rebuild := func(fee types.Currency) (submittedTransaction, error) {
+				return h.managedSubmitStorageProof(sp, fee)
+			}
+			so.ProofSubmission, extraFees = h.managedWatchSubmission(so, so.ProofSubmission, "storage proof", so.proofDeadline(), rebuild)
+			so.TransactionFeesAdded = so.TransactionFeesAdded.Add(extraFees)
+		} else {
+			// Get the index of the segment, and the index of the sector
+			// containing the segment.
+			segmentIndex, err := h.cs.StorageProofSegment(so.id())
+			if err != nil {
+				h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
+				return
+			}
+			sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+			// Pull the corresponding sector into memory.
+			sectorRoot := so.SectorRoots[sectorIndex]
+			sectorBytes, err := h.ReadSector(sectorRoot)
+			if err != nil {
+				h.log.Debugln(err)
+				return
+			}
 
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
-		// Create and build the transaction with the storage proof.
-		builder := h.wallet.StartTransaction()
-		_, feeRecommendation := h.tpool.FeeEstimation()
-		if so.value().Cmp(feeRecommendation) < 0 {
-			// There's no sense submitting the storage proof if the fee is more
-			// than the anticipated revenue.
-			h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
-			return
-		}
-		txnSize := uint64(len(encoding.Marshal(sp)) + 300)
-		requiredFee := feeRecommendation.Mul64(txnSize)
-		err = builder.FundSiacoins(requiredFee)
-		if err != nil {
-			h.log.Println("Host error when funding a storage proof transaction fee:", err)
-			return
-		}
-		builder.AddMinerFee(requiredFee)
-		builder.AddStorageProof(sp)
-		storageProofSet, err := builder.Sign(true)
-		if err != nil {
-			h.log.Println("Host error when signing the storage proof transaction:", err)
-			return
-		}
-		err = h.tpool.AcceptTransactionSet(storageProofSet)
-		if err != nil {
-			h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
-			return
-		}
-		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+			// Build the storage proof for just the sector.
+			sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+			base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
 
-		// Queue another action item to check whether the storage proof
-		// got confirmed.
-		h.mu.Lock()
-		err = h.queueActionItem(so.proofDeadline(), so.id())
-		h.mu.Unlock()
-		if err != nil {
-			h.log.Println("Error queuing action item:", err)
+			// Using the sector, build a cached root.
+			log2SectorSize := uint64(0)
+			for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+				log2SectorSize++
+			}
+			ct := crypto.NewCachedTree(log2SectorSize)
+			ct.SetIndex(segmentIndex)
+			for _, root := range so.SectorRoots {
+				ct.Push(root)
+			}
+			hashSet := ct.Prove(base, cachedHashSet)
+			sp := types.StorageProof{
+				ParentID: so.id(),
+				HashSet:  hashSet,
+			}
+			copy(sp.Segment[:], base)
+
+			// Create and build the transaction with the storage proof.
+			_, feeRecommendation := h.tpool.FeeEstimation()
+			if so.value().Cmp(feeRecommendation) < 0 {
+				// There's no sense submitting the storage proof if the fee is
+				// more than the anticipated revenue.
+				h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
+				return
+			}
+			txnSize := uint64(len(encoding.Marshal(sp)) + 300)
+			requiredFee := feeRecommendation.Mul64(txnSize)
+			so.ProofSubmission, err = h.managedSubmitStorageProof(sp, requiredFee)
+			if err != nil {
+				h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
+				return
+			}
+			so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+
+			// Queue another action item to check whether the storage proof
+			// got confirmed.
+			h.mu.Lock()
+			err = h.queueActionItem(so.proofDeadline(), so.id())
+			h.mu.Unlock()
+			if err != nil {
+				h.log.Println("Error queuing action item:", err)
+			}
 		}
 	}
 
@@ -870,7 +908,26 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
 			mso := modules.StorageObligation{
+				ObligationID:      so.id(),
 				NegotiationHeight: so.NegotiationHeight,
+				ExpirationHeight:  so.expiration(),
+				ProofDeadline:     so.proofDeadline(),
+				DataSize:          so.fileSize(),
+				SectorRootsCount:  uint64(len(so.SectorRoots)),
+
+				ContractCost:             so.ContractCost,
+				PotentialDownloadRevenue: so.PotentialDownloadRevenue,
+				PotentialStorageRevenue:  so.PotentialStorageRevenue,
+				PotentialUploadRevenue:   so.PotentialUploadRevenue,
+				LockedCollateral:         so.LockedCollateral,
+				RiskedCollateral:         so.RiskedCollateral,
+				TransactionFeesAdded:     so.TransactionFeesAdded,
+				FeeBumps:                 so.RevisionSubmission.Bumps + so.ProofSubmission.Bumps,
+
+				BytesUploaded:   so.BytesUploaded,
+				BytesDownloaded: so.BytesDownloaded,
+				RevisionCount:   so.RevisionCount,
+				RenterAddresses: so.RenterAddresses,
 
 				OriginConfirmed:     so.OriginConfirmed,
 				RevisionConstructed: so.RevisionConstructed,
@@ -878,6 +935,16 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 				ProofConstructed:    so.ProofConstructed,
 				ProofConfirmed:      so.ProofConfirmed,
 				ObligationStatus:    uint64(so.ObligationStatus),
+				Status:              so.ObligationStatus.String(),
+			}
+			if so.ObligationStatus == obligationSucceeded {
+				mso.RealizedRevenue = so.ContractCost.Add(so.PotentialDownloadRevenue).Add(so.PotentialStorageRevenue).Add(so.PotentialUploadRevenue)
+			}
+			for _, txn := range so.OriginTransactionSet {
+				mso.TransactionIDs = append(mso.TransactionIDs, txn.ID())
+			}
+			for _, txn := range so.RevisionTransactionSet {
+				mso.TransactionIDs = append(mso.TransactionIDs, txn.ID())
 			}
 			sos = append(sos, mso)
 			return nil
@@ -893,3 +960,41 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 
 	return sos
 }
+
+// ScrubStatus returns the progress of the storage manager's background
+// scrubber. The storage manager only knows the salted ids of the corrupt
+// sectors, so the host fills in the roots of the corrupt sectors that belong
+// to its storage obligations.
+func (h *Host) ScrubStatus() modules.StorageScrubStatus {
+	status := h.StorageManager.ScrubStatus()
+	if status.CorruptSectors == 0 {
+		return status
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	seen := make(map[crypto.Hash]struct{})
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			for _, root := range so.SectorRoots {
+				if _, exists := seen[root]; exists {
+					continue
+				}
+				seen[root] = struct{}{}
+				if h.StorageManager.SectorCorrupt(root) {
+					status.CorruptSectorRoots = append(status.CorruptSectorRoots, root)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide corrupt sector roots:", err))
+	}
+	return status
+}
@@ -193,6 +193,13 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 			}
 
+			// Remove the snapshot of the host's metrics taken at the reverted
+			// block.
+			err := h.deleteMetricsSnapshot(tx)
+			if err != nil {
+				h.log.Println("Could not remove a snapshot of the host metrics:", err)
+			}
+
 			// Height is not adjusted when dealing with the genesis block because
 			// the default height is 0 and the genesis block height is 0. If
 			// removing the genesis block, height will already be at height 0 and
@@ -271,6 +278,17 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 				h.blockHeight++
 			}
 
+			// Record a snapshot of the host's metrics if one is due. Snapshots
+			// are only taken once the host is synced, because the metrics
+			// held while syncing or rescanning describe the present rather
+			// than the block being processed.
+			if cc.Synced && h.blockHeight%h.metricsSnapshotInterval() == 0 {
+				err := h.putMetricsSnapshot(tx, block.Timestamp)
+				if err != nil {
+					h.log.Println("Could not record a snapshot of the host metrics:", err)
+				}
+			}
+
 			// Handle any action items relevant to the current height.
 			bai := tx.Bucket(bucketActionItems)
 			heightBytes := make([]byte, 8)
@@ -300,6 +318,22 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 		go h.threadedHandleActionItem(actionItems[i])
 	}
 
+	// Run the automatic pricing engine if an update is due. A height below
+	// the last update means that the host has been rescanning or has
+	// experienced a deep reorg, in which case the schedule is restarted.
+	if h.pricingSettings.Strategy != modules.HostPricingManual {
+		if h.blockHeight < h.lastPriceUpdate || h.blockHeight >= h.lastPriceUpdate+h.pricingSettings.UpdateInterval {
+			h.lastPriceUpdate = h.blockHeight
+			go h.threadedUpdatePrices()
+		}
+	}
+
+	// Run the storage proof readiness self-check if one is due.
+	if h.blockHeight < h.lastProofCheck || h.blockHeight >= h.lastProofCheck+proofCheckInterval {
+		h.lastProofCheck = h.blockHeight
+		go h.threadedCheckProofReadiness()
+	}
+
 	// Update the host's recent change pointer to point to the most recent
 	// change.
 	h.recentChange = cc.ID
@@ -66,6 +66,10 @@ const (
 	// data being requested.
 	NegotiateMaxDownloadActionRequestSize = 50e3
 
+	// NegotiateMaxSectorRootsPage is the maximum number of sector roots that
+	// the host will send in response to a single sector roots request.
+	NegotiateMaxSectorRootsPage = 1 << 16
+
 	// NegotiateMaxErrorSize indicates the maximum number of bytes that can be
 	// used to encode an error being sent during negotiation.
 	NegotiateMaxErrorSize = 256
@@ -156,6 +160,15 @@ var (
 	// contract.
 	RPCReviseContract = types.Specifier{'R', 'e', 'v', 'i', 's', 'e', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
+	// RPCSectorRoots is the specifier for requesting the sector roots of a
+	// file contract from the host.
+	RPCSectorRoots = types.Specifier{'S', 'e', 'c', 't', 'o', 'r', 'R', 'o', 'o', 't', 's'}
+
+	// RPCSecureSession is the specifier for upgrading a connection to an
+	// encrypted session. The RPC that the session is opened for follows the
+	// handshake, inside of the encrypted session.
+	RPCSecureSession = types.Specifier{'S', 'e', 'c', 'u', 'r', 'e', 'S', 'e', 's', 's', 'i', 'o', 'n'}
+
 	// RPCSettings is the specifier for requesting settings from the host.
 	RPCSettings = types.Specifier{'S', 'e', 't', 't', 'i', 'n', 'g', 's', 2}
 
@@ -183,6 +196,14 @@ type (
 		Length     uint64
 	}
 
+	// A SectorRootsRequest asks the host for a page of the sector roots of a
+	// file contract. NumRoots roots are requested, starting with the root at
+	// index Offset.
+	SectorRootsRequest struct {
+		Offset   uint64
+		NumRoots uint64
+	}
+
 	// HostAnnouncement is an announcement by the host that appears in the
 	// blockchain. 'Specifier' is always 'PrefixHostAnnouncement'. The
 	// announcement is always followed by a signature from the public key of
@@ -277,7 +298,8 @@ type (
 // ReadNegotiationAcceptance reads an accept/reject response from r (usually a
 // net.Conn). If the response is not AcceptResponse, ReadNegotiationAcceptance
 // returns the response as an error. If the response is StopResponse,
-// ErrStopResponse is returned, allowing for direct error comparison.
+// ErrStopResponse is returned, allowing for direct error comparison. If the
+// response is a rejection with an error code, a *NegotiationError is returned.
 //
 // Note that since errors returned by ReadNegotiationAcceptance are newly
 // allocated, they cannot be compared to other errors in the traditional
@@ -294,7 +316,7 @@ func ReadNegotiationAcceptance(r io.Reader) error {
 	case StopResponse:
 		return ErrStopResponse
 	default:
-		return errors.New(resp)
+		return decodeNegotiationError(resp)
 	}
 }
 
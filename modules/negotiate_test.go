@@ -2,9 +2,12 @@ package modules
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -125,3 +128,40 @@ func TestNegotiationResponses(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestNegotiationErrors checks that rejections with error codes are read back
+// as a *NegotiationError, and that the message is preserved.
+func TestNegotiationErrors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := WriteNegotiationError(buf, NegotiationErrorPrice, ErrLowBalance)
+	if err != ErrLowBalance {
+		t.Fatal(err)
+	}
+	err = ReadNegotiationAcceptance(buf)
+	ne, ok := err.(*NegotiationError)
+	if !ok {
+		t.Fatal("expected a *NegotiationError, got", err)
+	}
+	if ne.Code != NegotiationErrorPrice || ne.Error() != ErrLowBalance.Error() {
+		t.Fatal("rejection has the wrong code or message:", ne.Code, ne.Error())
+	}
+
+	// Long messages are truncated so that they can still be read.
+	buf.Reset()
+	WriteNegotiationError(buf, NegotiationErrorStorage, errors.New(strings.Repeat("a", NegotiateMaxErrorSize)))
+	err = ReadNegotiationAcceptance(buf)
+	if ne, ok := err.(*NegotiationError); !ok || ne.Code != NegotiationErrorStorage {
+		t.Fatal("long rejection was not read correctly:", err)
+	}
+
+	// Rejections without a code, or with a malformed code, are returned as
+	// plain errors.
+	for _, s := range []string{"price too low", negotiationErrorPrefix + "x: price too low", negotiationErrorPrefix + "3"} {
+		buf.Reset()
+		encoding.WriteObject(buf, s)
+		err = ReadNegotiationAcceptance(buf)
+		if _, ok := err.(*NegotiationError); ok || err.Error() != s {
+			t.Fatal("uncoded rejection was not read correctly:", err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+)
+
+// negotiateerrors.go contains the structured errors that a host sends to a
+// renter when it rejects a negotiation. The error is sent in the same string
+// form as any other rejection, prefixed with a numeric code, so that older
+// renters still receive a readable message while newer renters can tell why
+// the negotiation was rejected.
+
+// NegotiationErrorCode identifies the reason that a host rejected a
+// negotiation.
+type NegotiationErrorCode uint16
+
+const (
+	// NegotiationErrorUnknown is used for rejections that have no code,
+	// including all rejections sent by hosts that predate error codes.
+	NegotiationErrorUnknown NegotiationErrorCode = iota
+
+	// NegotiationErrorBadContract indicates that the renter proposed a
+	// contract or revision which does not meet the host's requirements.
+	NegotiationErrorBadContract
+
+	// NegotiationErrorPrice indicates that the renter offered to pay less
+	// than the host's prices.
+	NegotiationErrorPrice
+
+	// NegotiationErrorCollateral indicates that the host cannot put up the
+	// collateral required by the contract.
+	NegotiationErrorCollateral
+
+	// NegotiationErrorStorage indicates that the host does not have enough
+	// storage remaining.
+	NegotiationErrorStorage
+
+	// NegotiationErrorDenied indicates that the renter is on the host's
+	// denylist.
+	NegotiationErrorDenied
+
+	// NegotiationErrorRevision indicates that the renter's revision does not
+	// follow from the host's most recent revision of the contract.
+	NegotiationErrorRevision
+
+	// NegotiationErrorInternal indicates that the host ran into an internal
+	// error.
+	NegotiationErrorInternal
+
+	// NegotiationErrorPolicy indicates that the contract was refused by the
+	// host's contract acceptance policy.
+	NegotiationErrorPolicy
+)
+
+// negotiationErrorPrefix precedes the code of a structured rejection.
+const negotiationErrorPrefix = "rejection code "
+
+// NegotiationError is a rejection sent by a host, carrying the code of the
+// reason for the rejection. Error returns the message of the rejection
+// without the code.
+type NegotiationError struct {
+	Code    NegotiationErrorCode
+	Message string
+}
+
+// Error implements the error interface.
+func (ne *NegotiationError) Error() string {
+	return ne.Message
+}
+
+// String returns a short description of the code.
+func (c NegotiationErrorCode) String() string {
+	switch c {
+	case NegotiationErrorBadContract:
+		return "bad contract"
+	case NegotiationErrorPrice:
+		return "price too low"
+	case NegotiationErrorCollateral:
+		return "out of collateral"
+	case NegotiationErrorStorage:
+		return "host full"
+	case NegotiationErrorDenied:
+		return "renter denied"
+	case NegotiationErrorRevision:
+		return "revision mismatch"
+	case NegotiationErrorInternal:
+		return "internal host error"
+	case NegotiationErrorPolicy:
+		return "refused by host policy"
+	default:
+		return "unknown"
+	}
+}
+
+// encodeNegotiationError returns the string form of a structured rejection,
+// truncating the message so that the rejection does not exceed
+// NegotiateMaxErrorSize.
+func encodeNegotiationError(code NegotiationErrorCode, msg string) string {
+	s := fmt.Sprintf("%v%d: %v", negotiationErrorPrefix, code, msg)
+	// The encoded string is prefixed by its 8 byte length.
+	if len(s) > NegotiateMaxErrorSize-8 {
+		s = s[:NegotiateMaxErrorSize-8]
+	}
+	return s
+}
+
+// decodeNegotiationError turns a rejection read from a host into an error. If
+// the rejection has a code, a *NegotiationError is returned.
+func decodeNegotiationError(s string) error {
+	if !strings.HasPrefix(s, negotiationErrorPrefix) {
+		return errors.New(s)
+	}
+	i := strings.Index(s, ": ")
+	if i == -1 {
+		return errors.New(s)
+	}
+	code, err := strconv.ParseUint(s[len(negotiationErrorPrefix):i], 10, 16)
+	if err != nil {
+		return errors.New(s)
+	}
+	return &NegotiationError{
+		Code:    NegotiationErrorCode(code),
+		Message: s[i+2:],
+	}
+}
+
+// WriteNegotiationError writes a rejection response with the provided code to
+// w (usually a net.Conn) and returns the input error. If the write fails, the
+// write error is joined with the input error.
+func WriteNegotiationError(w io.Writer, code NegotiationErrorCode, err error) error {
+	writeErr := encoding.WriteObject(w, encodeNegotiationError(code, err.Error()))
+	if writeErr != nil {
+		return build.JoinErrors([]error{err, writeErr}, "; ")
+	}
+	return err
+}
@@ -0,0 +1,314 @@
+package modules
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+// negotiatesession.go contains the handshake that upgrades a connection
+// between a renter and a host to an encrypted session. The renter sends
+// RPCSecureSession followed by a SecureSessionRequest holding an ephemeral
+// X25519 key and the highest handshake version it supports. The host accepts
+// or rejects the request, and then sends a SecureSessionResponse holding the
+// negotiated version, its own ephemeral key, and a signature over the
+// handshake by the host's public key. Both sides derive a key for each
+// direction from the shared secret, and everything that follows, starting
+// with the specifier of the actual RPC, is sent in authenticated and
+// encrypted frames.
+
+const (
+	// SecureSessionHostVersion is the first host version that supports
+	// encrypted sessions. Renters only attempt an encrypted session with
+	// hosts of this version or later.
+	SecureSessionHostVersion = "1.3.2"
+
+	// SecureSessionVersion is the highest version of the secure session
+	// handshake that is supported.
+	SecureSessionVersion = 1
+
+	// secureFrameSize is the maximum number of plaintext bytes in a single
+	// encrypted frame.
+	secureFrameSize = 1 << 16
+)
+
+var (
+	// ErrSecureSessionUnsupported is returned if the host closes the
+	// connection instead of responding to the secure session handshake,
+	// indicating that it does not support encrypted sessions. Renters only
+	// send the handshake to hosts whose signed settings advertise support, so
+	// the connection is treated as failed.
+	ErrSecureSessionUnsupported = errors.New("host does not support encrypted sessions")
+
+	// errBadSessionSignature is returned if the host's signature over the
+	// handshake does not match the host's public key.
+	errBadSessionSignature = errors.New("host's secure session signature is invalid")
+
+	// errBadSessionKey is returned if an ephemeral key in the handshake
+	// results in a degenerate shared secret.
+	errBadSessionKey = errors.New("secure session handshake used an invalid key")
+
+	// errBadSessionVersion is returned if the handshake versions of the
+	// renter and the host are incompatible.
+	errBadSessionVersion = errors.New("unsupported secure session version")
+
+	// errSessionFrame is returned if an encrypted frame is too large or fails
+	// authentication.
+	errSessionFrame = errors.New("received a corrupt secure session frame")
+)
+
+type (
+	// SecureSessionRequest is sent by the renter to open an encrypted
+	// session. Version is the highest handshake version that the renter
+	// supports.
+	SecureSessionRequest struct {
+		Version      uint64
+		EphemeralKey [32]byte
+	}
+
+	// SecureSessionResponse is sent by the host after accepting a
+	// SecureSessionRequest. The signature covers the handshake, binding the
+	// session to the host's public key.
+	SecureSessionResponse struct {
+		Version      uint64
+		EphemeralKey [32]byte
+		Signature    crypto.Signature
+	}
+
+	// secureConn is a net.Conn that encrypts everything written to it and
+	// decrypts everything read from it. Each direction uses its own key, and
+	// frames are numbered by a counter that is used as the nonce.
+	secureConn struct {
+		net.Conn
+
+		readAEAD  cipher.AEAD
+		readBuf   []byte
+		readNonce uint64
+		readMu    sync.Mutex
+
+		writeAEAD  cipher.AEAD
+		writeNonce uint64
+		writeMu    sync.Mutex
+	}
+)
+
+// frameNonce returns the nonce of the frame with the provided counter.
+func frameNonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce, counter)
+	return nonce
+}
+
+// Read reads and decrypts data from the session.
+func (sc *secureConn) Read(b []byte) (int, error) {
+	sc.readMu.Lock()
+	defer sc.readMu.Unlock()
+
+	if len(sc.readBuf) == 0 {
+		var header [4]byte
+		if _, err := io.ReadFull(sc.Conn, header[:]); err != nil {
+			return 0, err
+		}
+		frameLen := binary.LittleEndian.Uint32(header[:])
+		if frameLen > secureFrameSize+uint32(sc.readAEAD.Overhead()) {
+			return 0, errSessionFrame
+		}
+		frame := make([]byte, frameLen)
+		if _, err := io.ReadFull(sc.Conn, frame); err != nil {
+			return 0, err
+		}
+		plaintext, err := sc.readAEAD.Open(frame[:0], frameNonce(sc.readNonce), frame, nil)
+		if err != nil {
+			return 0, errSessionFrame
+		}
+		sc.readNonce++
+		sc.readBuf = plaintext
+	}
+	n := copy(b, sc.readBuf)
+	sc.readBuf = sc.readBuf[n:]
+	return n, nil
+}
+
+// Write encrypts data and writes it to the session.
+func (sc *secureConn) Write(b []byte) (int, error) {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+
+	var written int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > secureFrameSize {
+			chunk = chunk[:secureFrameSize]
+		}
+		frame := make([]byte, 4, 4+len(chunk)+sc.writeAEAD.Overhead())
+		frame = sc.writeAEAD.Seal(frame, frameNonce(sc.writeNonce), chunk, nil)
+		binary.LittleEndian.PutUint32(frame[:4], uint32(len(frame)-4))
+		if _, err := sc.Conn.Write(frame); err != nil {
+			return written, err
+		}
+		sc.writeNonce++
+		written += len(chunk)
+		b = b[len(chunk):]
+	}
+	return written, nil
+}
+
+// generateSessionKey returns a new ephemeral X25519 key pair.
+func generateSessionKey() (private, public [32]byte) {
+	fastrand.Read(private[:])
+	curve25519.ScalarBaseMult(&public, &private)
+	return
+}
+
+// newSecureConn derives the session keys from the handshake and wraps conn
+// in an encrypted session.
+func newSecureConn(conn net.Conn, private, peer [32]byte, handshake crypto.Hash, renter bool) (net.Conn, error) {
+	var shared [32]byte
+	curve25519.ScalarMult(&shared, &private, &peer)
+	if shared == ([32]byte{}) {
+		return nil, errBadSessionKey
+	}
+	renterKey := crypto.HashAll(shared, handshake, "renter")
+	hostKey := crypto.HashAll(shared, handshake, "host")
+	// NOTE: chacha20poly1305.New only returns an error if the key has the
+	// wrong size.
+	renterAEAD, _ := chacha20poly1305.New(renterKey[:])
+	hostAEAD, _ := chacha20poly1305.New(hostKey[:])
+	if renter {
+		return &secureConn{Conn: conn, readAEAD: hostAEAD, writeAEAD: renterAEAD}, nil
+	}
+	return &secureConn{Conn: conn, readAEAD: renterAEAD, writeAEAD: hostAEAD}, nil
+}
+
+// sessionHandshakeHash returns the hash that the host signs to bind the
+// session to its public key.
+func sessionHandshakeHash(version uint64, renterKey, hostKey [32]byte) crypto.Hash {
+	return crypto.HashAll(RPCSecureSession, version, renterKey, hostKey)
+}
+
+// NewHostSecureSession performs the host side of the secure session
+// handshake, after RPCSecureSession has been read from the connection. The
+// returned connection encrypts everything sent through it.
+func NewHostSecureSession(conn net.Conn, sk crypto.SecretKey) (net.Conn, error) {
+	var req SecureSessionRequest
+	err := encoding.ReadObject(conn, &req, 256)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version < 1 {
+		return nil, WriteNegotiationRejection(conn, errBadSessionVersion)
+	}
+	err = WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	version := req.Version
+	if version > SecureSessionVersion {
+		version = SecureSessionVersion
+	}
+	private, public := generateSessionKey()
+	handshake := sessionHandshakeHash(version, req.EphemeralKey, public)
+	err = encoding.WriteObject(conn, SecureSessionResponse{
+		Version:      version,
+		EphemeralKey: public,
+		Signature:    crypto.SignHash(handshake, sk),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSecureConn(conn, private, req.EphemeralKey, handshake, false)
+}
+
+// NewRenterSecureSession performs the renter side of the secure session
+// handshake, starting with sending RPCSecureSession. The host's signature
+// over the handshake is checked against hostKey. ErrSecureSessionUnsupported
+// is returned if the host closes the connection instead of responding.
+func NewRenterSecureSession(conn net.Conn, hostKey types.SiaPublicKey) (net.Conn, error) {
+	if hostKey.Algorithm != types.SignatureEd25519 || len(hostKey.Key) != len(crypto.PublicKey{}) {
+		return nil, errors.New("host key does not support secure sessions")
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], hostKey.Key)
+
+	// Hosts that do not support secure sessions close the connection after
+	// reading the unknown specifier.
+	unsupported := func(err error) error {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrSecureSessionUnsupported
+		}
+		if netErr, ok := err.(net.Error); ok && !netErr.Timeout() {
+			return ErrSecureSessionUnsupported
+		}
+		return err
+	}
+
+	private, public := generateSessionKey()
+	err := encoding.WriteObject(conn, RPCSecureSession)
+	if err != nil {
+		return nil, unsupported(err)
+	}
+	err = encoding.WriteObject(conn, SecureSessionRequest{
+		Version:      SecureSessionVersion,
+		EphemeralKey: public,
+	})
+	if err != nil {
+		return nil, unsupported(err)
+	}
+	err = ReadNegotiationAcceptance(conn)
+	if err != nil {
+		return nil, unsupported(err)
+	}
+
+	var resp SecureSessionResponse
+	err = encoding.ReadObject(conn, &resp, 256)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Version < 1 || resp.Version > SecureSessionVersion {
+		return nil, errBadSessionVersion
+	}
+	handshake := sessionHandshakeHash(resp.Version, public, resp.EphemeralKey)
+	if crypto.VerifyHash(handshake, pk, resp.Signature) != nil {
+		return nil, errBadSessionSignature
+	}
+	return newSecureConn(conn, private, resp.EphemeralKey, handshake, true)
+}
+
+// DialHostSession dials a host and, if the host's version supports it, opens
+// an encrypted session with the host. The version must come from the host's
+// signed settings, so that it cannot be lowered by an attacker. Hosts whose
+// version predates encrypted sessions are sent plaintext RPCs. For any other
+// host a failed handshake is returned as an error, and the renter never falls
+// back to a plaintext connection, because an attacker could otherwise force
+// the fallback by interrupting the handshake.
+func DialHostSession(dialer *net.Dialer, address NetAddress, hostKey types.SiaPublicKey, version string) (net.Conn, error) {
+	conn, err := dialer.Dial("tcp", string(address))
+	if err != nil {
+		return nil, err
+	}
+	if build.VersionCmp(version, SecureSessionHostVersion) < 0 {
+		return conn, nil
+	}
+
+	conn.SetDeadline(time.Now().Add(NegotiateSettingsTime))
+	sconn, err := NewRenterSecureSession(conn, hostKey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return sconn, nil
+}
@@ -0,0 +1,216 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// hostSession reads the specifier from conn and performs the host side of the
+// secure session handshake.
+func hostSession(conn net.Conn, sk crypto.SecretKey) (net.Conn, error) {
+	var id types.Specifier
+	err := encoding.ReadObject(conn, &id, uint64(len(id)))
+	if err != nil {
+		return nil, err
+	}
+	if id != RPCSecureSession {
+		return nil, errBadSessionVersion
+	}
+	return NewHostSecureSession(conn, sk)
+}
+
+// TestSecureSession checks that data sent through a secure session arrives
+// intact in both directions, and is encrypted on the wire.
+func TestSecureSession(t *testing.T) {
+	t.Parallel()
+	sk, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+
+	renterPipe, hostPipe := net.Pipe()
+	defer renterPipe.Close()
+	defer hostPipe.Close()
+
+	// Record everything the renter sends.
+	renterConn := &recordingConn{Conn: renterPipe}
+	hostDone := make(chan error)
+	var hconn net.Conn
+	go func() {
+		var err error
+		hconn, err = hostSession(hostPipe, sk)
+		hostDone <- err
+	}()
+	rconn, err := NewRenterSecureSession(renterConn, hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostDone; err != nil {
+		t.Fatal(err)
+	}
+
+	// Send enough data to span several frames from the renter to the host.
+	data := fastrand.Bytes(secureFrameSize*2 + 100)
+	go func() {
+		_, err := rconn.Write(data)
+		hostDone <- err
+	}()
+	received := make([]byte, len(data))
+	if _, err := io.ReadFull(hconn, received); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostDone; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, data) {
+		t.Fatal("host received the wrong data")
+	}
+	if bytes.Contains(renterConn.written.Bytes(), data[:64]) {
+		t.Fatal("data was sent in plaintext")
+	}
+
+	// Send a reply from the host to the renter.
+	reply := []byte("reply")
+	go func() {
+		_, err := hconn.Write(reply)
+		hostDone <- err
+	}()
+	received = make([]byte, len(reply))
+	if _, err := io.ReadFull(rconn, received); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostDone; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, reply) {
+		t.Fatal("renter received the wrong data")
+	}
+}
+
+// recordingConn is a net.Conn that records everything written to it.
+type recordingConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+// Write records b and writes it to the underlying connection.
+func (rc *recordingConn) Write(b []byte) (int, error) {
+	rc.written.Write(b)
+	return rc.Conn.Write(b)
+}
+
+// TestSecureSessionWrongKey checks that the renter rejects a session with a
+// host that does not hold the expected key.
+func TestSecureSessionWrongKey(t *testing.T) {
+	t.Parallel()
+	sk, _ := crypto.GenerateKeyPair()
+	_, otherPK := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       otherPK[:],
+	}
+
+	renterConn, hostConn := net.Pipe()
+	defer renterConn.Close()
+	defer hostConn.Close()
+	go hostSession(hostConn, sk)
+	_, err := NewRenterSecureSession(renterConn, hostKey)
+	if err != errBadSessionSignature {
+		t.Fatal("expected errBadSessionSignature, got", err)
+	}
+}
+
+// TestSecureSessionUnsupported checks that a host which closes the connection
+// after receiving the handshake is reported as not supporting secure
+// sessions.
+func TestSecureSessionUnsupported(t *testing.T) {
+	t.Parallel()
+	_, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// Older hosts read the unknown specifier and close the connection.
+		hostConn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		var id types.Specifier
+		encoding.ReadObject(hostConn, &id, uint64(len(id)))
+		hostConn.Close()
+	}()
+	renterConn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer renterConn.Close()
+	_, err = NewRenterSecureSession(renterConn, hostKey)
+	if err != ErrSecureSessionUnsupported {
+		t.Fatal("expected ErrSecureSessionUnsupported, got", err)
+	}
+}
+
+// TestDialHostSessionNoDowngrade checks that DialHostSession does not fall
+// back to a plaintext connection if a host which advertises encrypted
+// sessions does not complete the handshake, but still connects to older hosts
+// without encryption.
+func TestDialHostSessionNoDowngrade(t *testing.T) {
+	t.Parallel()
+	_, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// Close every connection after reading the specifier, like a host
+		// that does not support secure sessions, or an attacker that
+		// interrupts the handshake.
+		for {
+			hostConn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var id types.Specifier
+			encoding.ReadObject(hostConn, &id, uint64(len(id)))
+			hostConn.Close()
+		}
+	}()
+	address := NetAddress(l.Addr().String())
+
+	// Hosts built from this tree report build.Version, which must be new
+	// enough to require a secure session.
+	conn, err := DialHostSession(new(net.Dialer), address, hostKey, build.Version)
+	if err != ErrSecureSessionUnsupported {
+		if err == nil {
+			conn.Close()
+		}
+		t.Fatal("expected ErrSecureSessionUnsupported, got", err)
+	}
+	conn, err = DialHostSession(new(net.Dialer), address, hostKey, "1.3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
@@ -16,6 +16,82 @@ const (
 	RenterDir = "renter"
 )
 
+var (
+	// PrefixFileContractIdentifier is used to indicate that a transaction's
+	// Arbitrary Data field contains a file contract identifier. The data is
+	// prefixed by PrefixNonSia, followed by this prefix and the identifier.
+	PrefixFileContractIdentifier = types.Specifier{'F', 'C', 'I', 'd', 'e', 'n', 't', 'i', 'f', 'i', 'e', 'r'}
+
+	// Specifiers used to derive the various renter keys from a RenterSeed.
+	renterSeedSpecifier         = types.Specifier{'r', 'e', 'n', 't', 'e', 'r'}
+	contractKeySpecifier        = types.Specifier{'c', 'o', 'n', 't', 'r', 'a', 'c', 't', 'k', 'e', 'y'}
+	contractIdentifierSpecifier = types.Specifier{'c', 'o', 'n', 't', 'r', 'a', 'c', 't', 'i', 'd'}
+)
+
+type (
+	// A RenterSeed is derived from the wallet's primary seed. The renter's
+	// contract keys are derived from it, so that contracts can be recovered
+	// using only the wallet seed.
+	RenterSeed [crypto.EntropySize]byte
+
+	// A ContractIdentifier is placed in the arbitrary data of a transaction
+	// containing a file contract. It allows the renter that formed the
+	// contract to find it on the blockchain, without revealing the renter to
+	// anyone else.
+	ContractIdentifier [crypto.HashSize]byte
+
+	// A KeyNonce is a random value that is mixed into a contract key derived
+	// from the renter seed. Each contract has its own nonce, so that no two
+	// contracts share a key.
+	KeyNonce [16]byte
+)
+
+// DeriveRenterSeed derives the renter seed from a wallet seed.
+func DeriveRenterSeed(walletSeed Seed) RenterSeed {
+	return RenterSeed(crypto.HashAll(walletSeed, renterSeedSpecifier))
+}
+
+// ContractKeyPair returns the keypair that the renter uses for the contract
+// with the specified host and nonce.
+func (rs RenterSeed) ContractKeyPair(hostKey types.SiaPublicKey, nonce KeyNonce) (crypto.SecretKey, crypto.PublicKey) {
+	return crypto.GenerateKeyPairDeterministic(crypto.HashAll(rs, contractKeySpecifier, hostKey, nonce))
+}
+
+// ContractIdentifier returns the identifier for a file contract with the
+// provided unlock hash.
+func (rs RenterSeed) ContractIdentifier(unlockHash types.UnlockHash) ContractIdentifier {
+	return ContractIdentifier(crypto.HashAll(rs, contractIdentifierSpecifier, unlockHash))
+}
+
+// ArbitraryData returns the identifier and the nonce of the contract key
+// encoded as arbitrary data that can be added to a transaction. The nonce is
+// needed to derive the contract key again during recovery.
+func (ci ContractIdentifier) ArbitraryData(nonce KeyNonce) []byte {
+	data := make([]byte, 0, 2*types.SpecifierLen+len(ci)+len(nonce))
+	data = append(data, PrefixNonSia[:]...)
+	data = append(data, PrefixFileContractIdentifier[:]...)
+	data = append(data, ci[:]...)
+	return append(data, nonce[:]...)
+}
+
+// ParseContractIdentifier decodes a contract identifier and the nonce of the
+// contract key from the arbitrary data of a transaction. False is returned if
+// the data does not contain an identifier.
+func ParseContractIdentifier(data []byte) (ci ContractIdentifier, nonce KeyNonce, ok bool) {
+	if len(data) != 2*types.SpecifierLen+len(ci)+len(nonce) {
+		return ContractIdentifier{}, KeyNonce{}, false
+	}
+	var prefix, subPrefix types.Specifier
+	copy(prefix[:], data)
+	copy(subPrefix[:], data[types.SpecifierLen:])
+	if prefix != PrefixNonSia || subPrefix != PrefixFileContractIdentifier {
+		return ContractIdentifier{}, KeyNonce{}, false
+	}
+	copy(ci[:], data[2*types.SpecifierLen:])
+	copy(nonce[:], data[2*types.SpecifierLen+len(ci):])
+	return ci, nonce, true
+}
+
 // An ErasureCoder is an error-correcting encoder and decoder.
 type ErasureCoder interface {
 	// NumPieces is the number of pieces returned by Encode.
@@ -157,6 +233,11 @@ type RenterPriceEstimation struct {
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
 	Allowance Allowance `json:"allowance"`
+
+	// DownloadOverdrive is the number of extra pieces that are requested
+	// for each chunk during a download. The first pieces to arrive are used
+	// to recover the chunk and the remaining requests are cancelled.
+	DownloadOverdrive int `json:"downloadoverdrive"`
 }
 
 // HostDBScans represents a sortable slice of scans.
@@ -217,6 +298,11 @@ type RenterContract struct {
 	SecretKey       crypto.SecretKey           `json:"secretkey"`
 	StartHeight     types.BlockHeight          `json:"startheight"`
 
+	// KeyNonce is the nonce that was used to derive SecretKey from the renter
+	// seed. Renewals keep the key, so the nonce is carried over to the
+	// renewed contract.
+	KeyNonce KeyNonce `json:"keynonce"`
+
 	DownloadSpending types.Currency `json:"downloadspending"`
 	StorageSpending  types.Currency `json:"storagespending"`
 	UploadSpending   types.Currency `json:"uploadspending"`
@@ -224,7 +310,8 @@ type RenterContract struct {
 	// TotalCost indicates the amount of money that the renter spent and/or
 	// locked up while forming a contract. This includes fees, and includes
 	// funds which were allocated (but not necessarily committed) to spend on
-	// uploads/downloads/storage.
+	// uploads/downloads/storage. The TotalCost of a contract that was
+	// recovered from the renter seed is unknown, and is left at zero.
 	//
 	// ContractFee is the amount of money paid to the host to cover potential
 	// future transaction fees that the host may incur, and to cover any other
@@ -316,10 +403,19 @@ type Renter interface {
 	// renter.
 	LoadSharedFilesAscii(asciiSia string) ([]string, error)
 
+	// InitRecoveryScan starts scanning the blockchain for contracts that
+	// were formed using keys derived from the wallet seed, recovering any
+	// unexpired contracts that the renter does not know about.
+	InitRecoveryScan() error
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation() RenterPriceEstimation
 
+	// RecoveryScanStatus returns whether a recovery scan is in progress, and
+	// the height that the scan has reached.
+	RecoveryScanStatus() (bool, types.BlockHeight)
+
 	// RenameFile changes the path of a file.
 	RenameFile(path, newPath string) error
 
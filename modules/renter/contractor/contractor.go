@@ -54,6 +54,12 @@ type Contractor struct {
 	currentPeriod types.BlockHeight
 	lastChange    modules.ConsensusChangeID
 
+	// recoveryScanInProgress and recoveryScannedHeight track the progress of
+	// the recovery scan, which searches the blockchain for contracts formed
+	// with keys derived from the renter seed.
+	recoveryScanInProgress bool
+	recoveryScannedHeight  types.BlockHeight
+
 	downloaders map[types.FileContractID]*hostDownloader
 	editors     map[types.FileContractID]*hostEditor
 	renewing    map[types.FileContractID]bool // prevent revising during renewal
@@ -24,6 +24,7 @@ func (newStub) Unsubscribe(modules.ConsensusSetSubscriber) { return }
 
 // wallet stubs
 func (newStub) NextAddress() (uc types.UnlockConditions, err error) { return }
+func (newStub) PrimarySeed() (modules.Seed, uint64, error)          { return modules.Seed{}, 0, nil }
 func (newStub) StartTransaction() modules.TransactionBuilder        { return nil }
 
 // transaction pool stubs
@@ -494,6 +495,9 @@ func (ws *testWalletShim) NextAddress() (types.UnlockConditions, error) {
 	ws.nextAddressCalled = true
 	return types.UnlockConditions{}, nil
 }
+func (ws *testWalletShim) PrimarySeed() (modules.Seed, uint64, error) {
+	return modules.Seed{}, 0, nil
+}
 func (ws *testWalletShim) StartTransaction() modules.TransactionBuilder {
 	ws.startTxnCalled = true
 	return nil
@@ -14,6 +14,7 @@ import (
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/renter/proto"
 	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
 )
 
 var (
@@ -197,6 +198,16 @@ func (c *Contractor) managedMarkContractsUtility() {
 	c.mu.Unlock()
 }
 
+// contractCost returns the TotalCost of a contract. The TotalCost of a
+// recovered contract is unknown, in which case the renter's initial payout is
+// used as an estimate.
+func contractCost(contract modules.RenterContract) types.Currency {
+	if contract.TotalCost.IsZero() && len(contract.FileContract.ValidProofOutputs) > 0 {
+		return contract.FileContract.ValidProofOutputs[0].Value
+	}
+	return contract.TotalCost
+}
+
 // managedNewContract negotiates an initial file contract with the specified
 // host, saves it, and returns it.
 func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (modules.RenterContract, error) {
@@ -215,6 +226,17 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		return modules.RenterContract{}, err
 	}
 
+	// derive the contract key from the renter seed, and mark the contract so
+	// that it can be recovered using only the wallet seed
+	rs, err := c.RenterSeed()
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	var nonce modules.KeyNonce
+	fastrand.Read(nonce[:])
+	sk, pk := rs.ContractKeyPair(host.PublicKey, nonce)
+	identifier := rs.ContractIdentifier(contractUnlockHash(pk, host.PublicKey))
+
 	// create contract params
 	c.mu.RLock()
 	params := proto.ContractParams{
@@ -223,6 +245,8 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		StartHeight:   c.blockHeight,
 		EndHeight:     endHeight,
 		RefundAddress: uc.UnlockHash(),
+		SecretKey:     sk,
+		Identifier:    identifier.ArbitraryData(nonce),
 	}
 	c.mu.RUnlock()
 
@@ -235,6 +259,8 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		return modules.RenterContract{}, err
 	}
 
+	contract.KeyNonce = nonce
+
 	contractValue := contract.RenterFunds()
 	c.log.Printf("Formed contract with %v for %v", host.NetAddress, contractValue.HumanString())
 	return contract, nil
@@ -275,6 +301,14 @@ func (c *Contractor) managedRenew(contract modules.RenterContract, contractFundi
 	}
 	c.mu.RUnlock()
 
+	// mark the renewed contract as recoverable if its key was derived from
+	// the renter seed; contracts formed with random keys cannot be recovered
+	if rs, err := c.RenterSeed(); err == nil {
+		if sk, pk := rs.ContractKeyPair(host.PublicKey, contract.KeyNonce); sk == contract.SecretKey {
+			params.Identifier = rs.ContractIdentifier(contractUnlockHash(pk, host.PublicKey)).ArbitraryData(contract.KeyNonce)
+		}
+	}
+
 	// execute negotiation protocol
 	txnBuilder := c.wallet.StartTransaction()
 	newContract, err := proto.Renew(contract, params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
@@ -300,6 +334,7 @@ func (c *Contractor) managedRenew(contract modules.RenterContract, contractFundi
 		txnBuilder.Drop() // return unused outputs to wallet
 		return modules.RenterContract{}, err
 	}
+	newContract.KeyNonce = contract.KeyNonce
 
 	return newContract, nil
 }
@@ -376,7 +411,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		var fundsUsed types.Currency
 		for _, contract := range c.contracts {
 			// Calculate the cost of the contract line.
-			contractLineCost := contract.TotalCost
+			contractLineCost := contractCost(contract)
 			for _, pre := range contract.PreviousContracts {
 				contractLineCost = contractLineCost.Add(pre.TotalCost)
 			}
@@ -433,7 +468,7 @@ func (c *Contractor) threadedContractMaintenance() {
 				// cycle. This is calculated by starting with the total cost and
 				// subtracting out all of the fees, and then all of the unused
 				// money that was allocated (the RenterFunds()).
-				renewAmount := contract.TotalCost.Sub(contract.ContractFee).Sub(contract.TxnFee).Sub(contract.SiafundFee).Sub(contract.RenterFunds())
+				renewAmount := contractCost(contract).Sub(contract.ContractFee).Sub(contract.TxnFee).Sub(contract.SiafundFee).Sub(contract.RenterFunds())
 				for _, pre := range contract.PreviousContracts {
 					renewAmount = renewAmount.Add(pre.TotalCost).Sub(pre.ContractFee).Sub(pre.TxnFee).Sub(pre.SiafundFee).Sub(contract.RenterFunds())
 				}
@@ -479,12 +514,12 @@ func (c *Contractor) threadedContractMaintenance() {
 				sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
 				sectorBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
 				sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
-				percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds().Big(), contract.TotalCost.Big()).Float64()
+				percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds().Big(), contractCost(contract).Big()).Float64()
 				if contract.RenterFunds().Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < minContractFundRenewalThreshold {
 					// This contract does need to be refreshed. Make sure there
 					// are enough funds available to perform the refresh, and
 					// then execute.
-					refreshAmount := contract.TotalCost.Mul64(2)
+					refreshAmount := contractCost(contract).Mul64(2)
 					if refreshAmount.Cmp(fundsAvailable) < 0 {
 						refreshSet[contract.ID] = struct{}{}
 						renewSet = append(renewSet, renewal{
@@ -544,7 +579,10 @@ func (c *Contractor) threadedContractMaintenance() {
 
 			// Create the new contract.
 			newContract, err := c.managedRenew(oldContract, amount, endHeight)
-			if err != nil {
+			if code, ok := proto.RejectionCode(err); ok {
+				c.log.Printf("WARN: %v rejected the renewal of contract %v (%v): %v\n", oldContract.NetAddress, id, code, err)
+				return
+			} else if err != nil {
 				c.log.Printf("WARN: failed to renew contract %v with %v: %v\n", id, oldContract.NetAddress, err)
 				return
 			}
@@ -645,7 +683,10 @@ func (c *Contractor) threadedContractMaintenance() {
 
 		// Attempt forming a contract with this host.
 		newContract, err := c.managedNewContract(host, initialContractFunds, endHeight)
-		if err != nil {
+		if code, ok := proto.RejectionCode(err); ok {
+			c.log.Printf("Attempted to form a contract with %v, but the host rejected it (%v): %v\n", host.NetAddress, code, err)
+			continue
+		} else if err != nil {
 			c.log.Printf("Attempted to form a contract with %v, but negotiation failed: %v\n", host.NetAddress, err)
 			continue
 		}
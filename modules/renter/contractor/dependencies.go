@@ -20,10 +20,12 @@ type (
 	// transactionBuilder.
 	walletShim interface {
 		NextAddress() (types.UnlockConditions, error)
+		PrimarySeed() (modules.Seed, uint64, error)
 		StartTransaction() modules.TransactionBuilder
 	}
 	wallet interface {
 		NextAddress() (types.UnlockConditions, error)
+		PrimarySeed() (modules.Seed, uint64, error)
 		StartTransaction() transactionBuilder
 	}
 	transactionBuilder interface {
@@ -70,6 +72,7 @@ type walletBridge struct {
 }
 
 func (ws *walletBridge) NextAddress() (types.UnlockConditions, error) { return ws.w.NextAddress() }
+func (ws *walletBridge) PrimarySeed() (modules.Seed, uint64, error)   { return ws.w.PrimarySeed() }
 func (ws *walletBridge) StartTransaction() transactionBuilder         { return ws.w.StartTransaction() }
 
 // stdPersist implements the persister interface via the journal type. The
@@ -22,6 +22,29 @@ type Downloader interface {
 	// retrieve.
 	Sector(root crypto.Hash) ([]byte, error)
 
+	// Sectors retrieves the sectors with the specified Merkle roots, revising
+	// the underlying contract once per batch of sectors rather than once per
+	// sector. If an error occurs, the sectors retrieved before the error are
+	// returned alongside it.
+	Sectors(roots []crypto.Hash) ([][]byte, error)
+
+	// PartialSector retrieves length bytes of the sector with the specified
+	// Merkle root, starting at offset. The offset and length must be
+	// multiples of crypto.SegmentSize, and the data is verified against the
+	// sector's Merkle root.
+	PartialSector(root crypto.Hash, offset, length uint64) ([]byte, error)
+
+	// PartialSectors retrieves the ranges of sectors described by actions,
+	// each of which may cover a full sector or a segment-aligned part of
+	// one, revising the underlying contract once per batch. If an error
+	// occurs, the data retrieved before the error is returned alongside it.
+	PartialSectors(actions []modules.DownloadAction) ([][]byte, error)
+
+	// Interrupt aborts the download that is in progress by closing the
+	// connection to the host. It may be called while another method is
+	// running, which will then return an error.
+	Interrupt() error
+
 	// Close terminates the connection to the host.
 	Close() error
 }
@@ -78,7 +101,78 @@ func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	hd.updateContract(contract)
+	return sector, nil
+}
+
+// Sectors retrieves the sectors with the specified Merkle roots, revising the
+// underlying contract once per batch of sectors.
+func (hd *hostDownloader) Sectors(roots []crypto.Hash) ([][]byte, error) {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	if hd.invalid {
+		return nil, errInvalidDownloader
+	}
+	contract, sectors, err := hd.downloader.Sectors(roots)
+	// Batches that completed before an error still revised the contract, so
+	// the revision must be saved either way.
+	if len(sectors) > 0 {
+		hd.updateContract(contract)
+	}
+	return sectors, err
+}
+
+// PartialSector retrieves length bytes of the sector with the specified Merkle
+// root, starting at offset, and revises the underlying contract to pay the
+// host for the bytes retrieved.
+func (hd *hostDownloader) PartialSector(root crypto.Hash, offset, length uint64) ([]byte, error) {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	if hd.invalid {
+		return nil, errInvalidDownloader
+	}
+	contract, data, err := hd.downloader.PartialSector(root, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	hd.updateContract(contract)
+	return data, nil
+}
+
+// PartialSectors retrieves the ranges of sectors described by actions,
+// revising the underlying contract once per batch.
+func (hd *hostDownloader) PartialSectors(actions []modules.DownloadAction) ([][]byte, error) {
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+	if hd.invalid {
+		return nil, errInvalidDownloader
+	}
+	contract, data, err := hd.downloader.PartialSectors(actions)
+	// Batches that completed before an error still revised the contract, so
+	// the revision must be saved either way.
+	if len(data) > 0 {
+		hd.updateContract(contract)
+	}
+	return data, err
+}
+
+// Interrupt aborts the download that is in progress, if any. The
+// hostDownloader is invalidated, so that no other client is handed the closed
+// connection.
+func (hd *hostDownloader) Interrupt() error {
+	// The lock is held by the download that is being interrupted, so the
+	// underlying downloader is interrupted without acquiring it.
+	err := hd.downloader.Interrupt()
+	hd.contractor.mu.Lock()
+	if hd.contractor.downloaders[hd.contractID] == hd {
+		delete(hd.contractor.downloaders, hd.contractID)
+	}
+	hd.contractor.mu.Unlock()
+	return err
+}
 
+// updateContract saves a contract that was revised by the downloader.
+func (hd *hostDownloader) updateContract(contract modules.RenterContract) {
 	hd.contractor.mu.Lock()
 	hd.contractor.contracts[contract.ID] = contract
 	hd.contractor.persist.update(updateDownloadRevision{
@@ -86,8 +180,6 @@ func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
 		NewDownloadSpending: contract.DownloadSpending,
 	})
 	hd.contractor.mu.Unlock()
-
-	return sector, nil
 }
 
 // Close cleanly terminates the download loop with the host and closes the
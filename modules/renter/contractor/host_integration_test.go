@@ -311,6 +311,89 @@ func TestIntegrationUploadDownload(t *testing.T) {
 	}
 }
 
+// TestIntegrationDownloadSectors tests that the contractor can download
+// several sectors from a host in batches.
+func TestIntegrationDownloadSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// upload several sectors
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numSectors := 3
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < numSectors; i++ {
+		data := fastrand.Bytes(int(modules.SectorSize))
+		root, err := editor.Upload(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// download all of the sectors at once
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := downloader.Sectors(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrieved) != numSectors {
+		t.Fatal("wrong number of sectors downloaded:", len(retrieved))
+	}
+	for i := range retrieved {
+		if !bytes.Equal(datas[i], retrieved[i]) {
+			t.Fatal("downloaded data does not match original")
+		}
+	}
+	err = downloader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the contract should have paid for every sector
+	c.mu.RLock()
+	spending := c.contracts[contract.ID].DownloadSpending
+	c.mu.RUnlock()
+	if spending.Cmp(hostEntry.DownloadBandwidthPrice.Mul64(modules.SectorSize*uint64(numSectors))) < 0 {
+		t.Fatal("download spending does not cover the downloaded sectors:", spending)
+	}
+}
+
 // TestIntegrationDelete tests that the contractor can delete a sector from a
 // contract previously formed with a host.
 func TestIntegrationDelete(t *testing.T) {
@@ -1094,3 +1177,232 @@ func TestContractPresenceLeak(t *testing.T) {
 		t.Fatalf("Expected to get equal errors, got %q and %q.", errors[0], errors[1])
 	}
 }
+
+// TestIntegrationDownloadPartialSector tests that a renter can download part
+// of a sector from a host, verified by a Merkle range proof.
+func TestIntegrationDownloadPartialSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+	// the host should report the version of this build, which sends range
+	// proofs
+	if hostEntry.Version != build.Version {
+		t.Fatalf("host reports version %v, expected %v", hostEntry.Version, build.Version)
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// upload a sector
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// download a few ranges of the sector
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloader.Close()
+	ranges := []struct{ offset, length uint64 }{
+		{0, crypto.SegmentSize},
+		{crypto.SegmentSize * 3, crypto.SegmentSize * 17},
+		{modules.SectorSize - crypto.SegmentSize*2, crypto.SegmentSize * 2},
+	}
+	for _, r := range ranges {
+		partial, err := downloader.PartialSector(root, r.offset, r.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(partial, data[r.offset:r.offset+r.length]) {
+			t.Fatal("downloaded data does not match original")
+		}
+	}
+
+	// full and partial sectors can be downloaded in the same batch
+	batch, err := downloader.PartialSectors([]modules.DownloadAction{
+		{MerkleRoot: root, Offset: 0, Length: modules.SectorSize},
+		{MerkleRoot: root, Offset: crypto.SegmentSize, Length: crypto.SegmentSize * 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || !bytes.Equal(batch[0], data) || !bytes.Equal(batch[1], data[crypto.SegmentSize:crypto.SegmentSize*3]) {
+		t.Fatal("downloaded batch does not match original")
+	}
+
+	// unaligned requests should be rejected before reaching the host
+	if _, err := downloader.PartialSector(root, 1, crypto.SegmentSize); err == nil {
+		t.Fatal("expected unaligned request to fail")
+	}
+}
+
+// TestIntegrationSectorRoots tests that a renter can retrieve the sector roots
+// of a contract from a host running this version, one page at a time.
+func TestIntegrationSectorRoots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db; the host should report the version
+	// of this build, which supports RPCSectorRoots
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+	if hostEntry.Version != build.Version {
+		t.Fatalf("host reports version %v, expected %v", hostEntry.Version, build.Version)
+	}
+
+	// form a contract with the host and upload more sectors than fit in a
+	// single page
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	for i := 0; i < 5; i++ {
+		root, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// retrieve the roots from the host
+	c.mu.RLock()
+	contract = c.contracts[contract.ID]
+	c.mu.RUnlock()
+	revisionTxn, hostRoots, err := proto.SectorRoots(hostEntry, contract.FileContract, contract.ID, contract.SecretKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revisionTxn.FileContractRevisions[0].NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Fatal("host sent the wrong revision")
+	}
+	if len(hostRoots) != len(roots) {
+		t.Fatalf("expected %v sector roots, got %v", len(roots), len(hostRoots))
+	}
+	for i := range roots {
+		if hostRoots[i] != roots[i] {
+			t.Fatal("sector roots do not match")
+		}
+	}
+}
+
+// TestIntegrationSecureSession tests that the renter talks to a host running
+// this version through encrypted sessions, using the version that the host
+// reports rather than one supplied by the test.
+func TestIntegrationSecureSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+	if hostEntry.Version != build.Version {
+		t.Fatalf("host reports version %v, expected %v", hostEntry.Version, build.Version)
+	}
+	if build.VersionCmp(hostEntry.Version, modules.SecureSessionHostVersion) < 0 {
+		t.Fatalf("host version %v predates secure sessions (%v)", hostEntry.Version, modules.SecureSessionHostVersion)
+	}
+
+	// form a contract, upload a sector, and download it again; each of these
+	// should open a secure session
+	sessions := h.NetworkMetrics().SecureSessions
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := downloader.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := downloader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, retrieved) {
+		t.Fatal("downloaded data does not match uploaded data")
+	}
+	if n := h.NetworkMetrics().SecureSessions - sessions; n < 3 {
+		t.Fatalf("expected at least 3 secure sessions, got %v", n)
+	}
+}
@@ -97,8 +97,9 @@ func (c *Contractor) load() error {
 		// COMPATv1.1.2
 		// Old versions calculated the TotalCost field incorrectly, omitting
 		// the transaction fee. Recompute the TotalCost from scratch using the
-		// original allocated funds and fees.
-		if len(contract.FileContract.ValidProofOutputs) > 0 {
+		// original allocated funds and fees. The TotalCost of a recovered
+		// contract is unknown and left at zero.
+		if len(contract.FileContract.ValidProofOutputs) > 0 && !contract.TotalCost.IsZero() {
 			contract.TotalCost = contract.FileContract.ValidProofOutputs[0].Value.
 				Add(contract.TxnFee).Add(contract.SiafundFee).Add(contract.ContractFee)
 		}
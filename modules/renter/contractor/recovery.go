@@ -0,0 +1,239 @@
+package contractor
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/proto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errRecoveryScanInProgress is returned if a recovery scan is requested
+	// while another one is still running.
+	errRecoveryScanInProgress = errors.New("a recovery scan is already in progress")
+)
+
+type (
+	// A recoveryScanner scans the blockchain for file contracts that were
+	// marked with an identifier derived from the renter seed.
+	recoveryScanner struct {
+		c         *Contractor
+		rs        modules.RenterSeed
+		contracts map[types.FileContractID]recoverableContract
+		height    types.BlockHeight
+	}
+
+	// A recoverableContract is a file contract found by the recovery scan,
+	// along with the nonce of its key and the height at which it appeared on
+	// the blockchain.
+	recoverableContract struct {
+		fc          types.FileContract
+		nonce       modules.KeyNonce
+		startHeight types.BlockHeight
+	}
+)
+
+// ProcessConsensusChange scans the transactions of the consensus change for
+// identifiable file contracts.
+func (s *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
+	for _, block := range cc.RevertedBlocks {
+		if block.ID() != types.GenesisID {
+			s.height--
+		}
+		for _, txn := range block.Transactions {
+			for i := range txn.FileContracts {
+				delete(s.contracts, txn.FileContractID(uint64(i)))
+			}
+		}
+	}
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			s.height++
+		}
+		for _, txn := range block.Transactions {
+			s.scanTransaction(txn)
+		}
+	}
+
+	s.c.mu.Lock()
+	s.c.recoveryScannedHeight = s.height
+	s.c.mu.Unlock()
+}
+
+// scanTransaction adds the file contracts of txn that carry an identifier
+// belonging to the renter.
+func (s *recoveryScanner) scanTransaction(txn types.Transaction) {
+	for _, arb := range txn.ArbitraryData {
+		ci, nonce, ok := modules.ParseContractIdentifier(arb)
+		if !ok {
+			continue
+		}
+		for i, fc := range txn.FileContracts {
+			if s.rs.ContractIdentifier(fc.UnlockHash) == ci {
+				s.contracts[txn.FileContractID(uint64(i))] = recoverableContract{
+					fc:          fc,
+					nonce:       nonce,
+					startHeight: s.height,
+				}
+			}
+		}
+	}
+}
+
+// contractUnlockHash returns the unlock hash of the unlock conditions used by
+// a contract between the provided renter and host keys.
+func contractUnlockHash(renterKey crypto.PublicKey, hostKey types.SiaPublicKey) types.UnlockHash {
+	return types.UnlockConditions{
+		PublicKeys: []types.SiaPublicKey{
+			types.Ed25519PublicKey(renterKey),
+			hostKey,
+		},
+		SignaturesRequired: 2,
+	}.UnlockHash()
+}
+
+// RenterSeed returns the renter seed, which is derived from the primary seed
+// of the wallet. The wallet must be unlocked.
+func (c *Contractor) RenterSeed() (modules.RenterSeed, error) {
+	seed, _, err := c.wallet.PrimarySeed()
+	if err != nil {
+		return modules.RenterSeed{}, err
+	}
+	return modules.DeriveRenterSeed(seed), nil
+}
+
+// InitRecoveryScan starts scanning the blockchain for contracts that were
+// formed using keys derived from the renter seed. Any unexpired contracts
+// which the contractor does not know about are recovered from their hosts.
+func (c *Contractor) InitRecoveryScan() error {
+	rs, err := c.RenterSeed()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.recoveryScanInProgress {
+		c.mu.Unlock()
+		return errRecoveryScanInProgress
+	}
+	c.recoveryScanInProgress = true
+	c.recoveryScannedHeight = 0
+	c.mu.Unlock()
+
+	go c.threadedRecoveryScan(rs)
+	return nil
+}
+
+// RecoveryScanStatus returns whether a recovery scan is in progress, and the
+// height that the current or most recent scan has reached.
+func (c *Contractor) RecoveryScanStatus() (bool, types.BlockHeight) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.recoveryScanInProgress, c.recoveryScannedHeight
+}
+
+// threadedRecoveryScan rescans the blockchain for contracts belonging to the
+// renter seed, and recovers the ones that are missing.
+func (c *Contractor) threadedRecoveryScan(rs modules.RenterSeed) {
+	defer func() {
+		c.mu.Lock()
+		c.recoveryScanInProgress = false
+		c.mu.Unlock()
+	}()
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+
+	s := &recoveryScanner{
+		c:         c,
+		rs:        rs,
+		contracts: make(map[types.FileContractID]recoverableContract),
+	}
+	err := c.cs.ConsensusSetSubscribe(s, modules.ConsensusChangeBeginning, c.tg.StopChan())
+	c.cs.Unsubscribe(s)
+	if err != nil {
+		c.log.Println("Recovery scan failed:", err)
+		return
+	}
+	c.log.Printf("Recovery scan found %v contracts belonging to the renter seed", len(s.contracts))
+
+	for id, rc := range s.contracts {
+		err := c.managedRecoverContract(rs, id, rc)
+		if err != nil {
+			c.log.Printf("Unable to recover contract %v: %v", id, err)
+		}
+	}
+}
+
+// managedRecoverContract rebuilds a contract found by the recovery scan,
+// fetching the latest revision from the host. Contracts that are already
+// known or have expired are skipped.
+func (c *Contractor) managedRecoverContract(rs modules.RenterSeed, id types.FileContractID, rc recoverableContract) error {
+	fc := rc.fc
+	c.mu.RLock()
+	_, known := c.contracts[id]
+	_, old := c.oldContracts[id]
+	height := c.blockHeight
+	c.mu.RUnlock()
+	if known || old || fc.WindowStart <= height {
+		return nil
+	}
+
+	// The unlock hash of the contract identifies the host.
+	var host modules.HostDBEntry
+	var sk crypto.SecretKey
+	var found bool
+	for _, h := range c.hdb.AllHosts() {
+		hsk, hpk := rs.ContractKeyPair(h.PublicKey, rc.nonce)
+		if contractUnlockHash(hpk, h.PublicKey) == fc.UnlockHash {
+			host, sk, found = h, hsk, true
+			break
+		}
+	}
+	if !found {
+		return errors.New("no host in the hostdb matches the contract")
+	}
+
+	// Fetch the latest revision and the sector roots from the host. Hosts
+	// that predate RPCSectorRoots can only provide the revision.
+	revisionTxn, roots, err := proto.SectorRoots(host, fc, id, sk, c.tg.StopChan())
+	if err == proto.ErrSectorRootsUnsupported {
+		revisionTxn, err = proto.RecoverRevision(host, fc, id, sk, c.tg.StopChan())
+	}
+	if err != nil {
+		return err
+	}
+	rev := revisionTxn.FileContractRevisions[0]
+
+	// The spending of the lost contract is unknown, so TotalCost is left at
+	// zero. If the sector roots could not be retrieved, the contract can't be
+	// used to upload or be renewed.
+	haveRoots := roots != nil
+	contract := modules.RenterContract{
+		FileContract:    fc,
+		HostPublicKey:   host.PublicKey,
+		ID:              id,
+		LastRevision:    rev,
+		LastRevisionTxn: revisionTxn,
+		NetAddress:      host.NetAddress,
+		SecretKey:       sk,
+		KeyNonce:        rc.nonce,
+		MerkleRoots:     roots,
+		StartHeight:     rc.startHeight,
+
+		GoodForRenew:  haveRoots,
+		GoodForUpload: haveRoots,
+	}
+
+	c.mu.Lock()
+	c.contracts[id] = contract
+	err = c.saveSync()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.log.Printf("Recovered contract %v with host %v", id, host.NetAddress)
+	return nil
+}
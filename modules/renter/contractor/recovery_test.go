@@ -0,0 +1,192 @@
+package contractor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestRecoveryScannerTransaction tests that the recovery scanner only picks up
+// contracts marked with an identifier derived from its seed, along with the
+// nonce of their key.
+func TestRecoveryScannerTransaction(t *testing.T) {
+	var seed, otherSeed modules.Seed
+	fastrand.Read(seed[:])
+	fastrand.Read(otherSeed[:])
+	rs := modules.DeriveRenterSeed(seed)
+	otherRS := modules.DeriveRenterSeed(otherSeed)
+
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	var nonce modules.KeyNonce
+	fastrand.Read(nonce[:])
+	_, pk := rs.ContractKeyPair(hostKey, nonce)
+	fc := types.FileContract{
+		UnlockHash:        contractUnlockHash(pk, hostKey),
+		ValidProofOutputs: []types.SiacoinOutput{{}, {}},
+	}
+
+	s := &recoveryScanner{
+		rs:        rs,
+		contracts: make(map[types.FileContractID]recoverableContract),
+	}
+
+	// A transaction marked with another seed's identifier should be ignored.
+	txn := types.Transaction{
+		FileContracts: []types.FileContract{fc},
+		ArbitraryData: [][]byte{otherRS.ContractIdentifier(fc.UnlockHash).ArbitraryData(nonce)},
+	}
+	s.scanTransaction(txn)
+	if len(s.contracts) != 0 {
+		t.Fatal("scanner picked up a contract belonging to another seed")
+	}
+
+	// A transaction marked with the scanner's identifier should be found.
+	txn.ArbitraryData = [][]byte{rs.ContractIdentifier(fc.UnlockHash).ArbitraryData(nonce)}
+	s.scanTransaction(txn)
+	if rc, ok := s.contracts[txn.FileContractID(0)]; !ok || len(s.contracts) != 1 {
+		t.Fatal("scanner did not pick up the contract")
+	} else if rc.nonce != nonce {
+		t.Fatal("scanner did not pick up the nonce of the contract key")
+	}
+}
+
+// TestContractKeyNonce tests that contracts with the same host use different
+// keys if their nonces differ.
+func TestContractKeyNonce(t *testing.T) {
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	rs := modules.DeriveRenterSeed(seed)
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+
+	var nonce1, nonce2 modules.KeyNonce
+	fastrand.Read(nonce1[:])
+	fastrand.Read(nonce2[:])
+	sk1, pk1 := rs.ContractKeyPair(hostKey, nonce1)
+	sk2, pk2 := rs.ContractKeyPair(hostKey, nonce2)
+	if sk1 == sk2 || pk1 == pk2 {
+		t.Fatal("contracts with the same host share a key")
+	}
+	if sk, _ := rs.ContractKeyPair(hostKey, nonce1); sk != sk1 {
+		t.Fatal("contract key is not deterministic")
+	}
+}
+
+// TestIntegrationRecoverContract tests that a contract formed by the
+// contractor can be recovered from the blockchain and the host after the
+// contractor has lost track of it.
+func TestIntegrationRecoverContract(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, m, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host and upload a few sectors
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	for i := 0; i < 3; i++ {
+		root, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// lose track of the contract
+	c.mu.Lock()
+	contract = c.contracts[contract.ID]
+	delete(c.contracts, contract.ID)
+	c.mu.Unlock()
+	rs, err := c.RenterSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk, _ := rs.ContractKeyPair(hostEntry.PublicKey, contract.KeyNonce); sk != contract.SecretKey {
+		t.Fatal("contract key was not derived from the renter seed")
+	}
+	if _, err := m.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// recover the contract
+	if err := c.InitRecoveryScan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.InitRecoveryScan(); err != errRecoveryScanInProgress {
+		t.Fatal("expected a second scan to be rejected, got", err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if inProgress, _ := c.RecoveryScanStatus(); inProgress {
+			return errRecoveryScanInProgress
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, ok := c.ContractByID(contract.ID)
+	if !ok {
+		t.Fatal("contract was not recovered")
+	}
+	if recovered.SecretKey != contract.SecretKey || recovered.KeyNonce != contract.KeyNonce {
+		t.Error("recovered contract has the wrong key")
+	}
+	if !recovered.TotalCost.IsZero() {
+		t.Error("the cost of a recovered contract should be unknown")
+	}
+	if recovered.LastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Error("recovered contract has the wrong revision")
+	}
+	if recovered.HostPublicKey.String() != contract.HostPublicKey.String() {
+		t.Error("recovered contract has the wrong host")
+	}
+	if len(recovered.MerkleRoots) != len(roots) {
+		t.Fatal("wrong number of sector roots recovered:", len(recovered.MerkleRoots))
+	}
+	for i := range roots {
+		if recovered.MerkleRoots[i] != roots[i] {
+			t.Fatal("recovered sector roots do not match")
+		}
+	}
+	if !recovered.GoodForUpload || !recovered.GoodForRenew {
+		t.Error("recovered contract with sector roots should be usable")
+	}
+}
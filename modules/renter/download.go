@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,13 +23,48 @@ import (
 const (
 	defaultFilePerm         = 0666
 	downloadFailureCooldown = time.Minute * 30
+
+	// downloadLatencyDecay is the weight given to the previous latency
+	// estimate of a worker when a new piece download completes. Higher values
+	// mean that a single slow or fast piece has less influence on how the
+	// worker is prioritized.
+	downloadLatencyDecay = 0.8
+
+	// downloadFailureLatency is the latency that is recorded for a piece
+	// download that failed faster than this, so that a worker whose host
+	// refuses connections does not appear to be fast.
+	downloadFailureLatency = time.Minute * 2
+
+	// pieceNonceSize is the size of the nonce that crypto.TwofishKey's
+	// EncryptBytes places in front of each encrypted piece.
+	pieceNonceSize = 12
 )
 
 var (
+	errDownloadCancelled  = errors.New("piece download cancelled because the chunk has already been recovered")
 	errPrevErr            = errors.New("download could not be completed due to a previous error")
 	errInsufficientHosts  = errors.New("insufficient hosts to recover file")
 	errInsufficientPieces = errors.New("couldn't fetch enough pieces to recover data")
 
+	// defaultDownloadOverdrive is the number of extra pieces that are
+	// requested for each chunk beyond the minimum needed to recover it. The
+	// first pieces to arrive are used, and the rest are discarded, which
+	// prevents a single slow host from stalling the whole chunk.
+	defaultDownloadOverdrive = build.Select(build.Var{
+		Standard: int(2),
+		Dev:      int(1),
+		Testing:  int(1),
+	}).(int)
+
+	// maxDownloadBatchPieces is the maximum number of pieces that are handed
+	// to a worker at once. The worker fetches all of the pieces in a batch
+	// from its host using as few contract revisions as the host allows.
+	maxDownloadBatchPieces = build.Select(build.Var{
+		Standard: int(4),
+		Dev:      int(4),
+		Testing:  int(3),
+	}).(int)
+
 	// maxActiveDownloadPieces determines the maximum number of pieces that are
 	// allowed to be concurrently downloading. More pieces means more
 	// parallelism, but also more RAM usage.
@@ -56,6 +92,30 @@ type (
 		// have tried to fetch a piece of the chunk.
 		completedPieces map[uint64][]byte
 		workerAttempts  map[types.FileContractID]bool
+
+		// activeWorkers is the number of workers that are currently fetching a
+		// piece of this chunk.
+		//
+		// cancel is closed once the chunk has been recovered, signaling to any
+		// workers that are still fetching an overdrive piece that the piece
+		// is no longer needed. Pieces that have not been started are skipped,
+		// and downloads that are in progress are interrupted.
+		//
+		// recovered indicates that enough pieces have arrived and the chunk
+		// has been written to the destination. Pieces that arrive after this
+		// point are discarded.
+		activeWorkers int
+		cancel        chan struct{}
+		recovered     bool
+
+		// dataOffset and dataLength are the range of the chunk's data that is
+		// needed by the download, if that range lies within a single data
+		// piece. Only the matching range of each piece is then downloaded, and
+		// the pieces are recovered together. dataLength is zero if whole
+		// pieces are downloaded. Both fields are static, and can be read by
+		// the workers.
+		dataOffset uint64
+		dataLength uint64
 	}
 
 	// A download is a file download that has been queued by the renter.
@@ -104,7 +164,8 @@ type (
 		//
 		// activeWorkers indicates the list of workers which are actively
 		// download a piece, and can be utilized again later but are currently
-		// unavailable.
+		// unavailable. Each worker is mapped to the number of pieces it has
+		// yet to return.
 		//
 		// incompleteChunks is a list of chunks (by index) which have had a
 		// download fail. Repeat entries means that multiple downloads failed.
@@ -112,12 +173,17 @@ type (
 		// unless no more workers exist who can download pieces for that chunk,
 		// in which case the download has failed.
 		//
+		// overdrive is the number of extra pieces that get requested for each
+		// new chunk, refreshed from the renter settings at the start of each
+		// iteration.
+		//
 		// resultChan is the channel that is used to receive completed worker
 		// downloads.
 		activePieces     int
-		activeWorkers    map[types.FileContractID]struct{}
+		activeWorkers    map[types.FileContractID]int
 		availableWorkers []*worker
 		incompleteChunks []*chunkDownload
+		overdrive        int
 		resultChan       chan finishedDownload
 	}
 )
@@ -221,6 +287,117 @@ func (d *download) fail(err error) {
 	d.destination.Close()
 }
 
+// cancelled returns true if the chunk no longer needs any more pieces.
+func (cd *chunkDownload) cancelled() bool {
+	select {
+	case <-cd.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// pieceForWorker returns the piece of the chunk that the worker is able to
+// fetch, if the worker holds a piece of the chunk and has not already been
+// scheduled to fetch it.
+func (cd *chunkDownload) pieceForWorker(w *worker) (pieceData, bool) {
+	scheduled, exists := cd.workerAttempts[w.contractID]
+	if scheduled || !exists {
+		// Either this worker does not contain a piece of this chunk, or this
+		// worker has already been scheduled to download a piece for this
+		// chunk.
+		return pieceData{}, false
+	}
+	piece, exists := cd.download.pieceSet[cd.index][w.contractID]
+	return piece, exists
+}
+
+// piecesNeeded returns the number of pieces that still need to be scheduled
+// before the chunk has a chance of being recovered, taking into account the
+// pieces that have already arrived and the pieces that are in flight.
+func (cd *chunkDownload) piecesNeeded() int {
+	return cd.download.erasureCode.MinPieces() - len(cd.completedPieces) - cd.activeWorkers
+}
+
+// pieceSize returns the size of the plaintext of each piece of the download.
+func (d *download) pieceSize() uint64 {
+	return d.chunkSize / uint64(d.erasureCode.MinPieces())
+}
+
+// partialRange returns the range of the chunk's data that is needed by the
+// download. ok is false if the range spans more than one data piece, or if
+// downloading the range would not save any bandwidth, in which case the
+// pieces are downloaded in full.
+func (d *download) partialRange(chunkIndex uint64) (offset, length uint64, ok bool) {
+	if d.length == 0 || d.chunkSize == 0 {
+		return 0, 0, false
+	}
+	chunkBase := chunkIndex * d.chunkSize
+	start, end := uint64(0), d.chunkSize
+	if d.offset > chunkBase {
+		start = d.offset - chunkBase
+	}
+	if d.offset+d.length < chunkBase+d.chunkSize {
+		end = d.offset + d.length - chunkBase
+	}
+	pieceSize := d.pieceSize()
+	if start/pieceSize != (end-1)/pieceSize {
+		return 0, 0, false
+	}
+	_, fetchLength := sectorRange(start%pieceSize, end-start)
+	if fetchLength+crypto.SegmentSize >= modules.SectorSize {
+		return 0, 0, false
+	}
+	return start, end - start, true
+}
+
+// sectorRange returns the segment-aligned range of a sector that holds the
+// ciphertext of the given range of a piece's plaintext. The sector starts with
+// the nonce of the piece, which is followed by the ciphertext.
+func sectorRange(pieceOffset, pieceLength uint64) (offset, length uint64) {
+	start := pieceNonceSize + pieceOffset
+	end := start + pieceLength
+	offset = start / crypto.SegmentSize * crypto.SegmentSize
+	end = (end + crypto.SegmentSize - 1) / crypto.SegmentSize * crypto.SegmentSize
+	return offset, end - offset
+}
+
+// pieceRange returns the range of the plaintext of each piece that is
+// downloaded for the chunk, and the segment-aligned range of the sector that
+// holds it. ok is false if whole pieces are downloaded.
+func (cd *chunkDownload) pieceRange() (pieceOffset, fetchOffset, fetchLength uint64, ok bool) {
+	if cd.dataLength == 0 {
+		return 0, 0, 0, false
+	}
+	pieceOffset = cd.dataOffset % cd.download.pieceSize()
+	fetchOffset, fetchLength = sectorRange(pieceOffset, cd.dataLength)
+	return pieceOffset, fetchOffset, fetchLength, true
+}
+
+// decryptPartialPiece decrypts the part of a piece that was downloaded for a
+// chunk whose pieces are downloaded in part. The data holds the nonce of the
+// piece, followed by the ciphertext from the start of the downloaded range,
+// or from the start of the piece if the range included the nonce. The
+// ciphertext was verified against the piece's Merkle root by the worker, so
+// it does not need to be authenticated.
+func (cd *chunkDownload) decryptPartialPiece(key crypto.TwofishKey, data []byte) ([]byte, error) {
+	pieceOffset, fetchOffset, _, _ := cd.pieceRange()
+	if len(data) < pieceNonceSize {
+		return nil, crypto.ErrInsufficientLen
+	}
+	var ctOffset uint64
+	if fetchOffset > pieceNonceSize {
+		ctOffset = fetchOffset - pieceNonceSize
+	}
+	nonce, ct := data[:pieceNonceSize], data[pieceNonceSize:]
+	start := pieceOffset - ctOffset
+	if uint64(len(ct)) < start+cd.dataLength {
+		return nil, errors.New("downloaded part of piece is too short")
+	}
+	ct = ct[start : start+cd.dataLength]
+	return key.DecryptBytesInRange(nonce, ct, pieceOffset)
+}
+
 // recoverChunk takes a chunk that has had a sufficient number of pieces
 // downloaded and verifies, decrypts and decodes them into the file.
 func (cd *chunkDownload) recoverChunk() error {
@@ -248,13 +425,37 @@ func (cd *chunkDownload) recoverChunk() error {
 
 		// Decrypt the piece.
 		key := deriveKey(cd.download.masterKey, cd.index, uint64(i))
-		decryptedPiece, err := key.DecryptBytes(chunk[i])
+		var decryptedPiece []byte
+		var err error
+		if cd.dataLength != 0 {
+			decryptedPiece, err = cd.decryptPartialPiece(key, chunk[i])
+		} else {
+			decryptedPiece, err = key.DecryptBytes(chunk[i])
+		}
 		if err != nil {
 			return build.ExtendErr("unable to decrypt piece", err)
 		}
 		chunk[i] = decryptedPiece
 	}
 
+	// If only part of each piece was downloaded, the same range of every
+	// piece is recovered, and the range of the data piece that holds the
+	// requested data is written to the destination.
+	if cd.dataLength != 0 {
+		dataPiece := cd.dataOffset / cd.download.pieceSize()
+		recoverWriter := new(bytes.Buffer)
+		err := cd.download.erasureCode.Recover(chunk, (dataPiece+1)*cd.dataLength, recoverWriter)
+		if err != nil {
+			return build.ExtendErr("unable to recover chunk", err)
+		}
+		result := recoverWriter.Bytes()[dataPiece*cd.dataLength:]
+		_, err = cd.download.destination.WriteAt(result, int64(cd.index*cd.download.chunkSize+cd.dataOffset))
+		if err != nil {
+			return build.ExtendErr("unable to write to download destination", err)
+		}
+		return cd.finish()
+	}
+
 	// Recover the chunk into a byte slice.
 	recoverWriter := new(bytes.Buffer)
 	recoverSize := cd.download.chunkSize
@@ -296,7 +497,12 @@ func (cd *chunkDownload) recoverChunk() error {
 	if err != nil {
 		return build.ExtendErr("unable to write to download destination", err)
 	}
+	return cd.finish()
+}
 
+// finish marks the chunk as finished once its data has been written to the
+// destination, completing the download if it was the last chunk.
+func (cd *chunkDownload) finish() error {
 	cd.download.mu.Lock()
 	defer cd.download.mu.Unlock()
 
@@ -319,7 +525,7 @@ func (cd *chunkDownload) recoverChunk() error {
 		// Signal that the download is complete.
 		cd.download.downloadComplete = true
 		close(cd.download.downloadFinished)
-		err = cd.download.destination.Close()
+		err := cd.download.destination.Close()
 		if err != nil {
 			return err
 		}
@@ -353,6 +559,10 @@ func (r *Renter) addDownloadToChunkQueue(d *download) {
 
 			completedPieces: make(map[uint64][]byte),
 			workerAttempts:  make(map[types.FileContractID]bool),
+			cancel:          make(chan struct{}),
+		}
+		if offset, length, ok := d.partialRange(uint64(i)); ok {
+			cd.dataOffset, cd.dataLength = offset, length
 		}
 		for fcid := range d.pieceSet[i] {
 			cd.workerAttempts[fcid] = false
@@ -361,6 +571,25 @@ func (r *Renter) addDownloadToChunkQueue(d *download) {
 	}
 }
 
+// sortWorkersByLatency sorts the workers so that the workers that have
+// historically returned pieces the fastest come first. Workers without any
+// latency history are tried first so that they can be measured.
+func sortWorkersByLatency(workers []*worker) {
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].downloadLatency < workers[j].downloadLatency
+	})
+}
+
+// updateDownloadLatency adds the duration of a piece download to the moving
+// average of the worker's download latency.
+func (w *worker) updateDownloadLatency(duration time.Duration) {
+	if w.downloadLatency == 0 {
+		w.downloadLatency = duration
+	} else {
+		w.downloadLatency = time.Duration(downloadLatencyDecay*float64(w.downloadLatency) + (1-downloadLatencyDecay)*float64(duration))
+	}
+}
+
 // downloadIteration performs one iteration of the download loop.
 func (r *Renter) managedDownloadIteration(ds *downloadState) {
 	// Check for sleep and break conditions.
@@ -386,6 +615,7 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 	contracts := r.hostContractor.Contracts()
 	id := r.mu.Lock()
 	r.updateWorkerPool(contracts)
+	ds.overdrive = r.downloadOverdrive
 	ds.availableWorkers = make([]*worker, 0, len(r.workerPool))
 	for _, worker := range r.workerPool {
 		// Ignore workers that are already in the active set of workers.
@@ -407,6 +637,8 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 	}
 	r.mu.Unlock(id)
 
+	sortWorkersByLatency(ds.availableWorkers)
+
 	// Add new chunks to the extent that resources allow.
 	r.managedScheduleNewChunks(ds)
 
@@ -423,8 +655,15 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 // completed.
 func (r *Renter) managedScheduleIncompleteChunks(ds *downloadState) {
 	var newIncompleteChunks []*chunkDownload
+	// batched marks the entries that were added to the batch of a worker
+	// while scheduling an earlier entry.
+	batched := make([]bool, len(ds.incompleteChunks))
 loop:
-	for _, incompleteChunk := range ds.incompleteChunks {
+	for i, incompleteChunk := range ds.incompleteChunks {
+		if batched[i] {
+			continue
+		}
+
 		// Drop this chunk if the file download has failed in any way.
 		incompleteChunk.download.mu.Lock()
 		downloadComplete := incompleteChunk.download.downloadComplete
@@ -441,33 +680,42 @@ loop:
 			continue
 		}
 
+		// Drop this entry if the chunk has already been recovered. This
+		// happens to overdrive pieces that were not scheduled in time.
+		if incompleteChunk.recovered {
+			ds.activePieces--
+			continue
+		}
+
 		// Try to find a worker that is able to pick up the slack on the
 		// incomplete download from the set of available workers.
-		for i, worker := range ds.availableWorkers {
-			scheduled, exists := incompleteChunk.workerAttempts[worker.contractID]
-			if scheduled || !exists {
-				// Either this worker does not contain a piece of this chunk,
-				// or this worker has already been scheduled to download a
-				// piece for this chunk.
+		for j, worker := range ds.availableWorkers {
+			piece, ok := incompleteChunk.pieceForWorker(worker)
+			if !ok {
 				continue
 			}
-
-			piece, exists := incompleteChunk.download.pieceSet[incompleteChunk.index][worker.contractID]
-			if !exists {
-				continue
+			batch := []downloadWork{ds.newDownloadWork(incompleteChunk, worker, piece)}
+
+			// Fill the rest of the batch with pieces of later chunks that
+			// the same worker is able to fetch, so that the worker can
+			// retrieve all of them from its host in one revision.
+			for k := i + 1; k < len(ds.incompleteChunks) && len(batch) < maxDownloadBatchPieces; k++ {
+				cd := ds.incompleteChunks[k]
+				if batched[k] || cd.recovered {
+					continue
+				}
+				piece, ok := cd.pieceForWorker(worker)
+				if !ok {
+					continue
+				}
+				batched[k] = true
+				batch = append(batch, ds.newDownloadWork(cd, worker, piece))
 			}
 
-			dw := downloadWork{
-				dataRoot:      piece.MerkleRoot,
-				pieceIndex:    piece.Piece,
-				chunkDownload: incompleteChunk,
-				resultChan:    ds.resultChan,
-			}
-			incompleteChunk.workerAttempts[worker.contractID] = true
-			ds.availableWorkers = append(ds.availableWorkers[:i], ds.availableWorkers[i+1:]...)
-			ds.activeWorkers[worker.contractID] = struct{}{}
+			ds.availableWorkers = append(ds.availableWorkers[:j], ds.availableWorkers[j+1:]...)
+			ds.activeWorkers[worker.contractID] = len(batch)
 			select {
-			case worker.priorityDownloadChan <- dw:
+			case worker.priorityDownloadChan <- batch:
 			default:
 				r.log.Critical("Download work not immediately received by worker")
 			}
@@ -494,6 +742,14 @@ loop:
 		// or the active set is able to pick up the slack. Verify that they are
 		// safe to be scheduled, and then schedule them if so.
 
+		// If the pieces that have arrived and the pieces that are in flight
+		// are enough to recover the chunk, this entry was an overdrive piece
+		// and can be dropped without failing the download.
+		if incompleteChunk.piecesNeeded() <= 0 {
+			ds.activePieces--
+			continue
+		}
+
 		// Cannot find workers to complete this download, fail the download
 		// connected to this chunk.
 		r.log.Println("Not enough workers to finish download:", errInsufficientHosts)
@@ -509,6 +765,19 @@ loop:
 	ds.incompleteChunks = newIncompleteChunks
 }
 
+// newDownloadWork marks the worker as fetching a piece of the chunk and
+// returns the corresponding download work.
+func (ds *downloadState) newDownloadWork(cd *chunkDownload, w *worker, piece pieceData) downloadWork {
+	cd.workerAttempts[w.contractID] = true
+	cd.activeWorkers++
+	return downloadWork{
+		dataRoot:      piece.MerkleRoot,
+		pieceIndex:    piece.Piece,
+		chunkDownload: cd,
+		resultChan:    ds.resultChan,
+	}
+}
+
 // managedScheduleNewChunks uses the set of available workers to schedule new
 // chunks if there are resources available to begin downloading them.
 func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
@@ -523,8 +792,19 @@ func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
 		// View the next chunk.
 		nextChunk := r.chunkQueue[0]
 
+		// Determine how many pieces to request for this chunk. Overdrive
+		// pieces are only requested if there are enough hosts to serve them.
+		numPieces := nextChunk.download.erasureCode.MinPieces() + ds.overdrive
+		if available := len(nextChunk.workerAttempts); numPieces > available && available >= nextChunk.download.erasureCode.MinPieces() {
+			numPieces = available
+		}
+
 		// Check whether there are enough resources to perform the download.
-		if ds.activePieces+nextChunk.download.erasureCode.MinPieces() > maxActiveDownloadPieces {
+		// Overdrive pieces are dropped before the chunk is held back.
+		if ds.activePieces+numPieces > maxActiveDownloadPieces {
+			numPieces = maxActiveDownloadPieces - ds.activePieces
+		}
+		if numPieces < nextChunk.download.erasureCode.MinPieces() {
 			// There is a limited amount of RAM available, and scheduling the
 			// next piece would consume too much RAM.
 			return
@@ -544,10 +824,10 @@ func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
 		}
 
 		// Add an incomplete chunk entry for every piece of the download.
-		for i := 0; i < nextChunk.download.erasureCode.MinPieces(); i++ {
+		for i := 0; i < numPieces; i++ {
 			ds.incompleteChunks = append(ds.incompleteChunks, nextChunk)
 		}
-		ds.activePieces += nextChunk.download.erasureCode.MinPieces()
+		ds.activePieces += numPieces
 	}
 }
 
@@ -573,7 +853,13 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 
 	// Prepare the piece.
 	workerID := finishedDownload.workerID
-	delete(ds.activeWorkers, workerID)
+	if ds.activeWorkers[workerID] <= 1 {
+		delete(ds.activeWorkers, workerID)
+	} else {
+		ds.activeWorkers[workerID]--
+	}
+	cd := finishedDownload.chunkDownload
+	cd.activeWorkers--
 
 	// Fetch the corresponding worker.
 	id := r.mu.RLock()
@@ -584,11 +870,27 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 		return
 	}
 
+	// Pieces that arrive after the chunk has been recovered, or after the
+	// download has failed, are no longer needed.
+	cd.download.mu.Lock()
+	downloadComplete := cd.download.downloadComplete
+	cd.download.mu.Unlock()
+	if cd.recovered || downloadComplete {
+		ds.activePieces--
+		return
+	}
+
 	// Check for an error.
-	cd := finishedDownload.chunkDownload
 	if finishedDownload.err != nil {
 		r.log.Debugln("Error when downloading a piece:", finishedDownload.err)
 		worker.recentDownloadFailure = time.Now()
+		// A failed piece counts against the worker's latency, so that the
+		// worker is not preferred once its failure cooldown has passed.
+		duration := finishedDownload.duration
+		if duration < downloadFailureLatency {
+			duration = downloadFailureLatency
+		}
+		worker.updateDownloadLatency(duration)
 		ds.incompleteChunks = append(ds.incompleteChunks, cd)
 		return
 	}
@@ -602,8 +904,15 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 	cd.completedPieces[finishedDownload.pieceIndex] = finishedDownload.data
 	atomic.AddUint64(&cd.download.atomicDataReceived, cd.download.reportedPieceSize)
 
-	// If the chunk has completed, perform chunk recovery.
+	// Update the latency estimate of the worker, which is used to prioritize
+	// faster workers when scheduling future pieces.
+	worker.updateDownloadLatency(finishedDownload.duration)
+
+	// If the chunk has completed, perform chunk recovery. Any overdrive pieces
+	// that are still outstanding are cancelled.
 	if len(cd.completedPieces) == cd.download.erasureCode.MinPieces() {
+		cd.recovered = true
+		close(cd.cancel)
 		err := cd.recoverChunk()
 		ds.activePieces -= len(cd.completedPieces)
 		cd.completedPieces = make(map[uint64][]byte)
@@ -629,7 +938,7 @@ func (r *Renter) threadedDownloadLoop() {
 
 	// Create the download state.
 	ds := &downloadState{
-		activeWorkers:    make(map[types.FileContractID]struct{}),
+		activeWorkers:    make(map[types.FileContractID]int),
 		availableWorkers: availableWorkers,
 		incompleteChunks: make([]*chunkDownload, 0),
 		resultChan:       make(chan finishedDownload),
@@ -1,11 +1,22 @@
 package renter
 
 import (
+	"bytes"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/contractor"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
 )
 
 // TestRenterDownloadFileWriter verifies that the renter's DownloadFileWriter
@@ -50,3 +61,322 @@ func TestRenterDownloadFileWriter(t *testing.T) {
 		t.Fatal("expected read to return file already closed, got", err, "instead.")
 	}
 }
+
+// blockingDownloader is a contractor.Downloader whose downloads block until
+// they are interrupted.
+type blockingDownloader struct {
+	started     chan struct{}
+	interrupted chan struct{}
+}
+
+func (bd *blockingDownloader) Sector(root crypto.Hash) ([]byte, error) {
+	_, err := bd.Sectors([]crypto.Hash{root})
+	return nil, err
+}
+func (bd *blockingDownloader) Sectors([]crypto.Hash) ([][]byte, error) {
+	return bd.PartialSectors(nil)
+}
+func (bd *blockingDownloader) PartialSector(crypto.Hash, uint64, uint64) ([]byte, error) {
+	_, err := bd.PartialSectors(nil)
+	return nil, err
+}
+func (bd *blockingDownloader) PartialSectors([]modules.DownloadAction) ([][]byte, error) {
+	close(bd.started)
+	<-bd.interrupted
+	return nil, errors.New("connection closed")
+}
+func (bd *blockingDownloader) Interrupt() error {
+	close(bd.interrupted)
+	return nil
+}
+func (bd *blockingDownloader) Close() error { return nil }
+
+// downloaderContractor is a hostContractor that hands out the same Downloader
+// for every contract.
+type downloaderContractor struct {
+	hostContractor
+	downloader contractor.Downloader
+}
+
+func (dc downloaderContractor) Downloader(types.FileContractID, <-chan struct{}) (contractor.Downloader, error) {
+	return dc.downloader, nil
+}
+
+// newTestDownloadRenter returns a renter with enough state to run the download
+// loop helpers, along with a worker for each of the provided contracts.
+func newTestDownloadRenter(ids []types.FileContractID) (*Renter, []*worker) {
+	r := &Renter{
+		workerPool: make(map[types.FileContractID]*worker),
+		log:        persist.NewLogger(ioutil.Discard),
+		mu:         sync.New(modules.SafeMutexDelay, 1),
+		tg:         new(sync.ThreadGroup),
+	}
+	var workers []*worker
+	for _, id := range ids {
+		w := &worker{
+			contractID:           id,
+			downloadChan:         make(chan []downloadWork, 1),
+			killChan:             make(chan struct{}),
+			priorityDownloadChan: make(chan []downloadWork, 1),
+			uploadChan:           make(chan uploadWork, 1),
+			renter:               r,
+		}
+		r.workerPool[id] = w
+		workers = append(workers, w)
+	}
+	return r, workers
+}
+
+// newTestDownload returns a single-chunk download whose pieces are held by the
+// provided contracts, one piece per contract. The erasure code has one more
+// piece than there are contracts, since it needs at least one parity piece.
+func newTestDownload(t *testing.T, minPieces int, ids []types.FileContractID) *download {
+	ec, err := NewRSCode(minPieces, len(ids)-minPieces+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &download{
+		erasureCode:      ec,
+		finishedChunks:   map[uint64]bool{0: false},
+		pieceSet:         map[uint64]map[types.FileContractID]pieceData{0: {}},
+		downloadFinished: make(chan struct{}),
+	}
+	for i, id := range ids {
+		d.pieceSet[0][id] = pieceData{Piece: uint64(i)}
+	}
+	return d
+}
+
+// testContractIDs returns n distinct contract ids.
+func testContractIDs(n int) []types.FileContractID {
+	ids := make([]types.FileContractID, n)
+	for i := range ids {
+		ids[i][0] = byte(i + 1)
+	}
+	return ids
+}
+
+// TestSortWorkersByLatency checks that workers without latency history are
+// tried first, followed by the workers with the lowest latency, and that a
+// failed piece moves a worker behind the workers that succeed.
+func TestSortWorkersByLatency(t *testing.T) {
+	ids := testContractIDs(4)
+	r, workers := newTestDownloadRenter(ids)
+	workers[0].downloadLatency = 3 * time.Second
+	workers[1].downloadLatency = time.Second
+	workers[2].downloadLatency = 2 * time.Second
+	// workers[3] has no latency history.
+
+	sorted := append([]*worker(nil), workers...)
+	sortWorkersByLatency(sorted)
+	expected := []*worker{workers[3], workers[1], workers[2], workers[0]}
+	for i := range sorted {
+		if sorted[i] != expected[i] {
+			t.Fatalf("worker %v is out of order", i)
+		}
+	}
+
+	// A piece that fails immediately should still slow the worker down.
+	d := newTestDownload(t, 1, ids)
+	r.addDownloadToChunkQueue(d)
+	ds := &downloadState{
+		activePieces:  1,
+		activeWorkers: map[types.FileContractID]int{ids[1]: 1},
+		resultChan:    make(chan finishedDownload, 1),
+	}
+	ds.resultChan <- finishedDownload{
+		chunkDownload: r.chunkQueue[0],
+		duration:      time.Millisecond,
+		err:           errors.New("connection refused"),
+		workerID:      ids[1],
+	}
+	r.managedWaitOnDownloadWork(ds)
+	if workers[1].downloadLatency <= 3*time.Second {
+		t.Fatal("failed piece did not increase the worker's latency:", workers[1].downloadLatency)
+	}
+	sortWorkersByLatency(sorted)
+	if sorted[len(sorted)-1] != workers[1] {
+		t.Fatal("failed worker is not tried last")
+	}
+}
+
+// TestDownloadOverdriveLaunch checks that overdrive pieces are scheduled on
+// separate workers alongside the pieces needed to recover a chunk, and that
+// overdrive pieces are only requested from hosts that exist.
+func TestDownloadOverdriveLaunch(t *testing.T) {
+	ids := testContractIDs(4)
+	r, workers := newTestDownloadRenter(ids)
+	d := newTestDownload(t, 2, ids)
+	r.addDownloadToChunkQueue(d)
+	ds := &downloadState{
+		activeWorkers:    make(map[types.FileContractID]int),
+		availableWorkers: append([]*worker(nil), workers...),
+		overdrive:        1,
+		resultChan:       make(chan finishedDownload, 4),
+	}
+	r.managedScheduleNewChunks(ds)
+	if ds.activePieces != 3 || len(ds.incompleteChunks) != 3 {
+		t.Fatalf("expected 3 pieces to be scheduled, got %v", ds.activePieces)
+	}
+	r.managedScheduleIncompleteChunks(ds)
+	if len(ds.activeWorkers) != 3 || len(ds.availableWorkers) != 1 {
+		t.Fatalf("expected 3 active workers, got %v", len(ds.activeWorkers))
+	}
+	pieces := make(map[uint64]struct{})
+	for _, w := range workers {
+		select {
+		case batch := <-w.priorityDownloadChan:
+			if len(batch) != 1 {
+				t.Fatal("expected a single piece per worker, got", len(batch))
+			}
+			pieces[batch[0].pieceIndex] = struct{}{}
+		default:
+		}
+	}
+	if len(pieces) != 3 {
+		t.Fatal("expected 3 distinct pieces to be requested, got", len(pieces))
+	}
+
+	// With only as many hosts as are needed, no overdrive pieces should be
+	// requested.
+	r, _ = newTestDownloadRenter(ids[:2])
+	r.addDownloadToChunkQueue(newTestDownload(t, 2, ids[:2]))
+	ds = &downloadState{
+		activeWorkers: make(map[types.FileContractID]int),
+		overdrive:     2,
+	}
+	r.managedScheduleNewChunks(ds)
+	if ds.activePieces != 2 {
+		t.Fatal("expected 2 pieces to be scheduled, got", ds.activePieces)
+	}
+}
+
+// TestDownloadCancelInFlight checks that a worker interrupts a piece download
+// that is in progress once the chunk has been recovered.
+func TestDownloadCancelInFlight(t *testing.T) {
+	ids := testContractIDs(1)
+	r, workers := newTestDownloadRenter(ids)
+	bd := &blockingDownloader{
+		started:     make(chan struct{}),
+		interrupted: make(chan struct{}),
+	}
+	r.hostContractor = downloaderContractor{downloader: bd}
+	r.addDownloadToChunkQueue(newTestDownload(t, 1, ids))
+	cd := r.chunkQueue[0]
+
+	resultChan := make(chan finishedDownload, 1)
+	go workers[0].download([]downloadWork{{
+		chunkDownload: cd,
+		resultChan:    resultChan,
+	}})
+	<-bd.started
+	close(cd.cancel)
+	select {
+	case fd := <-resultChan:
+		if fd.err != errDownloadCancelled {
+			t.Fatal("expected the download to be cancelled, got", fd.err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("in-progress download was not interrupted")
+	}
+
+	// A piece whose chunk was recovered before the worker started should not
+	// be downloaded at all.
+	bd = &blockingDownloader{
+		started:     make(chan struct{}),
+		interrupted: make(chan struct{}),
+	}
+	r.hostContractor = downloaderContractor{downloader: bd}
+	workers[0].download([]downloadWork{{
+		chunkDownload: cd,
+		resultChan:    resultChan,
+	}})
+	if fd := <-resultChan; fd.err != errDownloadCancelled {
+		t.Fatal("expected the download to be skipped, got", fd.err)
+	}
+	select {
+	case <-bd.started:
+		t.Fatal("cancelled piece was downloaded")
+	default:
+	}
+}
+
+// TestRecoverPartialChunk checks that a chunk whose pieces were only
+// downloaded in part is decrypted and recovered correctly.
+func TestRecoverPartialChunk(t *testing.T) {
+	ec, err := NewRSCode(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieceSize := modules.SectorSize - crypto.TwofishOverhead
+	chunkSize := pieceSize * 2
+	data := fastrand.Bytes(int(chunkSize))
+	masterKey := crypto.GenerateTwofishKey()
+	pieces, err := ec.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectors := make([][]byte, len(pieces))
+	for i, piece := range pieces {
+		sectors[i] = deriveKey(masterKey, 0, uint64(i)).EncryptBytes(piece)
+	}
+
+	ranges := []struct{ offset, length uint64 }{
+		{0, 100},
+		{pieceSize + 1000, 500},
+		{pieceSize*2 - 70, 70},
+	}
+	for _, r := range ranges {
+		dw := NewDownloadBufferWriter(r.length, int64(r.offset))
+		d := &download{
+			chunkSize:        chunkSize,
+			destination:      dw,
+			erasureCode:      ec,
+			fileSize:         chunkSize,
+			masterKey:        masterKey,
+			numChunks:        1,
+			offset:           r.offset,
+			length:           r.length,
+			finishedChunks:   map[uint64]bool{0: false},
+			downloadFinished: make(chan struct{}),
+		}
+		offset, length, ok := d.partialRange(0)
+		if !ok || offset != r.offset || length != r.length {
+			t.Fatalf("range [%v, %v) should be downloaded in part", r.offset, r.offset+r.length)
+		}
+		cd := &chunkDownload{
+			download:        d,
+			completedPieces: make(map[uint64][]byte),
+			dataOffset:      offset,
+			dataLength:      length,
+		}
+
+		// Hand the chunk the same data that a worker would assemble from a
+		// partial download of the second data piece and the parity piece.
+		_, fetchOffset, fetchLength, _ := cd.pieceRange()
+		for _, i := range []uint64{1, 2} {
+			piece := append([]byte(nil), sectors[i][:pieceNonceSize]...)
+			if fetchOffset == 0 {
+				piece = nil
+			}
+			cd.completedPieces[i] = append(piece, sectors[i][fetchOffset:fetchOffset+fetchLength]...)
+		}
+		if err := cd.recoverChunk(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dw.Bytes(), data[r.offset:r.offset+r.length]) {
+			t.Fatalf("range [%v, %v) was not recovered correctly", r.offset, r.offset+r.length)
+		}
+	}
+
+	// Ranges that span two pieces are downloaded in full.
+	d := &download{
+		chunkSize:   chunkSize,
+		erasureCode: ec,
+		offset:      pieceSize - 10,
+		length:      20,
+	}
+	if _, _, ok := d.partialRange(0); ok {
+		t.Fatal("range spanning two pieces should be downloaded in full")
+	}
+}
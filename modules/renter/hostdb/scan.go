@@ -225,6 +225,11 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			Cancel:  hdb.tg.StopChan(),
 			Timeout: hostRequestTimeout,
 		}
+		// The settings are signed by the host, so they can be fetched over
+		// a plaintext connection. Dialing with the version from an earlier
+		// scan would leave a host that was downgraded, or that fails the
+		// secure session handshake, unreachable forever, because the
+		// version is only refreshed by a successful scan.
 		conn, err := dialer.Dial("tcp", string(netAddr))
 		if err != nil {
 			return err
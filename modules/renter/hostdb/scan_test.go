@@ -2,9 +2,13 @@ package hostdb
 
 import (
 	"errors"
+	"net"
 	"testing"
 	"time"
 
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -182,3 +186,55 @@ func TestUpdateEntry(t *testing.T) {
 		t.Error("host not reporting historic uptime?")
 	}
 }
+
+// TestScanHostDowngraded checks that a host which reported a version with
+// secure sessions in an earlier scan, and has since been downgraded to a
+// version without them, can still be scanned.
+func TestScanHostDowngraded(t *testing.T) {
+	hdb := bareHostDB()
+
+	// Start a host that only serves the settings RPC over plaintext.
+	sk, pk := crypto.GenerateKeyPair()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	settings := modules.HostExternalSettings{
+		AcceptingContracts: true,
+		NetAddress:         modules.NetAddress(l.Addr().String()),
+		Version:            "1.3.1",
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var id types.Specifier
+			err = encoding.ReadObject(conn, &id, uint64(len(id)))
+			if err == nil && id == modules.RPCSettings {
+				crypto.WriteSignedObject(conn, settings, sk)
+			}
+			conn.Close()
+		}
+	}()
+
+	// Scan the host using an entry from a scan that saw a newer version.
+	entry := modules.HostDBEntry{
+		PublicKey: types.Ed25519PublicKey(pk),
+	}
+	entry.NetAddress = settings.NetAddress
+	entry.Version = build.Version
+	hdb.managedScanHost(entry)
+	updatedEntry, exists := hdb.hostTree.Select(entry.PublicKey)
+	if !exists {
+		t.Fatal("host was not added to the host tree")
+	}
+	if !updatedEntry.ScanHistory[len(updatedEntry.ScanHistory)-1].Success {
+		t.Fatal("scan of the downgraded host failed")
+	}
+	if updatedEntry.Version != settings.Version {
+		t.Fatalf("host version was not refreshed: expected %v, got %v", settings.Version, updatedEntry.Version)
+	}
+}
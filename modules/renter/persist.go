@@ -181,8 +181,9 @@ func (r *Renter) saveFile(f *file) error {
 // saveSync stores the current renter data to disk and then syncs to disk.
 func (r *Renter) saveSync() error {
 	data := struct {
-		Tracking map[string]trackedFile
-	}{r.tracking}
+		Tracking          map[string]trackedFile
+		DownloadOverdrive int
+	}{r.tracking, r.downloadOverdrive}
 
 	return persist.SaveJSON(saveMetadata, data, filepath.Join(r.persistDir, PersistFilename))
 }
@@ -226,8 +227,9 @@ func (r *Renter) load() error {
 
 	// Load contracts, repair set, and entropy.
 	data := struct {
-		Tracking  map[string]trackedFile
-		Repairing map[string]string // COMPATv0.4.8
+		Tracking          map[string]trackedFile
+		Repairing         map[string]string // COMPATv0.4.8
+		DownloadOverdrive *int
 	}{}
 	err = persist.LoadJSON(saveMetadata, &data, filepath.Join(r.persistDir, PersistFilename))
 	if err != nil {
@@ -236,6 +238,11 @@ func (r *Renter) load() error {
 	if data.Tracking != nil {
 		r.tracking = data.Tracking
 	}
+	// Older persist files do not contain the overdrive setting, in which case
+	// the default is kept.
+	if data.DownloadOverdrive != nil {
+		r.downloadOverdrive = *data.DownloadOverdrive
+	}
 
 	return nil
 }
@@ -229,6 +229,45 @@ func TestRenterSaveLoad(t *testing.T) {
 	}
 }
 
+// TestRenterDownloadOverdrivePersist checks that the download overdrive
+// setting is validated and survives a save and load.
+func TestRenterDownloadOverdrivePersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if rt.renter.Settings().DownloadOverdrive != defaultDownloadOverdrive {
+		t.Fatal("renter should start with the default download overdrive")
+	}
+
+	settings := rt.renter.Settings()
+	settings.DownloadOverdrive = -1
+	if err := rt.renter.SetSettings(settings); err != errNegativeOverdrive {
+		t.Fatal("expected errNegativeOverdrive, got", err)
+	}
+	settings.DownloadOverdrive = defaultDownloadOverdrive + 3
+	if err := rt.renter.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reset the in-memory value and reload it from disk.
+	id := rt.renter.mu.Lock()
+	rt.renter.downloadOverdrive = 0
+	err = rt.renter.load()
+	rt.renter.mu.Unlock(id)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if rt.renter.Settings().DownloadOverdrive != defaultDownloadOverdrive+3 {
+		t.Fatal("download overdrive was not persisted:", rt.renter.Settings().DownloadOverdrive)
+	}
+}
+
 // TestRenterPaths checks that the renter properly handles nicknames
 // containing the path separator ("/").
 func TestRenterPaths(t *testing.T) {
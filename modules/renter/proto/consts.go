@@ -4,6 +4,18 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// rangeProofVersion is the first host version that accompanies partial
+	// sector downloads with a Merkle range proof. Partial sectors are
+	// downloaded from older hosts by fetching the full sector.
+	rangeProofVersion = "1.3.2"
+
+	// sectorRootsVersion is the first host version that supports
+	// RPCSectorRoots.
+	sectorRootsVersion = "1.3.2"
 )
 
 var (
@@ -14,4 +26,12 @@ var (
 		Standard: 60 * time.Second,
 		Testing:  5 * time.Second,
 	}).(time.Duration)
+
+	// sectorRootsPageSize is the number of sector roots that are requested
+	// from the host at a time.
+	sectorRootsPageSize = build.Select(build.Var{
+		Dev:      uint64(1 << 10),
+		Standard: uint64(modules.NegotiateMaxSectorRootsPage),
+		Testing:  uint64(2),
+	}).(uint64)
 )
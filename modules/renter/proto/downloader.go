@@ -2,6 +2,8 @@ package proto
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"sync"
 	"time"
@@ -22,34 +24,187 @@ type Downloader struct {
 	once      sync.Once
 	hdb       hostDB
 
+	// interruptChan is closed when an in-progress download is aborted by
+	// Interrupt.
+	interruptChan chan struct{}
+	interruptOnce sync.Once
+
 	SaveFn revisionSaver
 }
 
 // Sector retrieves the sector with the specified Merkle root, and revises
 // the underlying contract to pay the host proportionally to the data
 // retrieve.
-func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []byte, err error) {
+func (hd *Downloader) Sector(root crypto.Hash) (modules.RenterContract, []byte, error) {
+	contract, sectors, err := hd.Sectors([]crypto.Hash{root})
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	return contract, sectors[0], nil
+}
+
+// Sectors retrieves the sectors with the specified Merkle roots. The sectors
+// are requested in batches that fit within the host's MaxDownloadBatchSize,
+// and the underlying contract is revised once per batch instead of once per
+// sector. If an error is encountered, the sectors that were retrieved before
+// the error are returned alongside it.
+func (hd *Downloader) Sectors(roots []crypto.Hash) (_ modules.RenterContract, sectors [][]byte, err error) {
+	batchSize := int(hd.host.MaxDownloadBatchSize / modules.SectorSize)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for len(roots) > 0 {
+		n := batchSize
+		if n > len(roots) {
+			n = len(roots)
+		}
+		batch := roots[:n]
+		actions := make([]modules.DownloadAction, len(batch))
+		for i, root := range batch {
+			actions[i] = modules.DownloadAction{
+				MerkleRoot: root,
+				Offset:     0,
+				Length:     modules.SectorSize,
+			}
+		}
+		err = hd.downloadBatch(actions, func(r io.Reader) error {
+			return readSectors(r, batch, func(sector []byte) {
+				sectors = append(sectors, sector)
+			})
+		})
+		if err != nil {
+			return hd.contract, sectors, err
+		}
+		roots = roots[n:]
+	}
+	return hd.contract, sectors, nil
+}
+
+// PartialSector retrieves length bytes of the sector with the specified
+// Merkle root, starting at offset. The offset and length must be multiples of
+// crypto.SegmentSize. The host proves that the data belongs to the sector
+// with a Merkle range proof, and only the requested bytes are paid for. Hosts
+// that predate range proofs are asked for the full sector instead.
+func (hd *Downloader) PartialSector(root crypto.Hash, offset, length uint64) (modules.RenterContract, []byte, error) {
+	contract, data, err := hd.PartialSectors([]modules.DownloadAction{{
+		MerkleRoot: root,
+		Offset:     offset,
+		Length:     length,
+	}})
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	return contract, data[0], nil
+}
+
+// PartialSectors retrieves the ranges of sectors described by actions, each
+// of which may cover a full sector or a segment-aligned part of one. The
+// actions are sent in batches that fit within the host's
+// MaxDownloadBatchSize, and the underlying contract is revised once per
+// batch. Hosts that predate range proofs are asked for full sectors instead.
+// If an error is encountered, the data that was retrieved before the error is
+// returned alongside it.
+func (hd *Downloader) PartialSectors(actions []modules.DownloadAction) (_ modules.RenterContract, data [][]byte, err error) {
+	for _, action := range actions {
+		if action.Length == 0 || action.Offset+action.Length > modules.SectorSize || action.Offset+action.Length < action.Offset {
+			return modules.RenterContract{}, nil, errors.New("partial sector request is out of bounds")
+		} else if action.Offset%crypto.SegmentSize != 0 || action.Length%crypto.SegmentSize != 0 {
+			return modules.RenterContract{}, nil, errors.New("partial sector request is not segment-aligned")
+		}
+	}
+	if build.VersionCmp(hd.host.Version, rangeProofVersion) < 0 {
+		return hd.partialSectorsCompat(actions)
+	}
+
+	for len(actions) > 0 {
+		// Fill the batch up to the host's limit, but always send at least one
+		// action.
+		n, size := 1, actions[0].Length
+		for n < len(actions) && size+actions[n].Length <= hd.host.MaxDownloadBatchSize {
+			size += actions[n].Length
+			n++
+		}
+		batch := actions[:n]
+		err = hd.downloadBatch(batch, func(r io.Reader) error {
+			// The host only sends range proofs if the batch contains a
+			// partial sector.
+			if !isPartialBatch(batch) {
+				roots := make([]crypto.Hash, len(batch))
+				for i, action := range batch {
+					roots[i] = action.MerkleRoot
+				}
+				return readSectors(r, roots, func(sector []byte) {
+					data = append(data, sector)
+				})
+			}
+			batchData, err := readPartialSectors(r, batch)
+			data = append(data, batchData...)
+			return err
+		})
+		if err != nil {
+			return hd.contract, data, err
+		}
+		actions = actions[n:]
+	}
+	return hd.contract, data, nil
+}
+
+// partialSectorsCompat retrieves the ranges of sectors described by actions
+// from a host that does not support range proofs, by downloading each sector
+// in full. Consecutive actions for the same sector share a download.
+//
+// COMPATv1.3.1
+func (hd *Downloader) partialSectorsCompat(actions []modules.DownloadAction) (modules.RenterContract, [][]byte, error) {
+	var roots []crypto.Hash
+	for i, action := range actions {
+		if i == 0 || action.MerkleRoot != actions[i-1].MerkleRoot {
+			roots = append(roots, action.MerkleRoot)
+		}
+	}
+	contract, sectors, err := hd.Sectors(roots)
+	var data [][]byte
+	sector := -1
+	for i, action := range actions {
+		if i == 0 || action.MerkleRoot != actions[i-1].MerkleRoot {
+			sector++
+		}
+		if sector >= len(sectors) {
+			break
+		}
+		data = append(data, sectors[sector][action.Offset:action.Offset+action.Length])
+	}
+	return contract, data, err
+}
+
+// downloadBatch performs a single iteration of the download loop, submitting
+// every action with a single revision. Once the host has accepted the
+// revision, read is called to consume and verify the host's response.
+func (hd *Downloader) downloadBatch(actions []modules.DownloadAction, read func(io.Reader) error) (err error) {
 	defer extendDeadline(hd.conn, time.Hour) // reset deadline when finished
 
 	// calculate price
-	sectorPrice := hd.host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
-	if hd.contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, nil, errors.New("contract has insufficient funds to support download")
+	var totalSize uint64
+	for _, action := range actions {
+		totalSize += action.Length
+	}
+	batchPrice := hd.host.DownloadBandwidthPrice.Mul64(totalSize)
+	if hd.contract.RenterFunds().Cmp(batchPrice) < 0 {
+		return errors.New("contract has insufficient funds to support download")
 	}
 	// to mitigate small errors (e.g. differing block heights), fudge the
 	// price and collateral by 0.2%. This is only applied to hosts above
 	// v1.0.1; older hosts use stricter math.
 	if build.VersionCmp(hd.host.Version, "1.0.1") > 0 {
-		sectorPrice = sectorPrice.MulFloat(1 + hostPriceLeeway)
+		batchPrice = batchPrice.MulFloat(1 + hostPriceLeeway)
 	}
 
 	// create the download revision
-	rev := newDownloadRevision(hd.contract.LastRevision, sectorPrice)
+	rev := newDownloadRevision(hd.contract.LastRevision, batchPrice)
 
 	// initiate download by confirming host settings
 	extendDeadline(hd.conn, modules.NegotiateSettingsTime)
 	if err := startDownload(hd.conn, hd.host); err != nil {
-		return modules.RenterContract{}, nil, err
+		return err
 	}
 
 	// Before we continue, save the revision. Unexpected termination (e.g.
@@ -59,24 +214,23 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	// we save the old revision as a fallback.
 	if hd.SaveFn != nil {
 		if err := hd.SaveFn(rev, hd.contract.MerkleRoots); err != nil {
-			return modules.RenterContract{}, nil, err
+			return err
 		}
 	}
 
-	// send download action
+	// send download actions
 	extendDeadline(hd.conn, 2*time.Minute)
-	err = encoding.WriteObject(hd.conn, []modules.DownloadAction{{
-		MerkleRoot: root,
-		Offset:     0,
-		Length:     modules.SectorSize,
-	}})
+	err = encoding.WriteObject(hd.conn, actions)
 	if err != nil {
-		return modules.RenterContract{}, nil, err
+		return err
 	}
 
-	// Increase Successful/Failed interactions accordingly
+	// Increase Successful/Failed interactions accordingly. A download that
+	// was interrupted by the renter is not the host's fault.
 	defer func() {
-		if err != nil {
+		if err != nil && hd.interrupted() {
+			return
+		} else if err != nil {
 			hd.hdb.IncrementFailedInteractions(hd.contract.HostPublicKey)
 		} else if err == nil {
 			hd.hdb.IncrementSuccessfulInteractions(hd.contract.HostPublicKey)
@@ -89,33 +243,137 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	if err == modules.ErrStopResponse {
 		// if host gracefully closed, close our connection as well; this will
 		// cause the next download to fail. However, we must delay closing
-		// until we've finished downloading the sector.
+		// until we've finished downloading the sectors.
 		defer hd.conn.Close()
 	} else if err != nil {
-		return modules.RenterContract{}, nil, err
+		return err
 	}
 
-	// read sector data, completing one iteration of the download loop
-	extendDeadline(hd.conn, modules.NegotiateDownloadTime)
-	var sectors [][]byte
-	if err := encoding.ReadObject(hd.conn, &sectors, modules.SectorSize+16); err != nil {
-		return modules.RenterContract{}, nil, err
-	} else if len(sectors) != 1 {
-		return modules.RenterContract{}, nil, errors.New("host did not send enough sectors")
-	}
-	sector := sectors[0]
-	if uint64(len(sector)) != modules.SectorSize {
-		return modules.RenterContract{}, nil, errors.New("host did not send enough sector data")
-	} else if crypto.MerkleRoot(sector) != root {
-		return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
+	// read the sector data, completing one iteration of the download loop.
+	extendDeadline(hd.conn, modules.NegotiateDownloadTime*time.Duration(len(actions)))
+	if err := read(hd.conn); err != nil {
+		return err
 	}
 
 	// update contract and metrics
 	hd.contract.LastRevision = rev
 	hd.contract.LastRevisionTxn = signedTxn
-	hd.contract.DownloadSpending = hd.contract.DownloadSpending.Add(sectorPrice)
+	hd.contract.DownloadSpending = hd.contract.DownloadSpending.Add(batchPrice)
+	return nil
+}
+
+// readSectors reads the [][]byte payload sent by the host in response to a
+// batch of download actions, verifying each sector against its expected
+// Merkle root before passing it to fn.
+func readSectors(r io.Reader, roots []crypto.Hash, fn func([]byte)) error {
+	prefix := make([]byte, 8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return err
+	}
+	maxLen := uint64(len(roots)) * (modules.SectorSize + 16)
+	dataLen := encoding.DecUint64(prefix)
+	if dataLen > maxLen {
+		return fmt.Errorf("length %d exceeds maxLen of %d", dataLen, maxLen)
+	}
+	lr := &io.LimitedReader{R: r, N: int64(dataLen)}
+	dec := encoding.NewDecoder(lr)
+	var numSectors uint64
+	if err := dec.Decode(&numSectors); err != nil {
+		return err
+	} else if numSectors != uint64(len(roots)) {
+		return errors.New("host did not send enough sectors")
+	}
+	for _, root := range roots {
+		var sector []byte
+		if err := dec.Decode(&sector); err != nil {
+			return err
+		}
+		if uint64(len(sector)) != modules.SectorSize {
+			return errors.New("host did not send enough sector data")
+		} else if crypto.MerkleRoot(sector) != root {
+			return errors.New("host sent bad sector data")
+		}
+		fn(sector)
+	}
+	// Any data left over would be read as the start of the next response.
+	if lr.N != 0 {
+		return errors.New("host sent more data than the sectors it was asked for")
+	}
+	return nil
+}
 
-	return hd.contract, sector, nil
+// isPartialBatch returns true if any of the actions asks for less than a full
+// sector.
+func isPartialBatch(actions []modules.DownloadAction) bool {
+	for _, action := range actions {
+		if action.Offset != 0 || action.Length != modules.SectorSize {
+			return true
+		}
+	}
+	return false
+}
+
+// readPartialSectors reads the payload and Merkle range proofs sent by the
+// host in response to a batch of download actions that includes partial
+// sectors, and verifies that the data belongs to the requested sectors. Full
+// sectors are verified against their Merkle root directly.
+func readPartialSectors(r io.Reader, actions []modules.DownloadAction) ([][]byte, error) {
+	var totalSize uint64
+	for _, action := range actions {
+		totalSize += action.Length
+	}
+	var payload [][]byte
+	if err := encoding.ReadObject(r, &payload, totalSize+8*uint64(len(actions)+1)); err != nil {
+		return nil, err
+	} else if len(payload) != len(actions) {
+		return nil, errors.New("host did not send enough sector data")
+	}
+	var proofs [][]crypto.Hash
+	maxProofLen := uint64(len(actions)) * (modules.SectorSize/crypto.SegmentSize*crypto.HashSize + 8)
+	if err := encoding.ReadObject(r, &proofs, maxProofLen+8); err != nil {
+		return nil, err
+	} else if len(proofs) != len(actions) {
+		return nil, errors.New("host did not send a range proof for every request")
+	}
+	for i, action := range actions {
+		if uint64(len(payload[i])) != action.Length {
+			return nil, errors.New("host did not send enough sector data")
+		}
+		if !isPartialBatch(actions[i : i+1]) {
+			if crypto.MerkleRoot(payload[i]) != action.MerkleRoot {
+				return nil, errors.New("host sent bad sector data")
+			}
+			continue
+		}
+		start := action.Offset / crypto.SegmentSize
+		end := (action.Offset + action.Length) / crypto.SegmentSize
+		if !crypto.VerifyRangeProof(payload[i], proofs[i], start, end, modules.SectorSize/crypto.SegmentSize, action.MerkleRoot) {
+			return nil, errors.New("host sent data with an invalid range proof")
+		}
+	}
+	return payload, nil
+}
+
+// interrupted returns true if Interrupt has been called.
+func (hd *Downloader) interrupted() bool {
+	select {
+	case <-hd.interruptChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// Interrupt aborts the download that is in progress, if any, by closing the
+// connection to the host. Unlike the other methods, Interrupt may be called
+// concurrently with a download. The Downloader cannot be used afterwards.
+func (hd *Downloader) Interrupt() error {
+	var err error
+	hd.interruptOnce.Do(func() {
+		close(hd.interruptChan)
+		err = hd.conn.Close()
+	})
+	return err
 }
 
 // shutdown terminates the revision loop and signals the goroutine spawned in
@@ -159,10 +417,10 @@ func NewDownloader(host modules.HostDBEntry, contract modules.RenterContract, hd
 	}()
 
 	// initiate download loop
-	conn, err := (&net.Dialer{
+	conn, err := modules.DialHostSession(&net.Dialer{
 		Cancel:  cancel,
 		Timeout: 15 * time.Second,
-	}).Dial("tcp", string(contract.NetAddress))
+	}, contract.NetAddress, contract.HostPublicKey, host.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -197,5 +455,7 @@ func NewDownloader(host modules.HostDBEntry, contract modules.RenterContract, hd
 		conn:      conn,
 		closeChan: closeChan,
 		hdb:       hdb,
+
+		interruptChan: make(chan struct{}),
 	}, nil
 }
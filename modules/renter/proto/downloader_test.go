@@ -0,0 +1,56 @@
+package proto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestReadSectors tests that readSectors verifies the sectors sent by the
+// host, and rejects responses that contain more data than was requested.
+func TestReadSectors(t *testing.T) {
+	sectors := [][]byte{
+		fastrand.Bytes(int(modules.SectorSize)),
+		fastrand.Bytes(int(modules.SectorSize)),
+	}
+	roots := []crypto.Hash{
+		crypto.MerkleRoot(sectors[0]),
+		crypto.MerkleRoot(sectors[1]),
+	}
+
+	// A well-formed response should be read completely.
+	buf := new(bytes.Buffer)
+	encoding.WriteObject(buf, sectors)
+	var read [][]byte
+	err := readSectors(buf, roots, func(sector []byte) {
+		read = append(read, sector)
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(read) != 2 || !bytes.Equal(read[0], sectors[0]) || !bytes.Equal(read[1], sectors[1]) {
+		t.Fatal("sectors were not read correctly")
+	} else if buf.Len() != 0 {
+		t.Fatal("response was not read completely")
+	}
+
+	// A response with trailing data should be rejected.
+	payload := append(encoding.Marshal(sectors), 0)
+	buf.Reset()
+	encoding.WritePrefix(buf, payload)
+	err = readSectors(buf, roots, func([]byte) {})
+	if err == nil {
+		t.Fatal("expected trailing data to be rejected")
+	}
+
+	// A response with too few sectors should be rejected.
+	buf.Reset()
+	encoding.WriteObject(buf, sectors[:1])
+	err = readSectors(buf, roots, func([]byte) {})
+	if err == nil {
+		t.Fatal("expected a short response to be rejected")
+	}
+}
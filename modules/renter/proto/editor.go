@@ -77,11 +77,15 @@ func (he *Editor) Close() error {
 // host for approval. If negotiation is successful, it updates the underlying
 // Contract.
 func (he *Editor) runRevisionIteration(actions []modules.RevisionAction, rev types.FileContractRevision, newRoots []crypto.Hash) (err error) {
+	// The revision was priced using he.host, and is compared against the
+	// settings that the host sends when the revision starts.
+	current := he.host.HostExternalSettings
 	defer func() {
-		// Increase Successful/Failed interactions accordingly
-		if err != nil {
+		// Increase Successful/Failed interactions accordingly. A price
+		// rejection is not the host's fault if the host raised its prices.
+		if err != nil && hostFault(err, he.host.HostExternalSettings, current) {
 			he.hdb.IncrementFailedInteractions(he.contract.HostPublicKey)
-		} else {
+		} else if err == nil {
 			he.hdb.IncrementSuccessfulInteractions(he.contract.HostPublicKey)
 		}
 
@@ -91,7 +95,8 @@ func (he *Editor) runRevisionIteration(actions []modules.RevisionAction, rev typ
 
 	// initiate revision
 	extendDeadline(he.conn, modules.NegotiateSettingsTime)
-	if err := startRevision(he.conn, he.host); err != nil {
+	current, err = startRevision(he.conn, he.host)
+	if err != nil {
 		return err
 	}
 
@@ -268,7 +273,7 @@ func NewEditor(host modules.HostDBEntry, contract modules.RenterContract, curren
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
 		// a revision mismatch is not necessarily the host's fault
-		if err != nil && !IsRevisionMismatch(err) {
+		if err != nil && !IsRevisionMismatch(err) && hostFault(err, host.HostExternalSettings, host.HostExternalSettings) {
 			hdb.IncrementFailedInteractions(contract.HostPublicKey)
 		} else if err == nil {
 			hdb.IncrementSuccessfulInteractions(contract.HostPublicKey)
@@ -276,10 +281,10 @@ func NewEditor(host modules.HostDBEntry, contract modules.RenterContract, curren
 	}()
 
 	// initiate revision loop
-	conn, err := (&net.Dialer{
+	conn, err := modules.DialHostSession(&net.Dialer{
 		Cancel:  cancel,
 		Timeout: 15 * time.Second,
-	}).Dial("tcp", string(contract.NetAddress))
+	}, contract.NetAddress, contract.HostPublicKey, host.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -22,8 +22,11 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	// Extract vars from params, for convenience.
 	host, funding, startHeight, endHeight, refundAddress := params.Host, params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
 
-	// Create our key.
-	ourSK, ourPK := crypto.GenerateKeyPair()
+	// Create our key, unless one was provided.
+	ourSK, ourPK := params.SecretKey, params.SecretKey.PublicKey()
+	if ourSK == (crypto.SecretKey{}) {
+		ourSK, ourPK = crypto.GenerateKeyPair()
+	}
 	// Create unlock conditions.
 	uc := types.UnlockConditions{
 		PublicKeys: []types.SiaPublicKey{
@@ -92,6 +95,10 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 		return modules.RenterContract{}, err
 	}
 	txnBuilder.AddFileContract(fc)
+	// Add the identifier, if any.
+	if len(params.Identifier) > 0 {
+		txnBuilder.AddArbitraryData(params.Identifier)
+	}
 	// Add miner fee.
 	txnBuilder.AddMinerFee(txnFee)
 
@@ -100,10 +107,13 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	txnSet := append(parentTxns, txn)
 
 	// Increase Successful/Failed interactions accordingly
+	offered := host.HostExternalSettings
 	defer func() {
-		if err != nil {
+		// A price rejection is not the host's fault if the settings that the
+		// host sends have higher prices than the ones the offer was based on.
+		if err != nil && hostFault(err, offered, host.HostExternalSettings) {
 			hdb.IncrementFailedInteractions(host.PublicKey)
-		} else {
+		} else if err == nil {
 			hdb.IncrementSuccessfulInteractions(host.PublicKey)
 		}
 	}()
@@ -113,7 +123,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 		Cancel:  cancel,
 		Timeout: connTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, err := modules.DialHostSession(dialer, host.NetAddress, host.PublicKey, host.Version)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -150,7 +160,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 
 	// Read acceptance and txn signed by host.
 	if err = modules.ReadNegotiationAcceptance(conn); err != nil {
-		return modules.RenterContract{}, errors.New("host did not accept our proposed contract: " + err.Error())
+		return modules.RenterContract{}, hostRejection("host did not accept our proposed contract: ", err)
 	}
 	// Host now sends any new parent transactions, inputs and outputs that
 	// were added to the transaction.
@@ -231,7 +241,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	// Read the host acceptance and signatures.
 	err = modules.ReadNegotiationAcceptance(conn)
 	if err != nil {
-		return modules.RenterContract{}, errors.New("host did not accept our signatures: " + err.Error())
+		return modules.RenterContract{}, hostRejection("host did not accept our signatures: ", err)
 	}
 	var hostSigs []types.TransactionSignature
 	if err = encoding.ReadObject(conn, &hostSigs, 2e3); err != nil {
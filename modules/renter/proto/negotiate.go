@@ -17,13 +17,13 @@ func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.
 
 // startRevision is run at the beginning of each revision iteration. It reads
 // the host's settings confirms that the values are acceptable, and writes an acceptance.
-func startRevision(conn net.Conn, host modules.HostDBEntry) error {
+func startRevision(conn net.Conn, host modules.HostDBEntry) (modules.HostExternalSettings, error) {
 	// verify the host's settings and confirm its identity
-	_, err := verifySettings(conn, host)
+	recvHost, err := verifySettings(conn, host)
 	if err != nil {
-		return err
+		return modules.HostExternalSettings{}, err
 	}
-	return modules.WriteNegotiationAcceptance(conn)
+	return recvHost.HostExternalSettings, modules.WriteNegotiationAcceptance(conn)
 }
 
 // startDownload is run at the beginning of each download iteration. It reads
@@ -69,47 +69,58 @@ func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntr
 // verifyRecentRevision confirms that the host and contractor agree upon the current
 // state of the contract being revised.
 func verifyRecentRevision(conn net.Conn, contract modules.RenterContract, hostVersion string) error {
+	lastRevision, hostSignatures, err := getRecentRevision(conn, contract.ID, contract.SecretKey, hostVersion)
+	if err != nil {
+		return err
+	}
+	// Check that the unlock hashes match; if they do not, something is
+	// seriously wrong. Otherwise, check that the revision numbers match.
+	if lastRevision.UnlockConditions.UnlockHash() != contract.LastRevision.UnlockConditions.UnlockHash() {
+		return errors.New("unlock conditions do not match")
+	} else if lastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		return &recentRevisionError{contract.LastRevision.NewRevisionNumber, lastRevision.NewRevisionNumber}
+	}
+	// NOTE: we can fake the blockheight here because it doesn't affect
+	// verification; it just needs to be above the fork height and below the
+	// contract expiration (which was checked earlier).
+	return modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, contract.FileContract.WindowStart-1)
+}
+
+// getRecentRevision proves ownership of the contract to the host, and reads
+// the host's most recent revision of the contract along with the signatures
+// that validate it.
+func getRecentRevision(conn net.Conn, id types.FileContractID, secretKey crypto.SecretKey, hostVersion string) (types.FileContractRevision, []types.TransactionSignature, error) {
 	// send contract ID
-	if err := encoding.WriteObject(conn, contract.ID); err != nil {
-		return errors.New("couldn't send contract ID: " + err.Error())
+	if err := encoding.WriteObject(conn, id); err != nil {
+		return types.FileContractRevision{}, nil, errors.New("couldn't send contract ID: " + err.Error())
 	}
 	// read challenge
 	var challenge crypto.Hash
 	if err := encoding.ReadObject(conn, &challenge, 32); err != nil {
-		return errors.New("couldn't read challenge: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read challenge: " + err.Error())
 	}
 	if build.VersionCmp(hostVersion, "1.3.0") >= 0 {
 		crypto.SecureWipe(challenge[:16])
 	}
 	// sign and return
-	sig := crypto.SignHash(challenge, contract.SecretKey)
+	sig := crypto.SignHash(challenge, secretKey)
 	if err := encoding.WriteObject(conn, sig); err != nil {
-		return errors.New("couldn't send challenge response: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't send challenge response: " + err.Error())
 	}
 	// read acceptance
 	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
-		return errors.New("host did not accept revision request: " + err.Error())
+		return types.FileContractRevision{}, nil, hostRejection("host did not accept revision request: ", err)
 	}
 	// read last revision and signatures
 	var lastRevision types.FileContractRevision
 	var hostSignatures []types.TransactionSignature
 	if err := encoding.ReadObject(conn, &lastRevision, 2048); err != nil {
-		return errors.New("couldn't read last revision: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read last revision: " + err.Error())
 	}
 	if err := encoding.ReadObject(conn, &hostSignatures, 2048); err != nil {
-		return errors.New("couldn't read host signatures: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read host signatures: " + err.Error())
 	}
-	// Check that the unlock hashes match; if they do not, something is
-	// seriously wrong. Otherwise, check that the revision numbers match.
-	if lastRevision.UnlockConditions.UnlockHash() != contract.LastRevision.UnlockConditions.UnlockHash() {
-		return errors.New("unlock conditions do not match")
-	} else if lastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
-		return &recentRevisionError{contract.LastRevision.NewRevisionNumber, lastRevision.NewRevisionNumber}
-	}
-	// NOTE: we can fake the blockheight here because it doesn't affect
-	// verification; it just needs to be above the fork height and below the
-	// contract expiration (which was checked earlier).
-	return modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, contract.FileContract.WindowStart-1)
+	return lastRevision, hostSignatures, nil
 }
 
 // negotiateRevision sends a revision and actions to the host for approval,
@@ -134,7 +145,7 @@ func negotiateRevision(conn net.Conn, rev types.FileContractRevision, secretKey
 	}
 	// read acceptance
 	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
-		return types.Transaction{}, errors.New("host did not accept revision: " + err.Error())
+		return types.Transaction{}, hostRejection("host did not accept revision: ", err)
 	}
 
 	// send the new transaction signature
@@ -146,7 +157,7 @@ func negotiateRevision(conn net.Conn, rev types.FileContractRevision, secretKey
 	// the revision, but return the error anyway.
 	responseErr := modules.ReadNegotiationAcceptance(conn)
 	if responseErr != nil && responseErr != modules.ErrStopResponse {
-		return types.Transaction{}, errors.New("host did not accept transaction signature: " + responseErr.Error())
+		return types.Transaction{}, hostRejection("host did not accept transaction signature: ", responseErr)
 	}
 	var hostSig types.TransactionSignature
 	if err := encoding.ReadObject(conn, &hostSig, 16e3); err != nil {
@@ -62,3 +62,58 @@ func TestNegotiateRevisionStopResponse(t *testing.T) {
 	}
 	rConn.Close()
 }
+
+// TestHostRejection checks that rejections with error codes are turned into
+// typed errors, and that only price rejections which the renter can verify are
+// not held against the host.
+func TestHostRejection(t *testing.T) {
+	err := hostRejection("host did not accept revision: ", &modules.NegotiationError{
+		Code:    modules.NegotiationErrorPrice,
+		Message: "price too low",
+	})
+	if err.Error() != "host did not accept revision: price too low" {
+		t.Fatal("rejection has the wrong message:", err)
+	}
+	if code, ok := RejectionCode(err); !ok || code != modules.NegotiationErrorPrice {
+		t.Fatal("rejection has the wrong code:", code)
+	}
+	// A price rejection is only excused if the host's settings really have
+	// higher prices than the ones the renter's offer was based on.
+	offered := modules.HostExternalSettings{
+		StoragePrice: types.NewCurrency64(10),
+		Collateral:   types.NewCurrency64(20),
+	}
+	if !hostFault(err, offered, offered) {
+		t.Fatal("price rejections of offers at the host's prices should be held against the host")
+	}
+	raised := offered
+	raised.StoragePrice = types.NewCurrency64(11)
+	if hostFault(err, offered, raised) {
+		t.Fatal("price rejections should not be held against the host after a price increase")
+	}
+	lowered := offered
+	lowered.Collateral = types.NewCurrency64(19)
+	if hostFault(err, offered, lowered) {
+		t.Fatal("price rejections should not be held against the host after a collateral decrease")
+	}
+
+	// Rejections for any other reason are held against the host.
+	for _, code := range []modules.NegotiationErrorCode{modules.NegotiationErrorStorage, modules.NegotiationErrorBadContract, modules.NegotiationErrorRevision} {
+		err = hostRejection("host did not accept revision: ", &modules.NegotiationError{
+			Code:    code,
+			Message: "rejected",
+		})
+		if !hostFault(err, offered, raised) {
+			t.Fatal("rejections should be held against the host:", code)
+		}
+	}
+
+	// Rejections without a code are plain errors.
+	err = hostRejection("host did not accept revision: ", errors.New("old host"))
+	if _, ok := RejectionCode(err); ok {
+		t.Fatal("uncoded rejection should not have a code")
+	}
+	if !hostFault(err, offered, raised) {
+		t.Fatal("uncoded rejections should be held against the host")
+	}
+}
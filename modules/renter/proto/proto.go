@@ -1,6 +1,7 @@
 package proto
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/NebulousLabs/Sia/crypto"
@@ -11,6 +12,7 @@ import (
 // Dependencies.
 type (
 	transactionBuilder interface {
+		AddArbitraryData([]byte) uint64
 		AddFileContract(types.FileContract) uint64
 		AddMinerFee(types.Currency) uint64
 		AddParents([]types.Transaction)
@@ -41,7 +43,16 @@ type ContractParams struct {
 	StartHeight   types.BlockHeight
 	EndHeight     types.BlockHeight
 	RefundAddress types.UnlockHash
-	// TODO: add optional keypair
+
+	// SecretKey is the renter's key for the contract. If it is left blank,
+	// FormContract generates a random key. Renew always uses the key of the
+	// contract being renewed.
+	SecretKey crypto.SecretKey
+
+	// Identifier is optional arbitrary data that is added to the contract
+	// transaction, allowing the renter to find the contract on the
+	// blockchain.
+	Identifier []byte
 }
 
 // A revisionSaver is called just before we send our revision signature to the host; this
@@ -64,3 +75,63 @@ func IsRevisionMismatch(err error) bool {
 	_, ok := err.(*recentRevisionError)
 	return ok
 }
+
+// A HostRejectionError occurs if the host rejects a negotiation with a
+// structured error code. The code indicates why the negotiation was rejected.
+type HostRejectionError struct {
+	Code modules.NegotiationErrorCode
+	msg  string
+}
+
+func (e *HostRejectionError) Error() string {
+	return e.msg
+}
+
+// hostRejection prefixes err with context. If err is a rejection with an
+// error code, the code is preserved in a *HostRejectionError.
+func hostRejection(context string, err error) error {
+	if ne, ok := err.(*modules.NegotiationError); ok {
+		return &HostRejectionError{
+			Code: ne.Code,
+			msg:  context + ne.Error(),
+		}
+	}
+	return errors.New(context + err.Error())
+}
+
+// RejectionCode returns the error code of a host rejection, and whether err is
+// a host rejection with an error code.
+func RejectionCode(err error) (modules.NegotiationErrorCode, bool) {
+	switch e := err.(type) {
+	case *HostRejectionError:
+		return e.Code, true
+	case *modules.NegotiationError:
+		return e.Code, true
+	default:
+		return modules.NegotiationErrorUnknown, false
+	}
+}
+
+// hostFault returns true if err should count as a failed interaction with the
+// host. The error codes of rejections are chosen by the host, so a rejection is
+// only excused if the renter can verify the reason: a price rejection is not
+// held against the host if the settings that the host sent during the
+// negotiation are less favorable than the settings that the renter's offer was
+// based on. Every other rejection is a failure, and callers log its code.
+func hostFault(err error, offered, current modules.HostExternalSettings) bool {
+	if code, ok := RejectionCode(err); ok && code == modules.NegotiationErrorPrice {
+		return !pricesIncreased(offered, current)
+	}
+	return true
+}
+
+// pricesIncreased returns true if the current settings of a host charge more
+// or offer less collateral than the settings that an offer was based on.
+func pricesIncreased(offered, current modules.HostExternalSettings) bool {
+	return current.ContractPrice.Cmp(offered.ContractPrice) > 0 ||
+		current.StoragePrice.Cmp(offered.StoragePrice) > 0 ||
+		current.UploadBandwidthPrice.Cmp(offered.UploadBandwidthPrice) > 0 ||
+		current.DownloadBandwidthPrice.Cmp(offered.DownloadBandwidthPrice) > 0 ||
+		current.Collateral.Cmp(offered.Collateral) < 0 ||
+		current.MaxCollateral.Cmp(offered.MaxCollateral) < 0
+}
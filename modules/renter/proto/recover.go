@@ -0,0 +1,64 @@
+package proto
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// RecoverRevision retrieves the most recent revision of a contract from the
+// host, proving ownership of the contract with secretKey. It is used to
+// rebuild contracts that the renter has lost track of. The returned
+// transaction contains the revision and the signatures that validate it.
+func RecoverRevision(host modules.HostDBEntry, fc types.FileContract, id types.FileContractID, secretKey crypto.SecretKey, cancel <-chan struct{}) (_ types.Transaction, err error) {
+	conn, err := modules.DialHostSession(&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}, host.NetAddress, host.PublicKey, host.Version)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	defer conn.Close()
+
+	// The revision is requested through the download RPC, which sends the
+	// most recent revision before entering the download loop.
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCDownload); err != nil {
+		return types.Transaction{}, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	lastRevision, signatures, err := getRecentRevision(conn, id, secretKey, host.Version)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	// Leave the download loop gracefully; errors are not important here.
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	_, _ = verifySettings(conn, host)
+	_ = modules.WriteNegotiationStop(conn)
+	extendDeadline(conn, time.Hour)
+
+	return verifyRecoveredRevision(fc, id, lastRevision, signatures)
+}
+
+// verifyRecoveredRevision checks that a revision sent by the host belongs to
+// the contract and is signed correctly, and returns the revision transaction.
+func verifyRecoveredRevision(fc types.FileContract, id types.FileContractID, rev types.FileContractRevision, signatures []types.TransactionSignature) (types.Transaction, error) {
+	if rev.ParentID != id {
+		return types.Transaction{}, errors.New("host sent a revision for the wrong contract")
+	} else if rev.UnlockConditions.UnlockHash() != fc.UnlockHash {
+		return types.Transaction{}, errors.New("unlock conditions do not match")
+	}
+	err := modules.VerifyFileContractRevisionTransactionSignatures(rev, signatures, fc.WindowStart-1)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	return types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{rev},
+		TransactionSignatures: signatures,
+	}, nil
+}
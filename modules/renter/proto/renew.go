@@ -89,6 +89,10 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 		return modules.RenterContract{}, err
 	}
 	txnBuilder.AddFileContract(fc)
+	// add the identifier, if any
+	if len(params.Identifier) > 0 {
+		txnBuilder.AddArbitraryData(params.Identifier)
+	}
 	// add miner fee
 	txnBuilder.AddMinerFee(txnFee)
 
@@ -97,9 +101,12 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 	txnSet := append(parentTxns, txn)
 
 	// Increase Successful/Failed interactions accordingly
+	offered := host.HostExternalSettings
 	defer func() {
-		// A revision mismatch might not be the host's fault.
-		if err != nil && !IsRevisionMismatch(err) {
+		// A revision mismatch might not be the host's fault, and neither is a
+		// price rejection if the settings that the host sends have higher
+		// prices than the ones the offer was based on.
+		if err != nil && !IsRevisionMismatch(err) && hostFault(err, offered, host.HostExternalSettings) {
 			hdb.IncrementFailedInteractions(contract.HostPublicKey)
 		} else if err == nil {
 			hdb.IncrementSuccessfulInteractions(contract.HostPublicKey)
@@ -111,7 +118,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 		Cancel:  cancel,
 		Timeout: connTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, err := modules.DialHostSession(dialer, host.NetAddress, host.PublicKey, host.Version)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -153,7 +160,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 
 	// read acceptance and txn signed by host
 	if err = modules.ReadNegotiationAcceptance(conn); err != nil {
-		return modules.RenterContract{}, errors.New("host did not accept our proposed contract: " + err.Error())
+		return modules.RenterContract{}, hostRejection("host did not accept our proposed contract: ", err)
 	}
 	// host now sends any new parent transactions, inputs and outputs that
 	// were added to the transaction
@@ -234,7 +241,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 	// Read the host acceptance and signatures.
 	err = modules.ReadNegotiationAcceptance(conn)
 	if err != nil {
-		return modules.RenterContract{}, errors.New("host did not accept our signatures: " + err.Error())
+		return modules.RenterContract{}, hostRejection("host did not accept our signatures: ", err)
 	}
 	var hostSigs []types.TransactionSignature
 	if err = encoding.ReadObject(conn, &hostSigs, 2e3); err != nil {
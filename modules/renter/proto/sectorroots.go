@@ -0,0 +1,101 @@
+package proto
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// ErrSectorRootsUnsupported is returned by SectorRoots if the host is too old
+// to support RPCSectorRoots.
+var ErrSectorRootsUnsupported = errors.New("host does not support listing sector roots")
+
+// SectorRoots retrieves the most recent revision of a contract and all of its
+// sector roots from the host, proving ownership of the contract with
+// secretKey. The roots are requested in pages, and are verified against the
+// NewFileMerkleRoot of the revision. The returned transaction contains the
+// revision and the signatures that validate it.
+func SectorRoots(host modules.HostDBEntry, fc types.FileContract, id types.FileContractID, secretKey crypto.SecretKey, cancel <-chan struct{}) (types.Transaction, []crypto.Hash, error) {
+	if build.VersionCmp(host.Version, sectorRootsVersion) < 0 {
+		return types.Transaction{}, nil, ErrSectorRootsUnsupported
+	}
+	conn, err := modules.DialHostSession(&net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}, host.NetAddress, host.PublicKey, host.Version)
+	if err != nil {
+		return types.Transaction{}, nil, err
+	}
+	defer conn.Close()
+
+	// Authenticate with the host and get the most recent revision.
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCSectorRoots); err != nil {
+		return types.Transaction{}, nil, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	lastRevision, signatures, err := getRecentRevision(conn, id, secretKey, host.Version)
+	if err != nil {
+		return types.Transaction{}, nil, err
+	}
+	revisionTxn, err := verifyRecoveredRevision(fc, id, lastRevision, signatures)
+	if err != nil {
+		return types.Transaction{}, nil, err
+	}
+
+	// Request the roots one page at a time.
+	numRoots := lastRevision.NewFileSize / modules.SectorSize
+	roots := make([]crypto.Hash, 0, numRoots)
+	for uint64(len(roots)) < numRoots {
+		req := modules.SectorRootsRequest{
+			Offset:   uint64(len(roots)),
+			NumRoots: numRoots - uint64(len(roots)),
+		}
+		if req.NumRoots > sectorRootsPageSize {
+			req.NumRoots = sectorRootsPageSize
+		}
+		page, err := requestSectorRoots(conn, req)
+		if err != nil {
+			return types.Transaction{}, nil, err
+		}
+		roots = append(roots, page...)
+	}
+
+	// Tell the host that we are finished; the error is not important.
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	_ = modules.WriteNegotiationStop(conn)
+	extendDeadline(conn, time.Hour)
+
+	if cachedMerkleRoot(roots) != lastRevision.NewFileMerkleRoot {
+		return types.Transaction{}, nil, errors.New("host sent sector roots that do not match the contract's Merkle root")
+	}
+	return revisionTxn, roots, nil
+}
+
+// requestSectorRoots performs a single iteration of the sector roots loop,
+// requesting one page of roots from the host.
+func requestSectorRoots(conn net.Conn, req modules.SectorRootsRequest) ([]crypto.Hash, error) {
+	extendDeadline(conn, modules.NegotiateDownloadTime)
+	if err := modules.WriteNegotiationAcceptance(conn); err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(conn, req); err != nil {
+		return nil, err
+	}
+	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
+		return nil, errors.New("host rejected sector roots request: " + err.Error())
+	}
+	var page []crypto.Hash
+	if err := encoding.ReadObject(conn, &page, 8+req.NumRoots*crypto.HashSize); err != nil {
+		return nil, err
+	}
+	if uint64(len(page)) != req.NumRoots {
+		return nil, errors.New("host sent the wrong number of sector roots")
+	}
+	return page, nil
+}
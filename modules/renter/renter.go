@@ -24,6 +24,8 @@ var (
 	errNilCS         = errors.New("cannot create renter with nil consensus set")
 	errNilTpool      = errors.New("cannot create renter with nil transaction pool")
 	errNilHdb        = errors.New("cannot create renter with nil hostdb")
+
+	errNegativeOverdrive = errors.New("download overdrive cannot be negative")
 )
 
 var (
@@ -115,6 +117,14 @@ type hostContractor interface {
 	// allowing the retrieval of sectors.
 	Downloader(types.FileContractID, <-chan struct{}) (contractor.Downloader, error)
 
+	// InitRecoveryScan starts scanning the blockchain for contracts formed
+	// with keys derived from the renter seed.
+	InitRecoveryScan() error
+
+	// RecoveryScanStatus returns whether a recovery scan is in progress, and
+	// the height that the scan has reached.
+	RecoveryScanStatus() (bool, types.BlockHeight)
+
 	// ResolveID returns the most recent renewal of the specified ID.
 	ResolveID(types.FileContractID) types.FileContractID
 }
@@ -152,6 +162,10 @@ type Renter struct {
 	newRepairs    chan *file
 	workerPool    map[types.FileContractID]*worker
 
+	// downloadOverdrive is the number of extra pieces that are requested for
+	// each chunk that is downloaded.
+	downloadOverdrive int
+
 	// Utilities.
 	cs             modules.ConsensusSet
 	hostContractor hostContractor
@@ -201,6 +215,8 @@ func newRenter(cs modules.ConsensusSet, tpool modules.TransactionPool, hdb hostD
 		newDownloads: make(chan *download),
 		workerPool:   make(map[types.FileContractID]*worker),
 
+		downloadOverdrive: defaultDownloadOverdrive,
+
 		cs:             cs,
 		hostDB:         hdb,
 		hostContractor: hc,
@@ -298,16 +314,31 @@ func (r *Renter) PriceEstimation() modules.RenterPriceEstimation {
 
 // SetSettings will update the settings for the renter.
 func (r *Renter) SetSettings(s modules.RenterSettings) error {
-	err := r.hostContractor.SetAllowance(s.Allowance)
-	if err != nil {
-		return err
+	if s.DownloadOverdrive < 0 {
+		return errNegativeOverdrive
+	}
+
+	// Only touch the allowance if it has changed, as setting the allowance
+	// triggers contract maintenance.
+	if !allowancesEqual(s.Allowance, r.hostContractor.Allowance()) {
+		err := r.hostContractor.SetAllowance(s.Allowance)
+		if err != nil {
+			return err
+		}
 	}
 
 	contracts := r.hostContractor.Contracts()
 	id := r.mu.Lock()
 	r.updateWorkerPool(contracts)
+	r.downloadOverdrive = s.DownloadOverdrive
+	err := r.saveSync()
 	r.mu.Unlock(id)
-	return nil
+	return err
+}
+
+// allowancesEqual returns true if the two allowances are identical.
+func allowancesEqual(a, b modules.Allowance) bool {
+	return a.Funds.Cmp(b.Funds) == 0 && a.Hosts == b.Hosts && a.Period == b.Period && a.RenewWindow == b.RenewWindow
 }
 
 // hostdb passthroughs
@@ -324,9 +355,17 @@ func (r *Renter) EstimateHostScore(e modules.HostDBEntry) modules.HostScoreBreak
 // contractor passthroughs
 func (r *Renter) Contracts() []modules.RenterContract { return r.hostContractor.Contracts() }
 func (r *Renter) CurrentPeriod() types.BlockHeight    { return r.hostContractor.CurrentPeriod() }
+func (r *Renter) InitRecoveryScan() error             { return r.hostContractor.InitRecoveryScan() }
+func (r *Renter) RecoveryScanStatus() (bool, types.BlockHeight) {
+	return r.hostContractor.RecoveryScanStatus()
+}
 func (r *Renter) Settings() modules.RenterSettings {
+	id := r.mu.RLock()
+	overdrive := r.downloadOverdrive
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
-		Allowance: r.hostContractor.Allowance(),
+		Allowance:         r.hostContractor.Allowance(),
+		DownloadOverdrive: overdrive,
 	}
 }
 func (r *Renter) AllContracts() []modules.RenterContract {
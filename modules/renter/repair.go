@@ -11,6 +11,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules/renter/proto"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -77,11 +78,14 @@ type (
 		resultChan        chan finishedUpload
 	}
 
-	// downloadingChunk tracks the download progress of a remote repair download
+	// downloadingChunk tracks the download progress of a remote repair
+	// download. Several consecutive chunks may share a download, in which case
+	// offset is the position of the chunk's data within the buffer.
 	downloadingChunk struct {
 		startTime time.Time
 		buffer    *DownloadBufferWriter
 		d         *download
+		offset    uint64
 	}
 )
 
@@ -366,15 +370,38 @@ func (r *Renter) managedDownloadChunkData(rs *repairState, file *file, offset ui
 
 		// Download finished. Delete it from the state and return the data
 		delete(rs.downloadingChunks, chunkID)
-		return dc.buffer.Bytes(), dc.d.Err()
+		if err := dc.d.Err(); err != nil {
+			return nil, err
+		}
+		data := dc.buffer.Bytes()
+		end := dc.offset + file.chunkSize()
+		if end > uint64(len(data)) {
+			end = uint64(len(data))
+		}
+		return data[dc.offset:end], nil
 	}
 
 	// Don't initiate too many downloads to avoid using up all memory
 	if len(rs.downloadingChunks) >= maxScheduledDownloads {
 		return nil, nil
 	}
+	// The chunks that follow this one usually need to be downloaded as well.
+	// They are added to the same download, so that the download loop can
+	// fetch their pieces from each host in batches.
+	numChunks := uint64(1)
+	for numChunks < uint64(maxDownloadBatchPieces) && len(rs.downloadingChunks)+int(numChunks) < maxScheduledDownloads {
+		next := chunkID
+		next.index = chunkIndex + numChunks
+		_, incomplete := rs.incompleteChunks[next]
+		_, downloading := rs.downloadingChunks[next]
+		if next.index >= file.numChunks() || !incomplete || downloading {
+			break
+		}
+		numChunks++
+	}
+
 	// If the data is not yet downloaded initialize a new download
-	downloadSize := file.chunkSize()
+	downloadSize := file.chunkSize() * numChunks
 	if offset+downloadSize > file.size {
 		downloadSize = file.size - offset
 	}
@@ -396,10 +423,16 @@ func (r *Renter) managedDownloadChunkData(rs *repairState, file *file, offset ui
 	}()
 
 	// remember download in repair state
-	rs.downloadingChunks[chunkID] = &downloadingChunk{
-		startTime: time.Now(),
-		buffer:    buf,
-		d:         d,
+	startTime := time.Now()
+	for i := uint64(0); i < numChunks; i++ {
+		cid := chunkID
+		cid.index = chunkIndex + i
+		rs.downloadingChunks[cid] = &downloadingChunk{
+			startTime: startTime,
+			buffer:    buf,
+			d:         d,
+			offset:    i * file.chunkSize(),
+		}
 	}
 	return nil, nil
 }
@@ -589,8 +622,13 @@ func (r *Renter) managedWaitOnRepairWork(rs *repairState) {
 		return
 	}
 
-	// Log the error and retire the worker.
-	r.log.Debugln("Error while performing upload to", finishedUpload.workerID, "::", finishedUpload.err)
+	// Log the error and retire the worker. Rejections by the host are logged
+	// with the reason that the host gave.
+	if code, ok := proto.RejectionCode(finishedUpload.err); ok {
+		r.log.Printf("Host rejected an upload to %v (%v): %v\n", finishedUpload.workerID, code, finishedUpload.err)
+	} else {
+		r.log.Debugln("Error while performing upload to", finishedUpload.workerID, "::", finishedUpload.err)
+	}
 	delete(rs.activeWorkers, finishedUpload.workerID)
 
 	// Indicate in the set of incomplete chunks that this piece was not
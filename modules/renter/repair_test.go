@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestRepairDownloadBatch checks that the repair loop downloads consecutive
+// chunks that need to be repaired together, so that their pieces can be
+// fetched from each host in batches, and that each chunk receives its own
+// part of the downloaded data.
+func TestRepairDownloadBatch(t *testing.T) {
+	rsc, err := NewRSCode(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &file{
+		name:        "batch",
+		size:        5*128 - 10,
+		masterKey:   crypto.GenerateTwofishKey(),
+		erasureCode: rsc,
+		pieceSize:   64,
+	}
+	r := &Renter{
+		newDownloads: make(chan *download, 1),
+		tg:           new(sync.ThreadGroup),
+	}
+	rs := &repairState{
+		incompleteChunks:  make(map[chunkID]*chunkStatus),
+		downloadingChunks: make(map[chunkID]*downloadingChunk),
+	}
+	// Chunk 2 does not need to be repaired.
+	for _, i := range []uint64{0, 1, 3, 4} {
+		rs.incompleteChunks[chunkID{i, f.masterKey}] = &chunkStatus{}
+	}
+
+	// Downloading chunk 0 should also download chunk 1, but not chunk 2 or
+	// any chunk after it.
+	first := chunkID{0, f.masterKey}
+	second := chunkID{1, f.masterKey}
+	data, err := r.managedDownloadChunkData(rs, f, 0, 0, first)
+	if data != nil || err != nil {
+		t.Fatal("expected the download to be started:", err)
+	}
+	d := <-r.newDownloads
+	if d.offset != 0 || d.length != 2*f.chunkSize() || len(d.finishedChunks) != 2 {
+		t.Fatalf("unexpected download: offset %v, length %v, %v chunks", d.offset, d.length, len(d.finishedChunks))
+	}
+	if len(rs.downloadingChunks) != 2 || rs.downloadingChunks[second] == nil {
+		t.Fatal("chunks were not marked as downloading")
+	}
+	if rs.downloadingChunks[second].d != d || rs.downloadingChunks[second].offset != f.chunkSize() {
+		t.Fatal("second chunk does not share the download")
+	}
+
+	// Complete the download, and check that each chunk gets its own data.
+	downloaded := fastrand.Bytes(int(2 * f.chunkSize()))
+	rs.downloadingChunks[first].buffer.WriteAt(downloaded, 0)
+	d.downloadComplete = true
+	close(d.downloadFinished)
+	data, err = r.managedDownloadChunkData(rs, f, f.chunkSize(), 1, second)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, downloaded[f.chunkSize():]) {
+		t.Fatal("second chunk received the wrong data")
+	}
+	data, err = r.managedDownloadChunkData(rs, f, 0, 0, first)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, downloaded[:f.chunkSize()]) {
+		t.Fatal("first chunk received the wrong data")
+	}
+	if len(rs.downloadingChunks) != 0 {
+		t.Fatal("finished chunks were not removed from the repair state")
+	}
+
+	// The last chunk of the file is shorter than a full chunk.
+	last := chunkID{4, f.masterKey}
+	r.managedDownloadChunkData(rs, f, 3*f.chunkSize(), 3, chunkID{3, f.masterKey})
+	d = <-r.newDownloads
+	if d.length != f.size-3*f.chunkSize() || rs.downloadingChunks[last] == nil {
+		t.Fatal("last chunks were not downloaded together:", d.length)
+	}
+}
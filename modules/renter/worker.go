@@ -32,6 +32,7 @@ type (
 	finishedDownload struct {
 		chunkDownload *chunkDownload
 		data          []byte
+		duration      time.Duration
 		err           error
 		pieceIndex    uint64
 		workerID      types.FileContractID
@@ -74,10 +75,13 @@ type (
 		//
 		// A busy higher priority channel is able to entirely starve all of the
 		// channels with lower priority.
-		downloadChan         chan downloadWork // higher priority than all uploads
-		killChan             chan struct{}     // highest priority
-		priorityDownloadChan chan downloadWork // higher priority than downloads (used for user-initiated downloads)
-		uploadChan           chan uploadWork   // lowest priority
+		//
+		// Download work arrives in batches of pieces that are all fetched
+		// from the host using a single downloader.
+		downloadChan         chan []downloadWork // higher priority than all uploads
+		killChan             chan struct{}       // highest priority
+		priorityDownloadChan chan []downloadWork // higher priority than downloads (used for user-initiated downloads)
+		uploadChan           chan uploadWork     // lowest priority
 
 		// recentUploadFailure documents the most recent time that an upload
 		// has failed.
@@ -88,29 +92,132 @@ type (
 		// has failed.
 		recentDownloadFailure time.Time // Only modified by the primary download loop.
 
+		// downloadLatency is a moving average of the time it takes the worker
+		// to complete a piece download. Workers with a lower latency are
+		// preferred when pieces are assigned.
+		downloadLatency time.Duration // Only modified by the primary download loop.
+
 		// Utilities.
 		renter *Renter
 	}
 )
 
-// download will perform some download work.
-func (w *worker) download(dw downloadWork) {
+// download will perform some download work. All of the pieces in the batch
+// are fetched from the worker's host using a single downloader, which allows
+// the host to serve several pieces per contract revision.
+func (w *worker) download(batch []downloadWork) {
+	// Skip the pieces of any chunks that have already been recovered using
+	// pieces from other workers.
+	var work []downloadWork
+	for _, dw := range batch {
+		if dw.chunkDownload.cancelled() {
+			w.returnDownload(dw, nil, 0, errDownloadCancelled)
+			continue
+		}
+		work = append(work, dw)
+	}
+	if len(work) == 0 {
+		return
+	}
+
+	start := time.Now()
 	d, err := w.renter.hostContractor.Downloader(w.contractID, w.renter.tg.StopChan())
 	if err != nil {
-		go func() {
-			select {
-			case dw.resultChan <- finishedDownload{dw.chunkDownload, nil, err, dw.pieceIndex, w.contractID}:
-			case <-w.renter.tg.StopChan():
-			}
-		}()
+		for _, dw := range work {
+			w.returnDownload(dw, nil, time.Since(start), err)
+		}
 		return
 	}
 	defer d.Close()
 
-	data, err := d.Sector(dw.dataRoot)
+	// Pieces of chunks that only need part of their data are fetched in
+	// part. The first segment of the sector holds the nonce of the piece, and
+	// is fetched as well if the range does not include it.
+	var actions []modules.DownloadAction
+	firstAction := make([]int, len(work))
+	for i, dw := range work {
+		firstAction[i] = len(actions)
+		_, fetchOffset, fetchLength, partial := dw.chunkDownload.pieceRange()
+		if !partial {
+			actions = append(actions, modules.DownloadAction{
+				MerkleRoot: dw.dataRoot,
+				Offset:     0,
+				Length:     modules.SectorSize,
+			})
+			continue
+		}
+		if fetchOffset != 0 {
+			actions = append(actions, modules.DownloadAction{
+				MerkleRoot: dw.dataRoot,
+				Offset:     0,
+				Length:     crypto.SegmentSize,
+			})
+		}
+		actions = append(actions, modules.DownloadAction{
+			MerkleRoot: dw.dataRoot,
+			Offset:     fetchOffset,
+			Length:     fetchLength,
+		})
+	}
+	// Overdrive pieces are still being fetched when the chunk is recovered
+	// using pieces from faster workers. Once every chunk in the batch has been
+	// recovered, the download is interrupted so that no more bandwidth is
+	// spent on it.
+	done := make(chan struct{})
+	watcherDone := make(chan struct{})
+	var interrupted bool
+	go func() {
+		defer close(watcherDone)
+		for _, dw := range work {
+			select {
+			case <-dw.chunkDownload.cancel:
+			case <-done:
+				return
+			}
+		}
+		select {
+		case <-done:
+		default:
+			interrupted = true
+			d.Interrupt()
+		}
+	}()
+	data, err := d.PartialSectors(actions)
+	close(done)
+	<-watcherDone
+	if err != nil && interrupted {
+		err = errDownloadCancelled
+	}
+
+	// The duration is reported per piece so that workers which fetched a
+	// batch can be compared to workers which fetched a single piece.
+	duration := time.Since(start) / time.Duration(len(work))
+	for i, dw := range work {
+		lastAction := len(actions)
+		if i+1 < len(work) {
+			lastAction = firstAction[i+1]
+		}
+		if lastAction > len(data) {
+			w.returnDownload(dw, nil, duration, err)
+			continue
+		}
+		// A piece that was fetched in part is returned as its nonce,
+		// followed by the ciphertext that was fetched.
+		piece := data[firstAction[i]]
+		if lastAction-firstAction[i] == 2 {
+			nonce := data[firstAction[i]][:pieceNonceSize]
+			piece = append(append([]byte(nil), nonce...), data[firstAction[i]+1]...)
+		}
+		w.returnDownload(dw, piece, duration, nil)
+	}
+}
+
+// returnDownload sends the result of a piece download back to the download
+// loop.
+func (w *worker) returnDownload(dw downloadWork, data []byte, duration time.Duration, err error) {
 	go func() {
 		select {
-		case dw.resultChan <- finishedDownload{dw.chunkDownload, data, err, dw.pieceIndex, w.contractID}:
+		case dw.resultChan <- finishedDownload{dw.chunkDownload, data, duration, err, dw.pieceIndex, w.contractID}:
 		case <-w.renter.tg.StopChan():
 		}
 	}()
@@ -255,9 +362,9 @@ func (r *Renter) updateWorkerPool(contracts []modules.RenterContract) {
 				contract:   contract,
 				contractID: id,
 
-				downloadChan:         make(chan downloadWork, 1),
+				downloadChan:         make(chan []downloadWork, 1),
 				killChan:             make(chan struct{}),
-				priorityDownloadChan: make(chan downloadWork, 1),
+				priorityDownloadChan: make(chan []downloadWork, 1),
 				uploadChan:           make(chan uploadWork, 1),
 
 				renter: r,
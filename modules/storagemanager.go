@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"github.com/NebulousLabs/Sia/crypto"
 )
 
@@ -12,6 +14,13 @@ const (
 	// StorageManagerDir is standard name used for the directory that contains
 	// all of the storage manager files.
 	StorageManagerDir = "storagemanager"
+
+	// StorageTierFast and StorageTierSlow are the tiers that a storage folder
+	// can be tagged with. New sectors and frequently read sectors are placed
+	// on fast storage folders, and sectors that are rarely read are moved to
+	// slow storage folders. Storage folders are slow by default.
+	StorageTierFast = "fast"
+	StorageTierSlow = "slow"
 )
 
 type (
@@ -34,6 +43,16 @@ type (
 		SuccessfulReads  uint64 `json:"successfulreads"`
 		SuccessfulWrites uint64 `json:"successfulwrites"`
 
+		// CorruptSectors is the number of sectors in the folder that the
+		// background scrubber found to no longer match their Merkle root.
+		CorruptSectors uint64 `json:"corruptsectors"`
+
+		// Tier is the storage tier of the folder, either StorageTierFast or
+		// StorageTierSlow. HotSectors is the number of sectors in the folder
+		// that are read often enough to belong on the fast tier.
+		Tier       string `json:"tier"`
+		HotSectors uint64 `json:"hotsectors"`
+
 		// Certain operations on a storage folder can take a long time (Add,
 		// Remove, and Resize). The fields below indicate the progress of any
 		// long running operations that might be under way in the storage
@@ -42,6 +61,184 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// StorageScrubStatus reports the progress of the background scrubber,
+	// which periodically reads every sector and checks that it still matches
+	// its Merkle root. The set of corrupt sectors is persisted across
+	// restarts, and sectors are removed from it when they are found intact
+	// again or are removed from the host.
+	StorageScrubStatus struct {
+		Running           bool      `json:"running"`
+		SectorsScrubbed   uint64    `json:"sectorsscrubbed"`
+		SectorsTotal      uint64    `json:"sectorstotal"`
+		PassesCompleted   uint64    `json:"passescompleted"`
+		LastPassStarted   time.Time `json:"lastpassstarted"`
+		LastPassCompleted time.Time `json:"lastpasscompleted"`
+		NextPass          time.Time `json:"nextpass"`
+
+		// CorruptSectors is the number of corrupt sectors that have been
+		// found. CorruptSectorRoots lists the roots of the corrupt sectors
+		// that belong to a storage obligation, and is filled out by the host,
+		// as the storage manager does not keep track of sector roots.
+		CorruptSectors     uint64        `json:"corruptsectors"`
+		CorruptSectorRoots []crypto.Hash `json:"corruptsectorroots"`
+	}
+
+	// StorageConsistencyIssue describes a problem with a single sector slot
+	// found by the consistency checker. Referenced is set if the slot holds
+	// a sector that belongs to a storage obligation.
+	StorageConsistencyIssue struct {
+		StorageFolder uint16 `json:"storagefolder"`
+		Index         uint32 `json:"index"`
+		Problem       string `json:"problem"`
+		Referenced    bool   `json:"referenced"`
+	}
+
+	// StorageFolderCheck contains the result of checking a single storage
+	// folder. The sizes of the metadata and data files are compared against
+	// the sizes implied by the folder's capacity. OrphanedSlots are used
+	// slots that the sector location map does not point to,
+	// DoublyAllocatedSlots are slots holding a sector that is also stored in
+	// another slot, DanglingLocations are locations in the map that point to
+	// a slot that does not hold the sector, and TruncatedSlots are used slots
+	// that lie beyond the end of the data or metadata file.
+	StorageFolderCheck struct {
+		Index       uint16 `json:"index"`
+		Path        string `json:"path"`
+		Unavailable bool   `json:"unavailable"`
+
+		MetadataSize         int64 `json:"metadatasize"`
+		ExpectedMetadataSize int64 `json:"expectedmetadatasize"`
+		DataSize             int64 `json:"datasize"`
+		ExpectedDataSize     int64 `json:"expecteddatasize"`
+
+		UsedSlots            uint64 `json:"usedslots"`
+		OrphanedSlots        uint64 `json:"orphanedslots"`
+		DoublyAllocatedSlots uint64 `json:"doublyallocatedslots"`
+		DanglingLocations    uint64 `json:"danglinglocations"`
+		TruncatedSlots       uint64 `json:"truncatedslots"`
+	}
+
+	// StorageConsistencyReport contains the result of a consistency check of
+	// the storage manager. MissingSectors lists the roots of sectors that
+	// belong to a storage obligation but are not stored, UnreferencedSectors
+	// counts the stored sectors that belong to no obligation, and
+	// CountMismatches counts the sectors whose number of virtual sectors
+	// differs from the number of obligation references. Issues lists the
+	// first problems found with individual slots.
+	//
+	// If the check was run with repair, Repaired is set, and the sector
+	// location map was rebuilt from the metadata on disk. SectorsRecovered
+	// and SectorsDropped are the number of sectors that were added to and
+	// removed from the map. The other fields describe the state before the
+	// repair, except for the cross-check against the obligations, which is
+	// made against the rebuilt map.
+	StorageConsistencyReport struct {
+		Consistent bool                      `json:"consistent"`
+		Folders    []StorageFolderCheck      `json:"folders"`
+		Issues     []StorageConsistencyIssue `json:"issues"`
+
+		MissingSectors      []crypto.Hash `json:"missingsectors"`
+		UnreferencedSectors uint64        `json:"unreferencedsectors"`
+		CountMismatches     uint64        `json:"countmismatches"`
+
+		Repaired         bool   `json:"repaired"`
+		SectorsRecovered uint64 `json:"sectorsrecovered"`
+		SectorsDropped   uint64 `json:"sectorsdropped"`
+	}
+
+	// StorageCacheMetrics reports the state of the in-memory cache of
+	// recently read sectors. Capacity is the configured size of the cache and
+	// Size is the amount of sector data currently held, both in bytes.
+	StorageCacheMetrics struct {
+		Capacity  uint64 `json:"capacity"`
+		Size      uint64 `json:"size"`
+		Sectors   uint64 `json:"sectors"`
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+	}
+
+	// StoredSector describes where a physical sector is stored, and how many
+	// virtual sectors reference it. The storage manager only keeps track of
+	// salted sector ids, so when sectors are enumerated the root is filled
+	// out by the host, and is left empty for sectors that do not belong to
+	// any storage obligation.
+	StoredSector struct {
+		Root          crypto.Hash `json:"root"`
+		StorageFolder uint16      `json:"storagefolder"`
+		Index         uint32      `json:"index"`
+		Count         uint16      `json:"count"`
+	}
+
+	// StorageFolderUsage contains statistics about the sectors stored in a
+	// storage folder. PhysicalSectors is the number of sectors that take up
+	// space in the folder, and VirtualSectors is the total number of
+	// references to those sectors. CountDistribution maps a reference count
+	// to the number of physical sectors that have that count.
+	StorageFolderUsage struct {
+		Index             uint16            `json:"index"`
+		Path              string            `json:"path"`
+		PhysicalSectors   uint64            `json:"physicalsectors"`
+		VirtualSectors    uint64            `json:"virtualsectors"`
+		CountDistribution map[uint16]uint64 `json:"countdistribution"`
+	}
+
+	// WALOperation summarizes the operations of a single type that are part
+	// of a change in the storage manager's write-ahead log. Folders lists
+	// the indexes of the storage folders affected by the operations.
+	WALOperation struct {
+		Type    string   `json:"type"`
+		Count   uint64   `json:"count"`
+		Folders []uint16 `json:"folders"`
+	}
+
+	// WALChange summarizes a change in the write-ahead log. All operations
+	// in a change are applied together. Size is the number of bytes that the
+	// change takes up in the log.
+	WALChange struct {
+		Size       uint64         `json:"size"`
+		Operations []WALOperation `json:"operations"`
+	}
+
+	// WALInspection lists the changes found in a write-ahead log file, which
+	// are replayed when the storage manager starts. Changes that do not hold
+	// any operations are counted in TotalChanges but are not listed. Folders
+	// lists every storage folder affected by the listed changes.
+	WALInspection struct {
+		Path         string      `json:"path"`
+		Exists       bool        `json:"exists"`
+		Size         uint64      `json:"size"`
+		TotalChanges uint64      `json:"totalchanges"`
+		Changes      []WALChange `json:"changes"`
+		Folders      []uint16    `json:"folders"`
+	}
+
+	// WALCheckpoint describes a commit of the write-ahead log, after which
+	// the changes in the log are guaranteed to survive an unclean shutdown.
+	// Number counts the commits since startup, Changes is the number of
+	// changes with operations that were committed, and Size is the size of
+	// the committed log in bytes.
+	WALCheckpoint struct {
+		Number   uint64        `json:"number"`
+		Time     time.Time     `json:"time"`
+		Changes  uint64        `json:"changes"`
+		Size     uint64        `json:"size"`
+		Duration time.Duration `json:"duration"`
+	}
+
+	// WALStatus reports the state of the storage manager's write-ahead log.
+	// Size is the number of bytes written to the log since the last
+	// checkpoint, and PendingChanges lists the changes that will be
+	// committed at the next checkpoint. RecoveredChanges is the number of
+	// changes that were replayed at startup after an unclean shutdown.
+	WALStatus struct {
+		Size             uint64        `json:"size"`
+		SyncInterval     time.Duration `json:"syncinterval"`
+		PendingChanges   []WALChange   `json:"pendingchanges"`
+		LastCheckpoint   WALCheckpoint `json:"lastcheckpoint"`
+		RecoveredChanges uint64        `json:"recoveredchanges"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -72,6 +269,14 @@ type (
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CheckConsistency checks the metadata of every storage folder
+		// against the data file and the sector location map, and
+		// cross-checks the stored sectors against the references, which map
+		// the sector roots of the host's storage obligations to the number
+		// of times each root is referenced. Nothing is changed, the location
+		// map can only be repaired offline.
+		CheckConsistency(references map[crypto.Hash]uint64) (StorageConsistencyReport, error)
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -80,6 +285,12 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MoveStorageFolder moves a storage folder to a new path. The data and
+		// metadata files are copied to the new path and verified before the
+		// storage folder is switched over to them, and sectors can still be
+		// read from the folder while the move is in progress.
+		MoveStorageFolder(index uint16, newPath string) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -118,8 +329,64 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubStatus returns the progress of the background scrubber.
+		ScrubStatus() StorageScrubStatus
+
+		// CheckSector reads a sector from disk, bypassing the sector cache,
+		// and returns an error if the sector cannot be read or no longer
+		// matches its root.
+		CheckSector(sectorRoot crypto.Hash) error
+
+		// SectorCorrupt returns true if the scrubber found that the data
+		// stored for the sector no longer matches the sector root.
+		SectorCorrupt(sectorRoot crypto.Hash) bool
+
+		// SectorCacheMetrics returns the size and the hit and miss counts of
+		// the sector cache.
+		SectorCacheMetrics() StorageCacheMetrics
+
+		// FillSectorRoots fills in the root of each of the provided sectors
+		// whose root is among the provided roots.
+		FillSectorRoots(sectors []StoredSector, roots []crypto.Hash)
+
+		// SectorLocation returns the storage folder and index at which a
+		// sector is stored, along with its number of virtual sectors.
+		SectorLocation(sectorRoot crypto.Hash) (StoredSector, error)
+
+		// SetSectorCacheSize sets the maximum number of bytes of sector data
+		// that are kept in memory to serve repeated reads. A size of zero
+		// disables the cache.
+		SetSectorCacheSize(size uint64)
+
+		// SetStorageFolderTier sets the tier of a storage folder to either
+		// StorageTierFast or StorageTierSlow. Sectors are moved between the
+		// tiers in the background.
+		SetStorageFolderTier(index uint16, tier string) error
+
+		// StartScrub starts a scrub of every sector immediately, instead of
+		// waiting for the next scheduled scrub.
+		StartScrub() error
+
+		// StopScrub stops the scrub that is in progress. The next scheduled
+		// scrub will still take place.
+		StopScrub() error
+
+		// StorageFolderSectors returns up to 'limit' of the sectors stored in
+		// a storage folder, ordered by their index within the folder and
+		// starting at 'offset', along with the total number of sectors in
+		// the folder. The roots of the returned sectors are not filled out.
+		StorageFolderSectors(index uint16, offset, limit uint64) ([]StoredSector, uint64, error)
+
+		// StorageFolderUsage returns statistics about the sectors stored in
+		// each storage folder.
+		StorageFolderUsage() []StorageFolderUsage
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
+
+		// WALStatus returns the size of the write-ahead log, the changes that
+		// have not been committed yet, and the last committed checkpoint.
+		WALStatus() WALStatus
 	}
 )
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/api"
 	"github.com/NebulousLabs/Sia/modules"
@@ -36,9 +37,25 @@ Available settings:
      netaddress:           string
      windowsize:           blocks
 
+     maxdownloadspeed:    bytes / second
+     maxuploadspeed:      bytes / second
+     maxconnections:      int
+     maxconnectionsperip: int
+
+     metricssnapshotinterval: blocks
+     sectorcachesize:         bytes
+
      collateral:       currency
      collateralbudget: currency
      maxcollateral:    currency
+     feebumpcap:       currency
+
+     minduration:            blocks
+     mincontractpayout:      currency
+     maxcollateralratio:     float
+     maxcontractsperrenter:  int
+     collateralperiod:       blocks
+     periodcollateralbudget: currency
 
      mincontractprice:          currency
      mindownloadbandwidthprice: currency / TB
@@ -47,9 +64,21 @@ Available settings:
 
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
-hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
-hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
+Speeds can be specified with size units, e.g. 10MB for 10 megabytes per second.
+A speed or connection limit of 0 means no limit. A sectorcachesize of 0
+disables the sector cache. feebumpcap is the most the host will pay in fees to
+get a revision or storage proof confirmed; 0 disables fee bumping.
+
+The contract acceptance policy (minduration through periodcollateralbudget)
+refuses contracts that break any of its rules. A value of 0 disables a rule.
+periodcollateralbudget limits the collateral of the contracts formed in the
+last collateralperiod blocks.
+
+Durations (maxduration, windowsize, metricssnapshotinterval, minduration and
+collateralperiod) must be
+specified in either blocks (b), hours (h), days (d), or weeks (w). A block is
+approximately 10 minutes, so one hour is six blocks, a day is 144 blocks, and
+a week is 1008 blocks.
 
 For a description of each parameter, see doc/API.md.
 
@@ -72,10 +101,18 @@ Doing so will override the standard connectivity checks.`,
 		Run: hostannouncecmd,
 	}
 
+	hostContractsCmd = &cobra.Command{
+		Use:   "contracts",
+		Short: "View the host's storage obligations",
+		Long: `View the storage obligations of the host. Use the --status flag to only
+show obligations that are unresolved, rejected, succeeded or failed.`,
+		Run: wrap(hostcontractscmd),
+	}
+
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, move, resize, or set the tier of a storage folder",
+		Long:  "Add, remove, move, resize, or set the tier of a storage folder.",
 	}
 
 	hostFolderAddCmd = &cobra.Command{
@@ -85,6 +122,15 @@ Doing so will override the standard connectivity checks.`,
 		Run:   wrap(hostfolderaddcmd),
 	}
 
+	hostFolderMoveCmd = &cobra.Command{
+		Use:   "move [path] [newpath]",
+		Short: "Move a storage folder to a new path",
+		Long: `Move a storage folder to a new path, such as a folder on another disk. The
+folder's files are copied to the new path and verified before the host switches
+over to them, and the folder's data can still be downloaded during the move.`,
+		Run: wrap(hostfoldermovecmd),
+	}
+
 	hostFolderRemoveCmd = &cobra.Command{
 		Use:   "remove [path]",
 		Short: "Remove a storage folder from the host",
@@ -103,6 +149,15 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostFolderTierCmd = &cobra.Command{
+		Use:   "tier [path] [fast|slow]",
+		Short: "Set the storage tier of a storage folder",
+		Long: `Set the storage tier of a storage folder. New sectors and frequently
+downloaded sectors are placed on fast storage folders, and sectors that are
+rarely downloaded are moved to slow storage folders in the background.`,
+		Run: wrap(hostfoldertiercmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -117,6 +172,21 @@ deleting a sector may impact host revenue.`,
 sector may impact host revenue.`,
 		Run: wrap(hostsectordeletecmd),
 	}
+
+	hostWALCmd = &cobra.Command{
+		Use:   "wal",
+		Short: "View the status of the host's write-ahead log",
+		Long:  "View the status of the write-ahead log of the host's storage manager.",
+	}
+
+	hostWALStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "View the size and last checkpoint of the write-ahead log",
+		Long: `View the size and sync interval of the write-ahead log of the host's storage
+manager, the changes that have not been committed yet, and the last committed
+checkpoint.`,
+		Run: wrap(hostwalstatuscmd),
+	}
 )
 
 // hostcmd is the handler for the command `siac host`.
@@ -188,9 +258,24 @@ Host Internal Settings:
 	netaddress:           %v
 	windowsize:           %v Hours
 
+	maxdownloadspeed:    %v
+	maxuploadspeed:      %v
+	maxconnections:      %v
+	maxconnectionsperip: %v
+
+	sectorcachesize: %v
+
 	collateral:       %v / TB / Month
 	collateralbudget: %v
 	maxcollateral:    %v Per Contract
+	feebumpcap:       %v
+
+	minduration:            %v Blocks
+	mincontractpayout:      %v
+	maxcollateralratio:     %v
+	maxcontractsperrenter:  %v
+	collateralperiod:       %v Blocks
+	periodcollateralbudget: %v
 
 	mincontractprice:          %v
 	mindownloadbandwidthprice: %v / TB
@@ -223,6 +308,10 @@ RPC Stats:
 	Revise Calls:       %v
 	Settings Calls:     %v
 	FormContract Calls: %v
+
+	Rejected Connections (connection limit): %v
+	Rejected Connections (per-IP limit):     %v
+	Rejected Contracts (acceptance policy):  %v
 `,
 			connectabilityString,
 
@@ -231,9 +320,20 @@ RPC Stats:
 			filesizeUnits(int64(is.MaxReviseBatchSize)), netaddr,
 			is.WindowSize/6,
 
+			speedLimit(is.MaxDownloadSpeed), speedLimit(is.MaxUploadSpeed),
+			connLimit(is.MaxConnections), connLimit(is.MaxConnectionsPerIP),
+
+			filesizeUnits(int64(is.SectorCacheSize)),
+
 			currencyUnits(is.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.CollateralBudget),
 			currencyUnits(is.MaxCollateral),
+			currencyUnits(is.FeeBumpCap),
+
+			is.MinDuration, currencyUnits(is.MinContractPayout),
+			is.MaxCollateralRatio, connLimit(is.MaxContractsPerRenter),
+			is.CollateralPeriod,
+			currencyUnits(is.PeriodCollateralBudget),
 
 			currencyUnits(is.MinContractPrice),
 			currencyUnits(is.MinDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
@@ -258,7 +358,10 @@ RPC Stats:
 
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
-			nm.FormContractCalls)
+			nm.FormContractCalls,
+
+			nm.RejectedConnections, nm.RejectedIPConnections,
+			nm.PolicyRejections)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -294,13 +397,17 @@ RPC Stats:
 		return
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tPath\n")
+	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tTier\tPath\n")
 	for _, folder := range sg.Folders {
 		curSize := int64(folder.Capacity - folder.CapacityRemaining)
 		pctUsed := 100 * (float64(curSize) / float64(folder.Capacity))
-		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", filesizeUnits(curSize), filesizeUnits(int64(folder.Capacity)), pctUsed, folder.Path)
+		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\t%s\n", filesizeUnits(curSize), filesizeUnits(int64(folder.Capacity)), pctUsed, folder.Tier, folder.Path)
 	}
 	w.Flush()
+
+	sc := sg.SectorCache
+	fmt.Printf("\nSector Cache: %v of %v used, %v hits, %v misses\n",
+		filesizeUnits(int64(sc.Size)), filesizeUnits(int64(sc.Capacity)), sc.Hits, sc.Misses)
 }
 
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
@@ -309,7 +416,8 @@ func hostconfigcmd(param, value string) {
 	var err error
 	switch param {
 	// currency (convert to hastings)
-	case "collateralbudget", "maxcollateral", "mincontractprice":
+	case "collateralbudget", "maxcollateral", "feebumpcap", "mincontractprice",
+		"mincontractpayout", "periodcollateralbudget":
 		value, err = parseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -345,14 +453,27 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "windowsize", "metricssnapshotinterval",
+		"minduration", "collateralperiod":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
+	// bytes per second, and the sector cache size in bytes
+	case "maxdownloadspeed", "maxuploadspeed", "sectorcachesize":
+		if value == "0" {
+			break // no limit, or no sector cache
+		}
+		value, err = parseFilesize(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress",
+		"maxconnections", "maxconnectionsperip", "maxcollateralratio",
+		"maxcontractsperrenter":
 
 	// invalid settings
 	default:
@@ -428,6 +549,15 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldermovecmd moves a folder in the host to a new path.
+func hostfoldermovecmd(path, newpath string) {
+	err := post("/host/storage/folders/move", fmt.Sprintf("path=%s&newpath=%s", abs(path), abs(newpath)))
+	if err != nil {
+		die("Could not move folder:", err)
+	}
+	fmt.Printf("Moved folder %v to %v\n", path, newpath)
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	err := post("/host/storage/folders/remove", "path="+abs(path))
@@ -457,6 +587,50 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostfoldertiercmd sets the storage tier of a folder in the host.
+func hostfoldertiercmd(path, tier string) {
+	err := post("/host/storage/folders/tier", fmt.Sprintf("path=%s&tier=%s", abs(path), tier))
+	if err != nil {
+		die("Could not set folder tier:", err)
+	}
+	fmt.Printf("Set the tier of folder %v to %v\n", path, tier)
+}
+
+// hostcontractscmd is the handler for the command `siac host contracts`.
+// It lists the storage obligations of the host.
+func hostcontractscmd() {
+	var hc api.HostContractsGET
+	err := getAPI("/host/contracts?status="+hostContractsStatus, &hc)
+	if err != nil {
+		die("Could not get host contracts:", err)
+	}
+	if len(hc.Contracts) == 0 {
+		fmt.Println("No contracts found.")
+		return
+	}
+	fmt.Println("Contracts:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tStatus\tNegotiated\tExpiration\tProof Deadline\tData\tSectors\tUploaded\tDownloaded\tRevisions\tPotential Revenue\tRealized Revenue\tLocked Collateral")
+	for _, so := range hc.Contracts {
+		potentialRevenue := so.ContractCost.Add(so.PotentialDownloadRevenue).Add(so.PotentialStorageRevenue).Add(so.PotentialUploadRevenue)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%8s\t%8s\t%8s\n",
+			so.ObligationID,
+			so.Status,
+			so.NegotiationHeight,
+			so.ExpirationHeight,
+			so.ProofDeadline,
+			filesizeUnits(int64(so.DataSize)),
+			so.SectorRootsCount,
+			filesizeUnits(int64(so.BytesUploaded)),
+			filesizeUnits(int64(so.BytesDownloaded)),
+			so.RevisionCount,
+			currencyUnits(potentialRevenue),
+			currencyUnits(so.RealizedRevenue),
+			currencyUnits(so.LockedCollateral))
+	}
+	w.Flush()
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	err := post("/host/storage/sectors/delete/"+root, "")
@@ -465,3 +639,61 @@ func hostsectordeletecmd(root string) {
 	}
 	fmt.Println("Deleted sector", root)
 }
+
+// hostwalstatuscmd is the handler for the command `siac host wal status`.
+// It prints the status of the storage manager's write-ahead log.
+func hostwalstatuscmd() {
+	var ws api.StorageWALGET
+	err := getAPI("/host/storage/wal", &ws)
+	if err != nil {
+		die("Could not get WAL status:", err)
+	}
+	fmt.Printf(`WAL status:
+	Size:              %v
+	Sync Interval:     %v
+	Recovered Changes: %v
+`, filesizeUnits(int64(ws.Size)), ws.SyncInterval, ws.RecoveredChanges)
+
+	cp := ws.LastCheckpoint
+	if cp.Number == 0 {
+		fmt.Println("\nNo checkpoint has been committed yet.")
+	} else {
+		fmt.Printf(`
+Last checkpoint:
+	Number:   %v
+	Time:     %v
+	Changes:  %v
+	Size:     %v
+	Duration: %v
+`, cp.Number, cp.Time.Format(time.RFC822), cp.Changes, filesizeUnits(int64(cp.Size)), cp.Duration)
+	}
+
+	if len(ws.PendingChanges) == 0 {
+		fmt.Println("\nNo pending changes.")
+		return
+	}
+	fmt.Println("\nPending changes:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tSize\tOperations\tFolders")
+	for _, wc := range ws.PendingChanges {
+		var ops []string
+		folders := make(map[uint16]struct{})
+		for _, op := range wc.Operations {
+			ops = append(ops, fmt.Sprintf("%v x%v", op.Type, op.Count))
+			for _, folder := range op.Folders {
+				folders[folder] = struct{}{}
+			}
+		}
+		var sorted []int
+		for folder := range folders {
+			sorted = append(sorted, int(folder))
+		}
+		sort.Ints(sorted)
+		var indexes []string
+		for _, folder := range sorted {
+			indexes = append(indexes, fmt.Sprint(folder))
+		}
+		fmt.Fprintf(w, "\t%v\t%v\t%v\n", filesizeUnits(int64(wc.Size)), strings.Join(ops, ", "), strings.Join(indexes, ", "))
+	}
+	w.Flush()
+}
@@ -18,12 +18,13 @@ import (
 
 var (
 	// Flags.
-	addr              string // override default API address
-	initPassword      bool   // supply a custom password when creating a wallet
-	initForce         bool   // destroy and reencrypt the wallet on init if it already exists
-	hostVerbose       bool   // display additional host info
-	renterShowHistory bool   // Show download history in addition to download queue.
-	renterListVerbose bool   // Show additional info about uploaded files.
+	addr                string // override default API address
+	initPassword        bool   // supply a custom password when creating a wallet
+	initForce           bool   // destroy and reencrypt the wallet on init if it already exists
+	hostVerbose         bool   // display additional host info
+	hostContractsStatus string // only display host contracts with this status
+	renterShowHistory   bool   // Show download history in addition to download queue.
+	renterListVerbose   bool   // Show additional info about uploaded files.
 
 	// Globals.
 	rootCmd *cobra.Command // Root command cobra object, used by bash completion cmd.
@@ -249,10 +250,12 @@ func main() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostContractsCmd, hostFolderCmd, hostSectorCmd, hostWALCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderMoveCmd, hostFolderRemoveCmd, hostFolderResizeCmd, hostFolderTierCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostWALCmd.AddCommand(hostWALStatusCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
+	hostContractsCmd.Flags().StringVarP(&hostContractsStatus, "status", "s", "", "Only display contracts with this status (unresolved, rejected, succeeded or failed)")
 
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbViewCmd)
@@ -159,6 +159,23 @@ func parseCurrency(amount string) (string, error) {
 	return "", errors.New("amount is missing units; run 'wallet --help' for a list of units")
 }
 
+// speedLimit formats a bandwidth limit in bytes per second, where 0 means no
+// limit.
+func speedLimit(bytesPerSecond uint64) string {
+	if bytesPerSecond == 0 {
+		return "No Limit"
+	}
+	return filesizeUnits(int64(bytesPerSecond)) + "/s"
+}
+
+// connLimit formats a connection limit, where 0 means no limit.
+func connLimit(limit uint64) string {
+	if limit == 0 {
+		return "No Limit"
+	}
+	return fmt.Sprint(limit)
+}
+
 // yesNo returns "Yes" if b is true, and "No" if b is false.
 func yesNo(b bool) string {
 	if b {
@@ -278,6 +278,12 @@ func startDaemon(config Config) (err error) {
 		}()
 	}
 
+	// Give the host access to the renter's hostdb, which the automatic pricing
+	// engine uses to follow network prices.
+	if hp, ok := h.(*host.Host); ok && r != nil {
+		hp.SetHostDB(r)
+	}
+
 	// Create the Sia API
 	a := api.New(
 		config.Siad.RequiredUserAgent,
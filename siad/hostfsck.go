@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/host"
+)
+
+var (
+	// hostFsckRepair is set by the --repair flag of 'siad host fsck'.
+	hostFsckRepair bool
+)
+
+// hostFsckCmd is a cobra command that checks the host's storage for
+// inconsistencies without starting the daemon.
+func hostFsckCmd(*cobra.Command, []string) {
+	report, err := host.CheckStorage(filepath.Join(globalConfig.Siad.SiaDir, modules.HostDir), hostFsckRepair)
+	if err != nil {
+		die("Could not check host storage:", err)
+	}
+
+	for _, check := range report.Folders {
+		fmt.Printf("Storage folder %v (%v):\n", check.Index, check.Path)
+		if check.Unavailable {
+			fmt.Println("\tunavailable, not checked")
+			continue
+		}
+		if check.MetadataSize != check.ExpectedMetadataSize {
+			fmt.Printf("\tmetadata file is %v bytes, expected %v\n", check.MetadataSize, check.ExpectedMetadataSize)
+		}
+		if check.DataSize != check.ExpectedDataSize {
+			fmt.Printf("\tdata file is %v bytes, expected %v\n", check.DataSize, check.ExpectedDataSize)
+		}
+		fmt.Printf("\t%v used slots, %v orphaned, %v doubly allocated, %v dangling locations, %v truncated\n",
+			check.UsedSlots, check.OrphanedSlots, check.DoublyAllocatedSlots, check.DanglingLocations, check.TruncatedSlots)
+	}
+	for _, issue := range report.Issues {
+		referenced := ""
+		if issue.Referenced {
+			referenced = " (referenced by a storage obligation)"
+		}
+		fmt.Printf("Folder %v, slot %v: %v%v\n", issue.StorageFolder, issue.Index, issue.Problem, referenced)
+	}
+	fmt.Printf("%v sectors missing, %v sectors not referenced by any obligation, %v sectors with a mismatched reference count\n",
+		len(report.MissingSectors), report.UnreferencedSectors, report.CountMismatches)
+	for _, root := range report.MissingSectors {
+		fmt.Println("Missing sector:", root)
+	}
+
+	if report.Repaired {
+		fmt.Printf("Rebuilt the sector location map: %v sectors recovered, %v sectors dropped.\n", report.SectorsRecovered, report.SectorsDropped)
+	} else if report.Consistent {
+		fmt.Println("No inconsistencies found.")
+	} else {
+		fmt.Println("Inconsistencies found. Run with --repair to rebuild the sector location map.")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
+)
+
+// hostWALCmd is a cobra command that lists the changes in the write-ahead log
+// of the host's storage manager without applying them.
+func hostWALCmd(*cobra.Command, []string) {
+	dir := filepath.Join(globalConfig.Siad.SiaDir, modules.HostDir, modules.ContractManagerDir)
+	inspection, err := contractmanager.InspectWAL(dir)
+	if !inspection.Exists && err == nil {
+		fmt.Println("No write-ahead log found; the host was shut down cleanly.")
+		return
+	}
+
+	fmt.Printf("Write-ahead log %v: %v bytes, %v changes\n", inspection.Path, inspection.Size, inspection.TotalChanges)
+	for i, wc := range inspection.Changes {
+		var ops []string
+		for _, op := range wc.Operations {
+			ops = append(ops, fmt.Sprintf("%v x%v (folders %v)", op.Type, op.Count, op.Folders))
+		}
+		fmt.Printf("Change %v, %v bytes: %v\n", i+1, wc.Size, strings.Join(ops, ", "))
+	}
+	if len(inspection.Changes) == 0 {
+		fmt.Println("No pending operations.")
+	} else {
+		fmt.Println("Affected storage folders:", inspection.Folders)
+	}
+	if err != nil {
+		die("Could not read the complete write-ahead log:", err)
+	}
+}
@@ -156,6 +156,35 @@ func main() {
 		Run:   modulesCmd,
 	})
 
+	hostCmd := &cobra.Command{
+		Use:   "host",
+		Short: "Perform offline maintenance on the host",
+		Long:  "Perform offline maintenance on the host. siad must not be running.",
+	}
+	fsckCmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the host's storage for inconsistencies",
+		Long: `Check the metadata of every storage folder against its data file and the
+sector location map, looking for orphaned and doubly allocated sector slots,
+and cross-check the stored sectors against the host's storage obligations.
+With --repair, the sector location map is rebuilt from the metadata on disk.`,
+		Run: hostFsckCmd,
+	}
+	fsckCmd.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	fsckCmd.Flags().BoolVarP(&hostFsckRepair, "repair", "", false, "rebuild the sector location map")
+	walCmd := &cobra.Command{
+		Use:   "wal",
+		Short: "Inspect the write-ahead log of the host's storage manager",
+		Long: `List the changes in the write-ahead log of the host's storage manager that
+will be replayed at startup, with their sizes and the storage folders they
+affect. The log is only read, so it can also be inspected while siad is
+running or hanging at startup.`,
+		Run: hostWALCmd,
+	}
+	walCmd.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	hostCmd.AddCommand(fsckCmd, walCmd)
+	root.AddCommand(hostCmd)
+
 	// Set default values, which have the lowest priority.
 	root.Flags().StringVarP(&globalConfig.Siad.RequiredUserAgent, "agent", "", "Sia-Agent", "required substring for the user agent")
 	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on")